@@ -0,0 +1,280 @@
+package blulang
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Analysis is what an editor needs to know about a parsed program: the declaration every name
+// refers to, the functions it declares and the problems the linter finds in it.
+// Positions given to its methods are the ones of any letter of a name, or just after its last one.
+type Analysis struct {
+	program     Program
+	diagnostics []LintDiagnostic
+	declared    []*binding
+	refers      map[int]*binding
+	// builtins maps the names of the builtins in the languages of the program to their English name
+	builtins map[string]string
+}
+
+// Analyze resolves the names of a program, which may be the partial program of ParseTolerant
+func Analyze(program Program) *Analysis {
+	if len(program.locales) == 0 {
+		program.locales = DefaultLocales()
+	}
+	a := &Analysis{program: program, builtins: make(map[string]string)}
+	l := &linter{tokens: program.tokens, builtins: make(map[string]bool), refers: make(map[int]*binding)}
+	l.scope = &nameScope{names: make(map[string]*binding)}
+	for _, locale := range program.locales {
+		for _, name := range builtinNames() {
+			localized := locale.builtinName(name)
+			a.builtins[localized] = name
+			l.builtins[localized] = true
+			l.scope.names[localized] = &binding{name: localized, kind: bindBuiltin, token: -1, used: true}
+		}
+	}
+	l.run(program.body)
+	a.diagnostics, a.declared, a.refers = l.diagnostics, l.declared, l.refers
+	return a
+}
+
+// nameAt is the index of the token of the name at a position, or -1
+func (a *Analysis) nameAt(pos Position) int {
+	for i, token := range a.program.tokens {
+		if token.name == TkIdentifier && token.pos.Line == pos.Line &&
+			token.pos.Column <= pos.Column && pos.Column <= token.pos.Column+token.end-token.start {
+			return i
+		}
+	}
+	return -1
+}
+
+// Definition is the position where the name at a position is declared, builtins have none
+func (a *Analysis) Definition(pos Position) (Position, bool) {
+	binding := a.refers[a.nameAt(pos)]
+	if binding == nil || binding.kind == bindBuiltin {
+		return Position{}, false
+	}
+	return binding.pos, true
+}
+
+// References are the positions of every use of the name at a position in the order of
+// the source, its declaration included
+func (a *Analysis) References(pos Position) []Position {
+	binding := a.refers[a.nameAt(pos)]
+	if binding == nil {
+		return nil
+	}
+	var tokens []int
+	for token, refers := range a.refers {
+		if refers == binding && token < len(a.program.tokens) {
+			tokens = append(tokens, token)
+		}
+	}
+	sort.Ints(tokens)
+	positions := make([]Position, len(tokens))
+	for i, token := range tokens {
+		positions[i] = a.program.tokens[token].pos
+	}
+	return positions
+}
+
+// Hover describes the name at a position: the kind of value of a builtin, or the declaration
+// of a name followed by the comments written above it
+func (a *Analysis) Hover(pos Position) (string, bool) {
+	binding := a.refers[a.nameAt(pos)]
+	if binding == nil {
+		return "", false
+	}
+	if binding.kind == bindBuiltin {
+		english := a.builtins[binding.name]
+		if english != binding.name {
+			return fmt.Sprintf("%s (%s) :: %s", binding.name, english, builtinKind(english)), true
+		}
+		return fmt.Sprintf("%s :: %s", binding.name, builtinKind(english)), true
+	}
+	description := a.signature(binding)
+	if doc := a.doc(binding); doc != "" {
+		description += "\n\n" + doc
+	}
+	return description, true
+}
+
+// builtinKind is the kind of value a builtin of the global scope holds
+func builtinKind(name string) ValueType {
+	if name == "args" {
+		return VaArrayVal
+	}
+	return newBuiltins(Environment{Capabilities: AllCapabilities})[name].Kind()
+}
+
+// keyword is the keyword a statement starts with, as it is written
+func (a *Analysis) keyword(statement Statement) string {
+	return a.program.tokens[startToken(statement)].value
+}
+
+// signature is the line declaring a binding, such as "fn add(a, b)"
+func (a *Analysis) signature(b *binding) string {
+	switch statement := b.statement.(type) {
+	case VarDeclareExpression:
+		if function, ok := statement.valueExpr.(FuncDeclareExpression); ok {
+			return fmt.Sprintf("%s %s = %s", a.keyword(statement), statement.name, a.signature(&binding{statement: function}))
+		}
+		return a.keyword(statement) + " " + statement.name
+	case FuncDeclareExpression:
+		parameters := make([]string, len(statement.arguments))
+		for i, parameter := range statement.arguments {
+			parameters[i] = parameter.name
+		}
+		signature := fmt.Sprintf("%s %s(%s)", a.keyword(statement), statement.name, strings.Join(parameters, ", "))
+		if statement.name == "" {
+			signature = fmt.Sprintf("%s (%s)", a.keyword(statement), strings.Join(parameters, ", "))
+		}
+		if b.kind == bindParameter {
+			return fmt.Sprintf("%s: parameter of %s", b.name, signature)
+		}
+		return signature
+	case ImportExpr:
+		start := startToken(statement)
+		if b.token < len(a.program.tokens) {
+			return fmt.Sprintf("%s %q %s %s", a.keyword(statement), statement.path, a.program.tokens[start+2].value, statement.alias)
+		}
+		return fmt.Sprintf("%s %q", a.keyword(statement), statement.path)
+	}
+	return b.name
+}
+
+// doc is the text of the comments written on the lines above a declaration
+func (a *Analysis) doc(b *binding) string {
+	if b.kind == bindParameter || b.kind == bindImport {
+		return ""
+	}
+	tokens := a.program.tokens
+	start := startToken(b.statement)
+	if start > 0 && tokens[start-1].name == TkExport {
+		start--
+	}
+	var lines []string
+	for _, comment := range tokens[start].comments {
+		// a comment on the line of the previous token belongs to it, directives aren't documentation
+		if start > 0 && comment.Pos.Line == tokens[start-1].pos.Line ||
+			localeHeader.MatchString(comment.Text) || lintComment.MatchString(comment.Text) {
+			continue
+		}
+		lines = append(lines, strings.TrimSpace(strings.TrimPrefix(comment.Text, ";")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Symbol is a function declared by a program, by a function declaration or
+// by a variable holding a function
+type Symbol struct {
+	Name string
+	// Detail is the line declaring the function, such as "fn add(a, b)"
+	Detail string
+	// Pos is the position of the name, Start and End the ones of the declaration,
+	// End being just after its closing brace
+	Pos   Position
+	Start Position
+	End   Position
+}
+
+// Symbols are the functions declared by the program in the order of the source
+func (a *Analysis) Symbols() []Symbol {
+	var symbols []Symbol
+	for _, binding := range a.declared {
+		function, isFunction := binding.function()
+		if !isFunction {
+			continue
+		}
+		symbols = append(symbols, Symbol{
+			Name:   binding.name,
+			Detail: a.signature(binding),
+			Pos:    binding.pos,
+			Start:  binding.statement.Pos(),
+			End:    a.functionEnd(function),
+		})
+	}
+	sort.SliceStable(symbols, func(i, j int) bool {
+		a, b := symbols[i].Start, symbols[j].Start
+		return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+	})
+	return symbols
+}
+
+// function is the function a binding declares, by its declaration or as the value of a variable
+func (b *binding) function() (FuncDeclareExpression, bool) {
+	switch statement := b.statement.(type) {
+	case FuncDeclareExpression:
+		return statement, b.kind == bindFunction
+	case VarDeclareExpression:
+		function, ok := statement.valueExpr.(FuncDeclareExpression)
+		return function, ok
+	}
+	return FuncDeclareExpression{}, false
+}
+
+// functionEnd is the position just after the brace closing the body of a function
+func (a *Analysis) functionEnd(function FuncDeclareExpression) Position {
+	tokens := a.program.tokens
+	depth := 0
+	for i := startToken(function); i < len(tokens); i++ {
+		switch tokens[i].name {
+		case TkOpenCurly:
+			depth++
+		case TkCloseCurly:
+			depth--
+			if depth == 0 {
+				return Position{Line: tokens[i].pos.Line, Column: tokens[i].pos.Column + 1}
+			}
+		}
+	}
+	return function.Pos()
+}
+
+// CompletionKind tells what a completion is
+type CompletionKind int
+
+const (
+	CompleteKeyword CompletionKind = iota
+	CompleteBuiltin
+	CompleteVariable
+	CompleteFunction
+)
+
+// Completion is a word an editor can offer while a name or a keyword of the program is typed
+type Completion struct {
+	Label  string
+	Kind   CompletionKind
+	Detail string
+}
+
+// Completions are the keywords of the languages of the program, the builtins and
+// the names the program declares
+func (a *Analysis) Completions() []Completion {
+	var completions []Completion
+	seen := make(map[string]bool)
+	add := func(completion Completion) {
+		if !seen[completion.Label] {
+			seen[completion.Label] = true
+			completions = append(completions, completion)
+		}
+	}
+	for _, locale := range a.program.locales {
+		for _, keyword := range sortedKeys(locale.Keywords) {
+			add(Completion{Label: keyword, Kind: CompleteKeyword, Detail: locale.Name})
+		}
+	}
+	for _, name := range sortedKeys(a.builtins) {
+		add(Completion{Label: name, Kind: CompleteBuiltin, Detail: string(builtinKind(a.builtins[name]))})
+	}
+	for _, binding := range a.declared {
+		kind := CompleteVariable
+		if _, isFunction := binding.function(); isFunction {
+			kind = CompleteFunction
+		}
+		add(Completion{Label: binding.name, Kind: kind, Detail: a.signature(binding)})
+	}
+	return completions
+}
@@ -0,0 +1,98 @@
+package blulang_test
+
+import (
+	"blulang"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const analysisSource = `; adds two numbers
+fn add(a, b) {
+    a + b
+}
+let twice = fn (x) { add(x, x) }
+import "math" as m
+let total = add(1, 2)
+total = twice(total) + m.pi
+in(total)
+`
+
+func analyze(t *testing.T, source string) *blulang.Analysis {
+	parser := blulang.NewParser()
+	program, errs := parser.ParseTolerant(source)
+	assert.Empty(t, errs)
+	return blulang.Analyze(program)
+}
+
+func TestAnalysisDefinition(t *testing.T) {
+	analysis := analyze(t, analysisSource)
+	pos, found := analysis.Definition(blulang.Position{Line: 7, Column: 15})
+	assert.True(t, found)
+	assert.Equal(t, blulang.Position{Line: 2, Column: 4}, pos)
+	// the end of a name still points at it
+	pos, found = analysis.Definition(blulang.Position{Line: 3, Column: 6})
+	assert.True(t, found)
+	assert.Equal(t, blulang.Position{Line: 2, Column: 8}, pos)
+	pos, found = analysis.Definition(blulang.Position{Line: 8, Column: 25})
+	assert.True(t, found)
+	assert.Equal(t, blulang.Position{Line: 6, Column: 18}, pos)
+
+	_, found = analysis.Definition(blulang.Position{Line: 9, Column: 1})
+	assert.False(t, found, "builtins aren't declared in the source")
+	_, found = analysis.Definition(blulang.Position{Line: 1, Column: 3})
+	assert.False(t, found, "comments hold no names")
+}
+
+func TestAnalysisReferences(t *testing.T) {
+	analysis := analyze(t, analysisSource)
+	assert.Equal(t, []blulang.Position{{Line: 7, Column: 5}, {Line: 8, Column: 1}, {Line: 8, Column: 15}, {Line: 9, Column: 4}},
+		analysis.References(blulang.Position{Line: 9, Column: 5}))
+	assert.Equal(t, []blulang.Position{{Line: 2, Column: 4}, {Line: 5, Column: 22}, {Line: 7, Column: 13}},
+		analysis.References(blulang.Position{Line: 2, Column: 4}))
+	assert.Nil(t, analysis.References(blulang.Position{Line: 3, Column: 7}))
+}
+
+func TestAnalysisHover(t *testing.T) {
+	analysis := analyze(t, analysisSource)
+	hovers := map[blulang.Position]string{
+		{Line: 7, Column: 13}: "fn add(a, b)\n\nadds two numbers",
+		{Line: 3, Column: 5}:  "a: parameter of fn add(a, b)",
+		{Line: 8, Column: 10}: "let twice = fn (x)",
+		{Line: 8, Column: 24}: "import \"math\" as m",
+		{Line: 9, Column: 1}:  "in (print) :: NativeFuncVal",
+	}
+	for pos, expected := range hovers {
+		hover, found := analysis.Hover(pos)
+		assert.Truef(t, found, "%v", pos)
+		assert.Equalf(t, expected, hover, "%v", pos)
+	}
+	hover, _ := analyze(t, "true").Hover(blulang.Position{Line: 1, Column: 1})
+	assert.Equal(t, "true :: BoolVal", hover)
+}
+
+func TestAnalysisSymbols(t *testing.T) {
+	analysis := analyze(t, analysisSource)
+	assert.Equal(t, []blulang.Symbol{
+		{Name: "add", Detail: "fn add(a, b)", Pos: blulang.Position{Line: 2, Column: 4},
+			Start: blulang.Position{Line: 2, Column: 1}, End: blulang.Position{Line: 4, Column: 2}},
+		{Name: "twice", Detail: "let twice = fn (x)", Pos: blulang.Position{Line: 5, Column: 5},
+			Start: blulang.Position{Line: 5, Column: 1}, End: blulang.Position{Line: 5, Column: 33}},
+	}, analysis.Symbols())
+}
+
+func TestAnalysisCompletions(t *testing.T) {
+	labels := make(map[string]blulang.CompletionKind)
+	for _, completion := range analyze(t, analysisSource).Completions() {
+		labels[completion.Label] = completion.Kind
+	}
+	assert.Equal(t, blulang.CompleteKeyword, labels["fn"])
+	assert.Equal(t, blulang.CompleteKeyword, labels["hàm"])
+	assert.Equal(t, blulang.CompleteBuiltin, labels["print"])
+	assert.Equal(t, blulang.CompleteBuiltin, labels["in"])
+	assert.Equal(t, blulang.CompleteFunction, labels["twice"])
+	assert.Equal(t, blulang.CompleteVariable, labels["total"])
+
+	for _, completion := range analyze(t, "; lang: vi\nhàm f() { 1 }").Completions() {
+		assert.NotEqual(t, "fn", completion.Label, "a Vietnamese file only completes Vietnamese keywords")
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package blulang
 
 type StmtType string
 
@@ -6,8 +6,10 @@ const (
 	StmtBinaryExpr      StmtType = "BinaryExpr"
 	StmtProgram         StmtType = "Program"
 	StmtIntLiteral      StmtType = "IntLiteral"
+	StmtFloatLiteral    StmtType = "FloatLiteral"
 	StmtStringLiteral   StmtType = "StringLiteral"
 	StmtNullLiteral     StmtType = "NullLiteral"
+	StmtBoolLiteral     StmtType = "BoolLiteral"
 	StmtVarDeclareExpr  StmtType = "VarDeclareExpr"
 	StmtFuncDeclareExpr StmtType = "FuncDeclareExpr"
 	StmtFuncCallExpr    StmtType = "FuncCallExpr"
@@ -20,18 +22,64 @@ const (
 	StmtArrayAccessExpr StmtType = "ArrayAccessExpr"
 	StmtObjDeclareExpr  StmtType = "ObjectDeclareExpr"
 	StmtObjAccessExpr   StmtType = "ObjectAccessExpr"
+	StmtImportExpr      StmtType = "ImportExpr"
+	StmtExportExpr      StmtType = "ExportExpr"
+	StmtTestBlock       StmtType = "TestBlock"
 )
 
 type Statement interface {
 	Kind() StmtType
+	// Pos is the position of the first token of the statement
+	Pos() Position
+	// End is the position just after the last token of the statement
+	End() Position
 }
 
 type Expression interface {
 	Statement
 }
 
+// node is embedded in every statement, it records where the parser found the statement
+type node struct {
+	pos Position
+	// token is the index of the first token of the statement in the program,
+	// the parentheses grouping the statement come before it
+	token int
+	end   Position
+	// comments are the comments written right before the statement
+	comments []Comment
+}
+
+func (n node) Pos() Position {
+	return n.pos
+}
+
+func (n node) End() Position {
+	return n.end
+}
+
+func (n node) startToken() int {
+	return n.token
+}
+
+// Comments are the comments written right before the statement, such as the description of a function
+func (n node) Comments() []Comment {
+	return n.comments
+}
+
 type Program struct {
+	node
 	body []Statement
+	// tokens are the tokens the program was parsed from, they carry the comments
+	// and comments are the ones following the last token
+	tokens   []Token
+	comments []Comment
+	// locales are the language packs the program is written with
+	locales []*Locale
+	// ends are the indexes of the tokens following each statement of the body,
+	// and clean is the number of statements parsed before the first syntax error
+	ends  []int
+	clean int
 }
 
 func NewProgram() Program {
@@ -45,6 +93,7 @@ func (p Program) Kind() StmtType {
 }
 
 type WhileLoopExpression struct {
+	node
 	condition Expression
 	body      []Statement
 }
@@ -60,19 +109,24 @@ func NewWhileLoopExpression(condition Expression, body []Statement) WhileLoopExp
 	}
 }
 
-type BreakStatement struct{}
+type BreakStatement struct {
+	node
+}
 
 func (s BreakStatement) Kind() StmtType { return StmtBreak }
 
 func NewBreakStatement() BreakStatement { return BreakStatement{} }
 
-type ReturnStatement struct{}
+type ReturnStatement struct {
+	node
+}
 
 func (s ReturnStatement) Kind() StmtType { return StmtReturn }
 
 func NewReturnStatement() ReturnStatement { return ReturnStatement{} }
 
 type ConditionalExpression struct {
+	node
 	condition Expression
 	trueBody  []Statement
 	falseBody []Statement
@@ -91,6 +145,7 @@ func NewConditionalExpression(condition Expression, trueBody []Statement, falseB
 }
 
 type BinaryExpression struct {
+	node
 	left     Expression
 	right    Expression
 	operator string
@@ -109,6 +164,7 @@ func (b BinaryExpression) Kind() StmtType {
 }
 
 type VarDeclareExpression struct {
+	node
 	name      string
 	valueExpr Expression
 }
@@ -125,6 +181,7 @@ func NewVarDeclareExpression(name string, value Expression) VarDeclareExpression
 }
 
 type FuncDeclareExpression struct {
+	node
 	name      string
 	arguments []Identifier
 	body      []Statement
@@ -143,6 +200,7 @@ func NewFuncDeclareExpression(name string, arguments []Identifier, body []Statem
 }
 
 type FuncCallExpression struct {
+	node
 	name      string
 	arguments []Expression
 }
@@ -159,6 +217,7 @@ func NewFuncCallExpression(name string, arguments []Expression) FuncCallExpressi
 }
 
 type IntLiteral struct {
+	node
 	value int
 }
 
@@ -172,7 +231,21 @@ func NewIntLiteral(value int) IntLiteral {
 	}
 }
 
+type FloatLiteral struct {
+	node
+	value float64
+}
+
+func (l FloatLiteral) Kind() StmtType {
+	return StmtFloatLiteral
+}
+
+func NewFloatLiteral(value float64) FloatLiteral {
+	return FloatLiteral{value: value}
+}
+
 type StringLiteral struct {
+	node
 	value string
 }
 
@@ -184,13 +257,31 @@ func NewStringLiteral(value string) StringLiteral {
 	return StringLiteral{value: value}
 }
 
-type NullLiteral struct{}
+type NullLiteral struct {
+	node
+}
 
 func (n NullLiteral) Kind() StmtType {
 	return StmtNullLiteral
 }
 
+// BoolLiteral is a boolean written by the parser, such as the one '!' compares its operand to,
+// the source only has the true and false builtins
+type BoolLiteral struct {
+	node
+	value bool
+}
+
+func (b BoolLiteral) Kind() StmtType {
+	return StmtBoolLiteral
+}
+
+func NewBoolLiteral(value bool) BoolLiteral {
+	return BoolLiteral{value: value}
+}
+
 type Identifier struct {
+	node
 	name string
 }
 
@@ -203,6 +294,7 @@ func NewIdentifier(name string) Identifier {
 }
 
 type ArrayLiteral struct {
+	node
 	values []Expression
 }
 
@@ -215,6 +307,7 @@ func NewArrayLiteral(values []Expression) ArrayLiteral {
 }
 
 type ArrayAccessExpr struct {
+	node
 	name  string
 	index Expression
 }
@@ -228,18 +321,22 @@ func NewArrayAccessExpr(name string, index Expression) ArrayAccessExpr {
 }
 
 type ObjectDeclareExpr struct {
-	props map[string]Expression
+	node
+	// names and values of the properties in the order they are written
+	names  []string
+	values []Expression
 }
 
 func (e ObjectDeclareExpr) Kind() StmtType {
 	return StmtObjDeclareExpr
 }
 
-func NewObjectDeclareExpr(props map[string]Expression) ObjectDeclareExpr {
-	return ObjectDeclareExpr{props: props}
+func NewObjectDeclareExpr(names []string, values []Expression) ObjectDeclareExpr {
+	return ObjectDeclareExpr{names: names, values: values}
 }
 
 type ObjectAccessExpr struct {
+	node
 	owner    Identifier
 	property Expression
 }
@@ -249,3 +346,55 @@ func (e ObjectAccessExpr) Kind() StmtType { return StmtObjAccessExpr }
 func NewObjectAccessExpr(owner Identifier, property Expression) ObjectAccessExpr {
 	return ObjectAccessExpr{owner: owner, property: property}
 }
+
+type ImportExpr struct {
+	node
+	path  string
+	alias string
+}
+
+func (e ImportExpr) Kind() StmtType { return StmtImportExpr }
+
+func NewImportExpr(path string, alias string) ImportExpr {
+	return ImportExpr{path: path, alias: alias}
+}
+
+type ExportExpr struct {
+	node
+	declaration Expression
+}
+
+func (e ExportExpr) Kind() StmtType { return StmtExportExpr }
+
+func NewExportExpr(declaration Expression) ExportExpr {
+	return ExportExpr{declaration: declaration}
+}
+
+// TestBlock is a named test of a program, runs skip it unless they select it, see Options.Test
+type TestBlock struct {
+	node
+	name string
+	body []Statement
+}
+
+func (t TestBlock) Kind() StmtType { return StmtTestBlock }
+
+func NewTestBlock(name string, body []Statement) TestBlock {
+	return TestBlock{name: name, body: body}
+}
+
+// Name is the name the test is written with
+func (t TestBlock) Name() string {
+	return t.name
+}
+
+// Tests are the test blocks of the program in the order they are written
+func (p Program) Tests() []TestBlock {
+	var tests []TestBlock
+	for _, statement := range p.body {
+		if test, ok := statement.(TestBlock); ok {
+			tests = append(tests, test)
+		}
+	}
+	return tests
+}
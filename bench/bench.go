@@ -0,0 +1,130 @@
+// Package bench is the benchmark suite of the evaluator. Its programs stress what an evaluator
+// design changes the cost of: recursive calls, nested loops and the scope of each block, arrays
+// built with arr = arr + [x], objects and strings. They are the Go benchmarks of the package and
+// what blulang bench runs, both evaluating a parsed program with Eval in a new global scope, and
+// results are written in the format of go test -bench so that a saved baseline can be compared
+// to a later run, by Compare or by benchstat.
+package bench
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"blulang"
+)
+
+//go:embed programs/*.blu
+var programs embed.FS
+
+// Program is a parsed program to benchmark
+type Program struct {
+	// Name is the name of the file without its extension
+	Name string
+	// Expected is the value the program evaluates to as blulang.Inspect shows it, given by
+	// a "; result:" comment line, empty when the program doesn't give it
+	Expected string
+	program  blulang.Program
+}
+
+// Programs are the programs of the suite, sorted by name
+func Programs() ([]Program, error) {
+	entries, err := programs.ReadDir("programs")
+	if err != nil {
+		return nil, err
+	}
+	var suite []Program
+	for _, entry := range entries {
+		source, err := programs.ReadFile(path.Join("programs", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		program, err := parse(entry.Name(), string(source))
+		if err != nil {
+			return nil, err
+		}
+		suite = append(suite, program)
+	}
+	return suite, nil
+}
+
+// Load reads a program to benchmark from a file. It runs without capabilities, so it can
+// only import the standard library.
+func Load(file string) (Program, error) {
+	source, err := os.ReadFile(file)
+	if err != nil {
+		return Program{}, err
+	}
+	return parse(filepath.Base(file), string(source))
+}
+
+func parse(file string, source string) (Program, error) {
+	program := Program{Name: strings.TrimSuffix(file, filepath.Ext(file))}
+	for _, line := range strings.Split(source, "\n") {
+		if expected, found := strings.CutPrefix(strings.TrimSpace(line), "; result:"); found {
+			program.Expected = strings.TrimSpace(expected)
+			break
+		}
+	}
+	parser := blulang.NewParser()
+	var err error
+	program.program, err = parser.Parse(source)
+	if err != nil {
+		return Program{}, err
+	}
+	return program, nil
+}
+
+// Eval evaluates the program once in a new global scope without capabilities
+func (p Program) Eval() (blulang.RuntimeVal, error) {
+	scope := blulang.NewGlobalScopeWith(blulang.Environment{Capabilities: blulang.NoCapabilities})
+	return blulang.Execute(p.program, scope)
+}
+
+// Check evaluates the program once and compares its value to the expected one, so that
+// an evaluator getting faster by getting a program wrong is noticed
+func (p Program) Check() error {
+	result, err := p.Eval()
+	if err != nil {
+		return err
+	}
+	if p.Expected != "" && blulang.Inspect(result) != p.Expected {
+		return fmt.Errorf("evaluated to %s instead of %s", blulang.Inspect(result), p.Expected)
+	}
+	return nil
+}
+
+// Benchmark evaluates the program b.N times, it is the sub-benchmark of BenchmarkEval running it
+func (p Program) Benchmark(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.Eval(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// Run benchmarks the program for about a second, its result is named like the sub-benchmark
+// of BenchmarkEval running it
+func (p Program) Run() (Result, error) {
+	if err := p.Check(); err != nil {
+		return Result{}, err
+	}
+	// a run failing in Benchmark gives an empty result, Check tells why a program fails
+	result := testing.Benchmark(p.Benchmark)
+	if result.N == 0 {
+		return Result{}, errors.New("the benchmark didn't run")
+	}
+	return Result{
+		Name:        "BenchmarkEval/" + p.Name,
+		N:           result.N,
+		NsPerOp:     float64(result.T.Nanoseconds()) / float64(result.N),
+		BytesPerOp:  result.AllocedBytesPerOp(),
+		AllocsPerOp: result.AllocsPerOp(),
+	}, nil
+}
@@ -0,0 +1,93 @@
+package bench_test
+
+import (
+	"blulang/bench"
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func BenchmarkEval(b *testing.B) {
+	programs, err := bench.Programs()
+	require.NoError(b, err)
+	for _, program := range programs {
+		b.Run(program.Name, program.Benchmark)
+	}
+}
+
+func TestPrograms(t *testing.T) {
+	programs, err := bench.Programs()
+	require.NoError(t, err)
+	var names []string
+	for _, program := range programs {
+		names = append(names, program.Name)
+		assert.NotEmpty(t, program.Expected, program.Name)
+		assert.NoError(t, program.Check(), program.Name)
+	}
+	assert.Equal(t, []string{"arrays", "fib", "loops", "objects", "strings"}, names)
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sum.blu")
+	require.NoError(t, os.WriteFile(path, []byte("; result: 4\nlet a = [1, 3]\na[0] + a[1]\n"), 0o644))
+	program, err := bench.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "sum", program.Name)
+	assert.NoError(t, program.Check())
+
+	require.NoError(t, os.WriteFile(path, []byte("; result: 5\n2 + 2\n"), 0o644))
+	program, err = bench.Load(path)
+	require.NoError(t, err)
+	assert.EqualError(t, program.Check(), "evaluated to 4 instead of 5")
+
+	require.NoError(t, os.WriteFile(path, []byte("1 / 0\n"), 0o644))
+	program, err = bench.Load(path)
+	require.NoError(t, err)
+	assert.EqualError(t, program.Check(), "runtime error: division by zero")
+}
+
+func TestReadResults(t *testing.T) {
+	results := []bench.Result{
+		{Name: "BenchmarkEval/fib", N: 120, NsPerOp: 9876543, BytesPerOp: 4096, AllocsPerOp: 77},
+		{Name: "BenchmarkEval/loops", N: 3000, NsPerOp: 401234, BytesPerOp: 512, AllocsPerOp: 9},
+	}
+	var out bytes.Buffer
+	require.NoError(t, bench.WriteResults(&out, results))
+	assert.Equal(t, "BenchmarkEval/fib\t120\t9876543 ns/op\t4096 B/op\t77 allocs/op\n"+
+		"BenchmarkEval/loops\t3000\t401234 ns/op\t512 B/op\t9 allocs/op\n", out.String())
+
+	// go test -bench adds a header, GOMAXPROCS to the names and a summary
+	read, err := bench.ReadResults(strings.NewReader("goos: linux\npkg: blulang/bench\n" +
+		"BenchmarkEval/fib-8         \t     100\t   9999999 ns/op\t    4000 B/op\t      70 allocs/op\n" +
+		out.String() + "PASS\nok  \tblulang/bench\t3.2s\n"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bench.Result{"BenchmarkEval/fib": results[0], "BenchmarkEval/loops": results[1]}, read)
+}
+
+func TestCompare(t *testing.T) {
+	baseline := map[string]bench.Result{
+		"BenchmarkEval/fib":   {Name: "BenchmarkEval/fib", NsPerOp: 1000, AllocsPerOp: 100},
+		"BenchmarkEval/loops": {Name: "BenchmarkEval/loops", NsPerOp: 1000, AllocsPerOp: 10},
+	}
+	results := []bench.Result{
+		{Name: "BenchmarkEval/fib", NsPerOp: 1250, AllocsPerOp: 50},
+		{Name: "BenchmarkEval/loops", NsPerOp: 1050, AllocsPerOp: 10},
+		{Name: "BenchmarkEval/objects", NsPerOp: 700, AllocsPerOp: 3},
+	}
+	var out bytes.Buffer
+	slower, err := bench.Compare(&out, baseline, results, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"BenchmarkEval/fib"}, slower)
+	assert.Regexp(t, `^ *old ns/op +new ns/op +delta +old allocs/op +new allocs/op +delta +benchmark\n`+
+		` *1000 +1250 +\+25\.0% +100 +50 +-50\.0% +BenchmarkEval/fib\n`+
+		` *1000 +1050 +\+5\.0% +10 +10 +\+0\.0% +BenchmarkEval/loops\n`+
+		` *- +700 +- +- +3 +- +BenchmarkEval/objects\n$`, out.String())
+
+	slower, err = bench.Compare(&out, baseline, results, 0)
+	require.NoError(t, err)
+	assert.Empty(t, slower)
+}
@@ -0,0 +1,103 @@
+package bench
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Result is what a benchmark measured
+type Result struct {
+	// Name is the name of the benchmark, BenchmarkEval/ and the name of the program
+	Name        string
+	N           int
+	NsPerOp     float64
+	BytesPerOp  int64
+	AllocsPerOp int64
+}
+
+// String is the line go test -bench writes for the result
+func (r Result) String() string {
+	return fmt.Sprintf("%s\t%d\t%.0f ns/op\t%d B/op\t%d allocs/op", r.Name, r.N, r.NsPerOp, r.BytesPerOp, r.AllocsPerOp)
+}
+
+// WriteResults writes results a line each, in the format of go test -bench
+func WriteResults(w io.Writer, results []Result) error {
+	var out strings.Builder
+	for _, result := range results {
+		fmt.Fprintln(&out, result)
+	}
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// procs is the suffix go test adds to benchmark names when GOMAXPROCS isn't 1
+var procs = regexp.MustCompile(`-\d+$`)
+
+// ReadResults reads the results written by WriteResults or by go test -bench, other lines
+// are skipped and the last result of a benchmark run several times is kept
+func ReadResults(r io.Reader) (map[string]Result, error) {
+	results := make(map[string]Result)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		result := Result{Name: procs.ReplaceAllString(fields[0], "")}
+		var err error
+		if result.N, err = strconv.Atoi(fields[1]); err != nil {
+			continue
+		}
+		for i := 2; i+1 < len(fields); i += 2 {
+			switch fields[i+1] {
+			case "ns/op":
+				result.NsPerOp, err = strconv.ParseFloat(fields[i], 64)
+			case "B/op":
+				result.BytesPerOp, err = strconv.ParseInt(fields[i], 10, 64)
+			case "allocs/op":
+				result.AllocsPerOp, err = strconv.ParseInt(fields[i], 10, 64)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", fields[0], err)
+			}
+		}
+		results[result.Name] = result
+	}
+	return results, scanner.Err()
+}
+
+// Compare writes a table of the change of the results against the baseline, a benchmark
+// missing from the baseline being shown without it. It returns the benchmarks whose time
+// grew by more than threshold percent, none when threshold is 0.
+func Compare(w io.Writer, baseline map[string]Result, results []Result, threshold float64) ([]string, error) {
+	var slower []string
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "old ns/op\tnew ns/op\tdelta\told allocs/op\tnew allocs/op\tdelta\t  benchmark")
+	for _, result := range results {
+		old, found := baseline[result.Name]
+		if !found {
+			fmt.Fprintf(table, "-\t%.0f\t-\t-\t%d\t-\t  %s\n", result.NsPerOp, result.AllocsPerOp, result.Name)
+			continue
+		}
+		delta := change(old.NsPerOp, result.NsPerOp)
+		fmt.Fprintf(table, "%.0f\t%.0f\t%+.1f%%\t%d\t%d\t%+.1f%%\t  %s\n", old.NsPerOp, result.NsPerOp, delta,
+			old.AllocsPerOp, result.AllocsPerOp, change(float64(old.AllocsPerOp), float64(result.AllocsPerOp)), result.Name)
+		if threshold > 0 && delta > threshold {
+			slower = append(slower, result.Name)
+		}
+	}
+	return slower, table.Flush()
+}
+
+// change is the change from old to new in percent
+func change(old float64, new float64) float64 {
+	if old == 0 {
+		return 0
+	}
+	return (new - old) / old * 100
+}
@@ -0,0 +1,151 @@
+// Package blulang is an interpreter for BluLang, a small expression based
+// language with English and Vietnamese syntax that can be embedded in Go programs.
+//
+//	interpreter := blulang.New(blulang.Options{})
+//	result, err := interpreter.Run(ctx, `let a = 10 a * 2`)
+package blulang
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Options configures a new Interpreter
+type Options struct {
+	// Args is exposed to scripts as the 'args' array
+	Args []string
+	// Stdin, Stdout and Stderr are used by the print and input builtins,
+	// they default to the streams of the process
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// Limits bound every Run and Call, see Limits
+	Limits Limits
+	// Capabilities decide which builtins are declared, none are granted by default
+	// so untrusted code can't touch the host unless it is allowed to
+	Capabilities Capability
+	// SearchPaths are looked up for imports that aren't found next to the importing file
+	SearchPaths []string
+	// Locales are the language packs sources may use, English and Vietnamese by default.
+	// The first one gives the language of error messages, a file with a lang header uses its own.
+	Locales []*Locale
+	// Language, when set, gives the language of every error message whatever the source uses
+	Language *Locale
+	// Hook, when set, follows every Run and Call statement by statement, see Hook
+	Hook Hook
+	// Test, when set, is the name of the test blocks Run evaluates, where they are written.
+	// Runs skip tests by default, and the tests of imported modules are always skipped.
+	Test string
+}
+
+// Interpreter evaluates BluLang source, variables declared by one Run are visible to the next
+type Interpreter struct {
+	options     Options
+	globalScope *Scope
+}
+
+func New(options Options) *Interpreter {
+	interpreter := &Interpreter{
+		options: options,
+		globalScope: NewGlobalScopeWith(Environment{
+			Streams:      Streams{Stdin: options.Stdin, Stdout: options.Stdout, Stderr: options.Stderr},
+			Capabilities: options.Capabilities,
+			SearchPaths:  options.SearchPaths,
+			Locales:      options.Locales,
+		}),
+	}
+	var args []RuntimeVal
+	for _, arg := range options.Args {
+		args = append(args, NewStringVal(arg))
+	}
+	interpreter.globalScope.module.declareBuiltin("args", NewArrayVal(args))
+	interpreter.globalScope.module.test = options.Test
+	return interpreter
+}
+
+// Run parses and evaluates the source in the global scope and returns the value of the last statement,
+// a program calling exit stops with an ExitError
+func (in *Interpreter) Run(ctx context.Context, source string) (RuntimeVal, error) {
+	parser := NewLocaleParser(in.options.Locales...)
+	program, err := parser.Parse(source)
+	if err != nil {
+		return NullVal{}, LocalizeError(err, in.messageLocale(parser.Locale()))
+	}
+	in.globalScope.module.useLocale(parser.Locale())
+	result, err := executeHooked(ctx, program, in.globalScope, in.options.Limits, in.options.Hook)
+	return result, LocalizeError(err, in.messageLocale(parser.Locale()))
+}
+
+// messageLocale is the language of error messages for a source using the given locale
+func (in *Interpreter) messageLocale(sourceLocale *Locale) *Locale {
+	if in.options.Language != nil {
+		return in.options.Language
+	}
+	return sourceLocale
+}
+
+// RunFile runs the source of a file, imports in it are relative to the file
+func (in *Interpreter) RunFile(ctx context.Context, path string) (RuntimeVal, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return NullVal{}, err
+	}
+	module := in.globalScope.module
+	previous := module.path
+	module.path, _ = filepath.Abs(path)
+	// the file is being loaded so importing it back is reported as a cycle
+	module.registry.loading = append(module.registry.loading, module.path)
+	defer func() {
+		module.path = previous
+		module.registry.loading = module.registry.loading[:len(module.registry.loading)-1]
+	}()
+	return in.Run(ctx, string(source))
+}
+
+// Set declares a global variable or replaces its value when it already exists
+func (in *Interpreter) Set(name string, value RuntimeVal) {
+	if in.globalScope.variables[name] != nil {
+		in.globalScope.AssignVar(name, value)
+		return
+	}
+	in.globalScope.DeclareVar(name, value)
+}
+
+// Get returns the value of a global variable, null when it isn't declared
+func (in *Interpreter) Get(name string) RuntimeVal {
+	return in.globalScope.GetVarVal(name)
+}
+
+// Call invokes a user or native function declared in the global scope
+func (in *Interpreter) Call(fnName string, args ...RuntimeVal) (RuntimeVal, error) {
+	return in.CallContext(context.Background(), fnName, args...)
+}
+
+// CallContext is like Call but stops when the context is done
+func (in *Interpreter) CallContext(ctx context.Context, fnName string, args ...RuntimeVal) (result RuntimeVal, err error) {
+	if in.options.Limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, in.options.Limits.Timeout)
+		defer cancel()
+	}
+	previous := in.globalScope.exec
+	in.globalScope.exec = newExecution(ctx, in.options.Limits, in.options.Hook)
+	defer func() {
+		in.globalScope.exec = previous
+		if r := recover(); r != nil {
+			result, err = NullVal{}, LocalizeError(recoverError(r), in.messageLocale(in.globalScope.module.locales[0]))
+		}
+	}()
+	switch funcVal := in.globalScope.GetVarVal(fnName).(type) {
+	case FunctionVal, NativeFuncVal:
+		return CallFunction(funcVal, args, in.globalScope), nil
+	}
+	return NullVal{}, LocalizeError(codedError(CodeNotAFunction, fnName), in.messageLocale(in.globalScope.module.locales[0]))
+}
+
+// GlobalScope gives access to the scope shared by every Run
+func (in *Interpreter) GlobalScope() *Scope {
+	return in.globalScope
+}
@@ -0,0 +1,160 @@
+package blulang_test
+
+import (
+	"blulang"
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestInterpreterRun(t *testing.T) {
+	interpreter := blulang.New(blulang.Options{Args: []string{"a", "b"}})
+	_, err := interpreter.Run(context.Background(), "let a = 10")
+	assert.NoError(t, err)
+	result, err := interpreter.Run(context.Background(), "a * 2 + count(args)")
+	assert.NoError(t, err)
+	assert.Equal(t, 22, result.Value())
+}
+
+func TestInterpreterRunError(t *testing.T) {
+	interpreter := blulang.New(blulang.Options{})
+	_, err := interpreter.Run(context.Background(), "let a = ")
+	assert.ErrorAs(t, err, &blulang.SyntaxError{})
+	_, err = interpreter.Run(context.Background(), "let a = 1 let a = 2")
+	assert.ErrorAs(t, err, &blulang.RuntimeError{})
+}
+
+func TestInterpreterSetAndCall(t *testing.T) {
+	interpreter := blulang.New(blulang.Options{})
+	interpreter.Set("limit", blulang.NewIntVal(5))
+	interpreter.Set("limit", blulang.NewIntVal(7))
+	_, err := interpreter.Run(context.Background(), `
+	fn allowed(amount) {
+		amount <= limit
+	}
+	`)
+	assert.NoError(t, err)
+	result, err := interpreter.Call("allowed", blulang.NewIntVal(7))
+	assert.NoError(t, err)
+	assert.Equal(t, true, result.Value())
+	result, err = interpreter.Call("abs", blulang.NewIntVal(-3))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, result.Value())
+	_, err = interpreter.Call("limit")
+	assert.EqualError(t, err, "runtime error: limit is not a function")
+}
+
+func TestInterpreterStreams(t *testing.T) {
+	var stdout1, stdout2, stderr bytes.Buffer
+	first := blulang.New(blulang.Options{Stdin: strings.NewReader("3\n4\nBlu\n"), Stdout: &stdout1, Stderr: &stderr, Capabilities: blulang.CapIO | blulang.CapInput})
+	second := blulang.New(blulang.Options{Stdout: &stdout2, Capabilities: blulang.CapIO})
+	_, err := first.Run(context.Background(), `
+	let a = input()
+	let b = nhập()
+	print(a + b, input())
+	printErr("done")
+	`)
+	assert.NoError(t, err)
+	_, err = second.Run(context.Background(), `in("xin chào")`)
+	assert.NoError(t, err)
+	assert.Equal(t, "7 Blu\n", stdout1.String())
+	assert.Equal(t, "done\n", stderr.String())
+	assert.Equal(t, "xin chào\n", stdout2.String())
+}
+
+func TestCapabilities(t *testing.T) {
+	var stdout bytes.Buffer
+	sandboxed := blulang.New(blulang.Options{Stdout: &stdout, Capabilities: blulang.Untrusted})
+	for _, builtin := range []string{"exit", "readFile", "writeFile", "now", "sleep", "random", "input"} {
+		assert.Equalf(t, blulang.VaNullVal, sandboxed.Get(builtin).Kind(), "%s should not be granted", builtin)
+	}
+	_, err := sandboxed.Run(context.Background(), `print("hi") abs(0 - 1)`)
+	assert.NoError(t, err)
+	assert.Equal(t, "hi\n", stdout.String())
+	// input would read the stdin of the process, the host gives the script its own to read
+	result, err := sandboxed.Run(context.Background(), `input()`)
+	assert.NoError(t, err)
+	assert.Equal(t, blulang.VaNullVal, result.Kind())
+	reading := blulang.New(blulang.Options{Stdin: strings.NewReader("typed\n"), Capabilities: blulang.Untrusted | blulang.CapInput})
+	result, err = reading.Run(context.Background(), `input()`)
+	assert.NoError(t, err)
+	assert.Equal(t, "typed", result.Value())
+
+	trusted := blulang.New(blulang.Options{Capabilities: blulang.CapTime | blulang.CapRandom | blulang.CapFilesystem})
+	result, err = trusted.Run(context.Background(), `let r = random(3) sleep(1) (now() > 0) && (r < 3)`)
+	assert.NoError(t, err)
+	assert.Equal(t, true, result.Value())
+	path := t.TempDir() + "/note.txt"
+	trusted.Set("path", blulang.NewStringVal(path))
+	result, err = trusted.Run(context.Background(), `writeFile(path, "ghi chú") đọcTệp(path)`)
+	assert.NoError(t, err)
+	assert.Equal(t, "ghi chú", result.Value())
+}
+
+func TestExitUnwindsToHost(t *testing.T) {
+	var stdout bytes.Buffer
+	interpreter := blulang.New(blulang.Options{Stdout: &stdout, Capabilities: blulang.CapIO | blulang.CapProcess})
+	_, err := interpreter.Run(context.Background(), `print("before") thoát(3) print("after")`)
+	assert.Equal(t, blulang.ExitError{Code: 3}, err)
+	assert.Equal(t, "before\n", stdout.String())
+}
+
+func TestArrayAccessErrors(t *testing.T) {
+	for source, message := range map[string]string{
+		`let n = 1 n[0]`:                   "runtime error: n is not an array",
+		`let a = [1] a["0"]`:               `runtime error: invalid index "0" of a, an array of 1 elements`,
+		`let a = [1] a[0 - 1] = 2`:         "runtime error: invalid index -1 of a, an array of 1 elements",
+		`let o = { a: [] } o.a[0]`:         "runtime error: invalid index 0 of a, an array of 0 elements",
+		`let a = [1] let b = [a] a[0] = b`: "runtime error: a can't contain itself",
+	} {
+		_, err := blulang.New(blulang.Options{}).Run(context.Background(), source)
+		assert.EqualError(t, err, message, source)
+	}
+}
+
+func TestScriptsShadowBuiltins(t *testing.T) {
+	interpreter := blulang.New(blulang.Options{Args: []string{"a"}, Capabilities: blulang.AllCapabilities})
+	result, err := interpreter.Run(context.Background(), `
+	let now = 5
+	let args = [count(args)]
+	fn assert(value) { value * 2 }
+	cho thamsố = 1
+	[now, args, assert(3), thamsố]
+	`)
+	assert.NoError(t, err)
+	assert.Equal(t, `[5, [1], 6, 1]`, blulang.Inspect(result))
+	// a block may shadow them too, the builtins stay for the rest of the program
+	result, err = interpreter.Run(context.Background(), `
+	fn f() {
+		let abs = 1
+		abs
+	}
+	[f(), abs(0 - 2)]
+	`)
+	assert.NoError(t, err)
+	assert.Equal(t, `[1, 2]`, blulang.Inspect(result))
+}
+
+func TestNotWithoutTrue(t *testing.T) {
+	for _, source := range []string{
+		"let true = 0 let a = 1 == 2 [!a, !(1 == 1)]",
+		"; lang: vi\ncho a = 1 == 2 [!a, !(1 == 1)]",
+	} {
+		result, err := blulang.New(blulang.Options{}).Run(context.Background(), source)
+		assert.NoError(t, err, source)
+		assert.Equal(t, "[true, false]", blulang.Inspect(result), source)
+	}
+}
+
+func TestComparingFunctions(t *testing.T) {
+	for source, operator := range map[string]string{
+		`count == abs`:                           "==",
+		`fn f(a) { a } fn g(a) { a + 1 } f != g`: "!=",
+		`fn f() { 1 } [f] == [f]`:                "==",
+	} {
+		_, err := blulang.New(blulang.Options{}).Run(context.Background(), source)
+		assert.EqualError(t, err, "runtime error: functions can't be compared with "+operator, source)
+	}
+}
@@ -0,0 +1,120 @@
+package blulang
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+)
+
+// Capability is a set of permissions the host grants to the builtins of a global scope
+type Capability uint
+
+const (
+	// CapIO allows print and printErr on the configured streams
+	CapIO Capability = 1 << iota
+	// CapFilesystem allows reading and writing files
+	CapFilesystem
+	// CapProcess allows ending the program with exit
+	CapProcess
+	// CapTime allows reading the clock and sleeping
+	CapTime
+	// CapRandom allows generating random numbers
+	CapRandom
+	// CapInput allows input on the configured stdin, which is the one of the process unless
+	// the host gives its own
+	CapInput
+)
+
+const (
+	NoCapabilities Capability = 0
+	// Untrusted is the locked-down profile for code the host doesn't control, only printing
+	// is allowed. Reading is granted apart with CapInput once the host gives its own stdin.
+	Untrusted       = CapIO
+	AllCapabilities = CapIO | CapFilesystem | CapProcess | CapTime | CapRandom | CapInput
+)
+
+func (c Capability) Has(capability Capability) bool {
+	return c&capability == capability
+}
+
+// Environment is what the builtins of a global scope are allowed to use
+type Environment struct {
+	Streams      Streams
+	Capabilities Capability
+	// SearchPaths are looked up for imports not found relative to the importing file
+	SearchPaths []string
+	// Locales are the language packs whose keywords and builtin names are available,
+	// the first one gives the language of error messages. DefaultLocales are used when empty.
+	Locales []*Locale
+}
+
+func (e Environment) locales() []*Locale {
+	if len(e.Locales) == 0 {
+		return DefaultLocales()
+	}
+	return e.Locales
+}
+
+// ExitError is returned to the host when a program calls exit
+type ExitError struct {
+	Code int
+}
+
+func (e ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+var ExitFunc = NewCheckedFuncVal("exit", 0, 1, func(scope *Scope, args NativeArgs) RuntimeVal {
+	code := 0
+	if args.Len() > 0 {
+		code = args.Int(0)
+	}
+	panic(ExitError{Code: code})
+})
+
+var ReadFileFunc = NewCheckedFuncVal("readFile", 1, 1, func(scope *Scope, args NativeArgs) RuntimeVal {
+	content, err := os.ReadFile(args.String(0))
+	if err != nil {
+		panic(WrapRuntimeError(err))
+	}
+	return NewStringVal(string(content))
+})
+
+var WriteFileFunc = NewCheckedFuncVal("writeFile", 2, 2, func(scope *Scope, args NativeArgs) RuntimeVal {
+	err := os.WriteFile(args.String(0), []byte(args.String(1)), 0644)
+	if err != nil {
+		panic(WrapRuntimeError(err))
+	}
+	return NullVal{}
+})
+
+// NowFunc returns the unix time in milliseconds
+var NowFunc = NewCheckedFuncVal("now", 0, 0, func(scope *Scope, args NativeArgs) RuntimeVal {
+	return NewIntVal(int(time.Now().UnixMilli()))
+})
+
+// SleepFunc pauses for the given milliseconds, it wakes up early when the run is cancelled
+var SleepFunc = NewCheckedFuncVal("sleep", 1, 1, func(scope *Scope, args NativeArgs) RuntimeVal {
+	duration := time.Duration(args.Int(0)) * time.Millisecond
+	ctx := context.Background()
+	if scope.exec != nil {
+		ctx = scope.exec.ctx
+	}
+	select {
+	case <-time.After(duration):
+	case <-ctx.Done():
+		panic(causedError(ctx.Err(), CodeStopped, ctx.Err()))
+	}
+	return NullVal{}
+})
+
+// RandomFunc returns a random integer from 0 up to but not including its argument
+var RandomFunc = NewCheckedFuncVal("random", 1, 1, func(scope *Scope, args NativeArgs) RuntimeVal {
+	limit := args.Int(0)
+	if limit <= 0 {
+		panic(codedError(CodeRandomLimit, limit))
+	}
+	return NewIntVal(rand.Intn(limit))
+})
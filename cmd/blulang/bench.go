@@ -0,0 +1,95 @@
+package main
+
+import (
+	"blulang"
+	"blulang/bench"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// runBench benchmarks the programs of the suite, or the given scripts, writing the results in the
+// format of go test -bench, and compares them to the results saved by an earlier run
+func runBench(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := newFlagSet("bench", stderr)
+	run := flags.String("run", "", "only benchmark the programs whose name matches the regular expression")
+	baselineFile := flags.String("baseline", "", "compare the results to the ones saved in the file")
+	save := flags.String("save", "", "write the results to the file, to compare later runs to them")
+	threshold := flags.Float64("threshold", 0, "fail when a program is slower than in the baseline by more than this percentage")
+	if err := flags.Parse(args); err != nil {
+		return reportUsageError(stderr, nil, err)
+	}
+	language, err := messageLanguage(flags)
+	if err != nil {
+		return reportUsageError(stderr, nil, err)
+	}
+	filter, err := regexp.Compile(*run)
+	if err != nil {
+		return reportUsageError(stderr, language, err)
+	}
+
+	var baseline map[string]bench.Result
+	if *baselineFile != "" {
+		file, err := os.Open(*baselineFile)
+		if err != nil {
+			return reportUsageError(stderr, language, usageError{blulang.CodeReadFile, []any{err}})
+		}
+		baseline, err = bench.ReadResults(file)
+		file.Close()
+		if err != nil {
+			return reportUsageError(stderr, language, usageError{blulang.CodeReadFile, []any{err}})
+		}
+	}
+
+	exitCode := exitOK
+	var programs []bench.Program
+	if flags.NArg() == 0 {
+		programs, err = bench.Programs()
+		if err != nil {
+			return reportError(stderr, language, "bench", err)
+		}
+	}
+	for _, file := range flags.Args() {
+		program, err := bench.Load(file)
+		if err != nil {
+			exitCode = max(exitCode, reportError(stderr, language, file, err))
+			continue
+		}
+		programs = append(programs, program)
+	}
+
+	var results []bench.Result
+	for _, program := range programs {
+		if !filter.MatchString(program.Name) {
+			continue
+		}
+		result, err := program.Run()
+		if err != nil {
+			exitCode = max(exitCode, reportError(stderr, language, program.Name, err))
+			continue
+		}
+		fmt.Fprintln(stdout, result)
+		results = append(results, result)
+	}
+
+	if *save != "" {
+		if err := writeReport(*save, func(w io.Writer) error { return bench.WriteResults(w, results) }); err != nil {
+			return reportUsageError(stderr, language, usageError{blulang.CodeWriteFile, []any{err}})
+		}
+	}
+	if baseline == nil {
+		return exitCode
+	}
+	fmt.Fprintln(stdout)
+	slower, err := bench.Compare(stdout, baseline, results, *threshold)
+	if err != nil {
+		return reportUsageError(stderr, language, err)
+	}
+	if len(slower) > 0 {
+		fmt.Fprintln(stderr, blulang.Localize(language, blulang.CodeSlowerThanBaseline, *threshold, strings.Join(slower, ", ")))
+		exitCode = max(exitCode, exitRuntimeError)
+	}
+	return exitCode
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"blulang"
+	"blulang/debugger"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const debugHelp = `Commands:
+  break, b [file:]line [if condition]  stop before the line, when the condition holds if one is given
+  delete, d [file:]line                remove the breakpoint of the line
+  breakpoints                          list the breakpoints
+  continue, c                          run until the next breakpoint
+  step, s                              stop at the next line, inside the functions it calls
+  next, n                              stop at the next line of this function
+  out, o                               stop after this function returns
+  print, p expression                  evaluate an expression in the scope of the line
+  watch, w expression                  evaluate an expression at every stop
+  unwatch number                       remove a watch expression
+  scopes [frame]                       list the variables of the scopes of a frame, 0 being the current one
+  stack, bt                            list the calls in progress
+  list, l                              show the lines around the current one
+  quit, q                              end the run
+`
+
+// debugSession answers the stops of a run with the commands typed by the user
+type debugSession struct {
+	debugger *debugger.Debugger
+	// file is the absolute path of the debugged script, empty for -e source
+	file     string
+	source   string
+	reader   *bufio.Reader
+	stdout   io.Writer
+	language *blulang.Locale
+	watches  []string
+	// lines caches the lines of the files shown by list
+	lines map[string][]string
+	// done is set once the commands run out, the run then goes on without stopping
+	done bool
+}
+
+// runDebug runs a script under the debugger, it stops on the first line so breakpoints can be set
+func runDebug(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := newFlagSet("debug", stderr)
+	expr := flags.String("e", "", "debug the given source instead of a file")
+	if err := flags.Parse(args); err != nil {
+		return reportUsageError(stderr, nil, err)
+	}
+	language, err := messageLanguage(flags)
+	if err != nil {
+		return reportUsageError(stderr, nil, err)
+	}
+	scriptArgs := flags.Args()
+	// the commands come from stdin so the script can't be read from it
+	fileName, source := "-e", *expr
+	if *expr == "" {
+		if len(scriptArgs) == 0 || scriptArgs[0] == "-" {
+			return reportUsageError(stderr, language, usageError{blulang.CodeNoDebugScript, nil})
+		}
+		fileName, scriptArgs = scriptArgs[0], scriptArgs[1:]
+		if _, source, err = readScript(fileName, nil); err != nil {
+			return reportUsageError(stderr, language, err)
+		}
+	}
+
+	// the reader is shared with the input builtin so lines typed for it aren't read as commands
+	session := &debugSession{
+		source:   source,
+		reader:   bufio.NewReader(stdin),
+		stdout:   stdout,
+		language: language,
+		lines:    make(map[string][]string),
+	}
+	if fileName != "-e" {
+		session.file, _ = filepath.Abs(fileName)
+	}
+	session.debugger = debugger.New(true, session.stopped)
+	interpreter := blulang.New(blulang.Options{
+		Args:         scriptArgs,
+		Stdin:        session.reader,
+		Stdout:       stdout,
+		Stderr:       stderr,
+		Capabilities: blulang.AllCapabilities,
+		SearchPaths:  searchPaths(),
+		Language:     language,
+		Hook:         session.debugger,
+	})
+	if fileName != "-e" {
+		_, err = interpreter.RunFile(context.Background(), fileName)
+	} else {
+		_, err = interpreter.Run(context.Background(), source)
+	}
+	if errors.Is(err, debugger.ErrQuit) {
+		return exitOK
+	}
+	if err != nil {
+		return reportError(stderr, language, fileName, err)
+	}
+	fmt.Fprintln(stdout, "The program ended")
+	return exitOK
+}
+
+// stopped shows where the run stopped and reads commands until one of them resumes it
+func (s *debugSession) stopped(stop *debugger.Stop) debugger.Action {
+	if s.done {
+		return debugger.Continue
+	}
+	frame := stop.Frame()
+	fmt.Fprintf(s.stdout, "Stopped at %s (%s)\n", s.location(frame), stop.Reason)
+	if stop.ConditionErr != nil {
+		fmt.Fprintf(s.stdout, "the condition %q failed: %v\n", stop.Breakpoint.Condition, s.localize(stop.ConditionErr))
+	}
+	s.showLine(frame.File, frame.Pos.Line, true)
+	for i, watch := range s.watches {
+		fmt.Fprintf(s.stdout, "%d: %s = %s\n", i+1, watch, s.evaluate(stop, watch))
+	}
+	for {
+		fmt.Fprint(s.stdout, "(blu) ")
+		line, err := s.reader.ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Fprintln(s.stdout)
+			s.done = true
+			return debugger.Continue
+		}
+		command, argument, _ := strings.Cut(strings.TrimSpace(line), " ")
+		argument = strings.TrimSpace(argument)
+		switch command {
+		case "":
+		case "continue", "c":
+			return debugger.Continue
+		case "step", "s":
+			return debugger.StepIn
+		case "next", "n":
+			return debugger.StepOver
+		case "out", "o":
+			return debugger.StepOut
+		case "quit", "q":
+			return debugger.Quit
+		case "break", "b":
+			s.setBreakpoint(frame, argument)
+		case "delete", "d":
+			file, line, err := s.parseLine(frame, argument)
+			switch {
+			case err != nil:
+				fmt.Fprintln(s.stdout, err)
+			case !s.debugger.RemoveBreakpoint(file, line):
+				fmt.Fprintf(s.stdout, "no breakpoint at %s:%d\n", s.displayPath(file), line)
+			}
+		case "breakpoints":
+			for _, breakpoint := range s.debugger.Breakpoints() {
+				fmt.Fprintf(s.stdout, "%s:%d", s.displayPath(breakpoint.File), breakpoint.Line)
+				if breakpoint.Condition != "" {
+					fmt.Fprintf(s.stdout, " if %s", breakpoint.Condition)
+				}
+				fmt.Fprintln(s.stdout)
+			}
+		case "print", "p":
+			fmt.Fprintln(s.stdout, s.evaluate(stop, argument))
+		case "watch", "w":
+			if argument == "" {
+				fmt.Fprintln(s.stdout, "watch needs an expression")
+				continue
+			}
+			s.watches = append(s.watches, argument)
+			fmt.Fprintf(s.stdout, "%d: %s = %s\n", len(s.watches), argument, s.evaluate(stop, argument))
+		case "unwatch":
+			number, err := strconv.Atoi(argument)
+			if err != nil || number < 1 || number > len(s.watches) {
+				fmt.Fprintf(s.stdout, "no watch %s\n", argument)
+				continue
+			}
+			s.watches = slices.Delete(s.watches, number-1, number)
+		case "scopes":
+			s.showScopes(stop, argument)
+		case "stack", "bt":
+			for i := len(stop.Stack) - 1; i >= 0; i-- {
+				fmt.Fprintf(s.stdout, "#%d %s\n", len(stop.Stack)-1-i, s.location(stop.Stack[i]))
+			}
+		case "list", "l":
+			for line := max(frame.Pos.Line-3, 1); line <= frame.Pos.Line+3; line++ {
+				s.showLine(frame.File, line, line == frame.Pos.Line)
+			}
+		case "help", "h":
+			fmt.Fprint(s.stdout, debugHelp)
+		default:
+			fmt.Fprintf(s.stdout, "unknown command %q, type help to list the commands\n", command)
+		}
+	}
+}
+
+// setBreakpoint adds a breakpoint from an argument such as "12", "lib.blu:3" or "12 if n > 2"
+func (s *debugSession) setBreakpoint(frame blulang.Frame, argument string) {
+	location, condition, _ := strings.Cut(argument, " if ")
+	file, line, err := s.parseLine(frame, strings.TrimSpace(location))
+	if err != nil {
+		fmt.Fprintln(s.stdout, err)
+		return
+	}
+	condition = strings.TrimSpace(condition)
+	s.debugger.AddBreakpoint(debugger.Breakpoint{File: file, Line: line, Condition: condition})
+	fmt.Fprintf(s.stdout, "breakpoint at %s:%d\n", s.displayPath(file), line)
+}
+
+// parseLine reads a [file:]line location, the file defaults to the one of the frame
+func (s *debugSession) parseLine(frame blulang.Frame, location string) (string, int, error) {
+	file := frame.File
+	if name, number, found := strings.Cut(location, ":"); found {
+		file, _ = filepath.Abs(name)
+		location = number
+	}
+	line, err := strconv.Atoi(location)
+	if err != nil || line < 1 {
+		return "", 0, fmt.Errorf("expected a line number such as 12 or lib.blu:3, got %q", location)
+	}
+	return file, line, nil
+}
+
+// showScopes lists the variables of each scope of a frame, from the innermost one to the global scope
+func (s *debugSession) showScopes(stop *debugger.Stop, argument string) {
+	index := 0
+	if argument != "" {
+		var err error
+		if index, err = strconv.Atoi(argument); err != nil || index < 0 || index >= len(stop.Stack) {
+			fmt.Fprintf(s.stdout, "no frame %s, stack lists the frames\n", argument)
+			return
+		}
+	}
+	level := 0
+	for scope := stop.Stack[len(stop.Stack)-1-index].Scope; scope != nil; scope = scope.Parent() {
+		if scope.Parent() == nil {
+			fmt.Fprintln(s.stdout, "global:")
+		} else {
+			fmt.Fprintf(s.stdout, "scope %d:\n", level)
+		}
+		variables := scope.Variables()
+		for _, name := range slices.Sorted(maps.Keys(variables)) {
+			fmt.Fprintf(s.stdout, "  %s = %s\n", name, blulang.Inspect(variables[name]))
+		}
+		level++
+	}
+}
+
+// evaluate shows the value of an expression in the scope of the stopped line, or the error it fails with
+func (s *debugSession) evaluate(stop *debugger.Stop, expression string) string {
+	value, err := stop.Evaluate(expression, 0)
+	if err != nil {
+		return s.localize(err).Error()
+	}
+	return blulang.Inspect(value)
+}
+
+func (s *debugSession) localize(err error) error {
+	if s.language != nil {
+		return blulang.LocalizeError(err, s.language)
+	}
+	return err
+}
+
+func (s *debugSession) location(frame blulang.Frame) string {
+	location := fmt.Sprintf("%s:%d", s.displayPath(frame.File), frame.Pos.Line)
+	if frame.Function != "" {
+		location += " in " + frame.Function
+	}
+	return location
+}
+
+// displayPath shows a file relative to the working directory when it is below it
+func (s *debugSession) displayPath(file string) string {
+	if file == "" {
+		return "-e"
+	}
+	if wd, err := os.Getwd(); err == nil {
+		if relative, err := filepath.Rel(wd, file); err == nil && !strings.HasPrefix(relative, "..") {
+			return relative
+		}
+	}
+	return file
+}
+
+// showLine prints a line of a file with its number and an arrow for the current one,
+// nothing when the file has no such line
+func (s *debugSession) showLine(file string, line int, current bool) {
+	lines, found := s.lines[file]
+	if !found {
+		source := s.source
+		if file != s.file {
+			content, err := os.ReadFile(file)
+			source = string(content)
+			if err != nil {
+				source = ""
+			}
+		}
+		lines = strings.Split(source, "\n")
+		s.lines[file] = lines
+	}
+	if line >= 1 && line <= len(lines) {
+		marker := "  "
+		if current {
+			marker = "->"
+		}
+		fmt.Fprintf(s.stdout, "%s %4d | %s\n", marker, line, lines[line-1])
+	}
+}
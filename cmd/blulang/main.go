@@ -0,0 +1,660 @@
+package main
+
+import (
+	"blulang"
+	"blulang/dap"
+	"blulang/lsp"
+	"blulang/profile"
+	"blulang/trace"
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	exitOK           = 0
+	exitRuntimeError = 1
+	exitUsage        = 2
+	exitSyntaxError  = 3
+	// exitUnformatted is returned by fmt -check when a script isn't in the canonical layout
+	exitUnformatted = 1
+	// exitLintProblems is returned by lint when it reports a problem
+	exitLintProblems = 1
+	// exitServerError is returned by lsp and dap when the editor exits without a shutdown or the connection fails
+	exitServerError = 1
+)
+
+// lintConfigFile is read by lint from the working directory when no -config is given
+const lintConfigFile = ".blulint.json"
+
+const usage = `Usage:
+  blulang [run] [-sandbox] [-profile stacks.folded] [-e source] [file.blu | -] [arguments...]
+  blulang repl
+  blulang check file.blu...
+  blulang ast [-e source] [file.blu | -]
+  blulang translate -to language [-e source] [file.blu | -]
+  blulang fmt [-check | -w] [file.blu... | -]
+  blulang lint [-config file.json] [-rules] [file.blu... | -]
+  blulang lsp [-config file.json]
+  blulang debug [-e source] [file.blu] [arguments...]
+  blulang dap
+  blulang trace [-json] [-e source] [file.blu | -] [arguments...]
+  blulang test [-v] [-junit report.xml] [-cover] [-coverhtml report.html] [-lcov lcov.info] [file.blu | directory...]
+  blulang bench [-run regexp] [-baseline results.txt] [-save results.txt] [-threshold percent] [file.blu...]
+  blulang explain [code]
+
+Commands:
+  run        evaluate a script, '-' or no file reads the script from stdin, -profile reports the
+             calls, time and allocations of its functions and its hot lines, and writes its stacks
+             in the folded format of flame graph tools
+  repl       start an interactive session
+  check      parse scripts and report syntax errors without running them
+  ast        print the syntax tree of a script
+  translate  rewrite the keywords and builtin names of a script in another language (en, vi)
+  fmt        print scripts in the canonical layout, -w rewrites the files and -check lists
+             the ones that aren't formatted, '-' or no file formats stdin to stdout
+  lint       report likely mistakes such as unused variables, rules are turned off in
+             .blulint.json or with '; lint-disable rule' comments, -rules lists them
+  lsp        run a language server for editors over stdin and stdout, giving diagnostics,
+             definitions, references, hovers, symbols, completion and formatting
+  debug      run a script stopping on its first line, breakpoints, stepping, watches and
+             the variables of each scope are driven by commands read from stdin, type help
+  dap        run a debug adapter for editors over stdin and stdout, the editor launches the
+             script, sets breakpoints, steps and shows the stack and the variables of each scope
+  trace      run a script printing every expression it evaluates with its span and value,
+             and every variable it declares or assigns, -json writes one JSON event per line
+  test       run the test blocks of the *_test.blu files of the directories, the current one
+             by default, each in a global scope of its own, -junit writes a JUnit XML report,
+             -cover prints the share of statements and if branches they ran, -coverhtml and
+             -lcov write the coverage as an HTML page or an lcov file
+  bench      benchmark the evaluator on the programs of its suite, or on the given scripts run
+             without capabilities, writing the results like go test -bench. -save writes them
+             to a file and -baseline compares a run to the saved ones, failing when a program
+             got slower than -threshold percent
+  explain    describe an error code such as E0012, or list every code
+
+Every command accepts -lang en or -lang vi to choose the language of error messages,
+BLU_LANG sets the default.
+
+Script arguments are available to the program in the 'args' array.
+With -sandbox the script can only print and read stdin.
+Imports are looked up next to the importing file, then in the directories of BLU_PATH.
+`
+
+func main() {
+	os.Exit(runCommand(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func runCommand(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		if isTerminal(stdin) {
+			return runRepl(nil, stdin, stdout, stderr)
+		}
+		return runScript(nil, stdin, stdout, stderr)
+	}
+	switch args[0] {
+	case "run":
+		return runScript(args[1:], stdin, stdout, stderr)
+	case "repl":
+		return runRepl(args[1:], stdin, stdout, stderr)
+	case "check":
+		return runCheck(args[1:], stdin, stdout, stderr)
+	case "ast":
+		return runAST(args[1:], stdin, stdout, stderr)
+	case "translate":
+		return runTranslate(args[1:], stdin, stdout, stderr)
+	case "fmt":
+		return runFormat(args[1:], stdin, stdout, stderr)
+	case "lint":
+		return runLint(args[1:], stdin, stdout, stderr)
+	case "lsp":
+		return runLanguageServer(args[1:], stdin, stdout, stderr)
+	case "debug":
+		return runDebug(args[1:], stdin, stdout, stderr)
+	case "dap":
+		return runDebugAdapter(args[1:], stdin, stdout, stderr)
+	case "trace":
+		return runTrace(args[1:], stdin, stdout, stderr)
+	case "test":
+		return runTests(args[1:], stdin, stdout, stderr)
+	case "bench":
+		return runBench(args[1:], stdout, stderr)
+	case "explain":
+		return runExplain(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return exitOK
+	}
+	// 'blulang file.blu' and 'blulang -e source' are shorthands for run
+	return runScript(args, stdin, stdout, stderr)
+}
+
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() { fmt.Fprint(stderr, usage) }
+	flags.String("lang", os.Getenv("BLU_LANG"), "language of error messages such as en or vi, defaults to BLU_LANG")
+	return flags
+}
+
+// messageLanguage is the language of diagnostics chosen with -lang or BLU_LANG,
+// nil keeps the language of each script
+func messageLanguage(flags *flag.FlagSet) (*blulang.Locale, error) {
+	name := flags.Lookup("lang").Value.String()
+	if name == "" {
+		return nil, nil
+	}
+	return lookupLocale(name)
+}
+
+func lookupLocale(name string) (*blulang.Locale, error) {
+	locale, found := blulang.Locales[name]
+	if !found {
+		var names []string
+		for name := range blulang.Locales {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, usageError{blulang.CodeUnknownLanguageFlag, []any{name, strings.Join(names, ", ")}}
+	}
+	return locale, nil
+}
+
+// usageError is a diagnostic of the command line from the message catalogue
+type usageError struct {
+	code blulang.MessageCode
+	args []any
+}
+
+func (e usageError) Error() string {
+	return blulang.Localize(nil, e.code, e.args...)
+}
+
+// sourceFlags parses the flags shared by commands that take a single script
+func sourceFlags(flags *flag.FlagSet, args []string, stdin io.Reader) (fileName string, source string, rest []string, err error) {
+	expr := flags.String("e", "", "evaluate the given source instead of a file")
+	if err := flags.Parse(args); err != nil {
+		return "", "", nil, err
+	}
+	rest = flags.Args()
+	if *expr != "" {
+		return "-e", *expr, rest, nil
+	}
+	if len(rest) == 0 {
+		rest = []string{"-"}
+	}
+	fileName, source, err = readScript(rest[0], stdin)
+	return fileName, source, rest[1:], err
+}
+
+// scriptNames gives the scripts named on the command line, the stdin when there are none
+func scriptNames(flags *flag.FlagSet) []string {
+	if flags.NArg() == 0 {
+		return []string{"-"}
+	}
+	return flags.Args()
+}
+
+// readScript reads a script from its file, or from the stdin when it is named "-"
+func readScript(fileName string, stdin io.Reader) (name string, source string, err error) {
+	if fileName == "-" {
+		stdinSource, err := io.ReadAll(stdin)
+		if err != nil {
+			return "<stdin>", "", usageError{blulang.CodeReadStdin, []any{err}}
+		}
+		return "<stdin>", string(stdinSource), nil
+	}
+	fileSource, err := os.ReadFile(fileName)
+	if err != nil {
+		return fileName, "", usageError{blulang.CodeReadFile, []any{err}}
+	}
+	return fileName, string(fileSource), nil
+}
+
+// runScript runs a script, with -profile it reports the time and calls of its functions,
+// its hot lines and what they allocated, and writes its stacks for flame graphs
+func runScript(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := newFlagSet("run", stderr)
+	sandbox := flags.Bool("sandbox", false, "only allow printing and reading stdin")
+	profileFile := flags.String("profile", "", "write the folded stacks of the run to the file and report its cost to stderr")
+	fileName, source, scriptArgs, err := sourceFlags(flags, args, stdin)
+	language, languageErr := messageLanguage(flags)
+	if err = errors.Join(err, languageErr); err != nil {
+		return reportUsageError(stderr, language, err)
+	}
+	capabilities := blulang.AllCapabilities
+	if *sandbox {
+		// the script reads the stdin of the command, which the user running it gives
+		capabilities = blulang.Untrusted | blulang.CapInput
+	}
+	fromFile := fileName != "-e" && fileName != "<stdin>"
+	var profiler *profile.Profiler
+	var hook blulang.Hook
+	if *profileFile != "" {
+		file := ""
+		if fromFile {
+			file, _ = filepath.Abs(fileName)
+		}
+		profiler = profile.New(file)
+		hook = profiler
+	}
+	interpreter := blulang.New(blulang.Options{
+		Args:         scriptArgs,
+		Stdin:        stdin,
+		Stdout:       stdout,
+		Stderr:       stderr,
+		Capabilities: capabilities,
+		SearchPaths:  searchPaths(),
+		Language:     language,
+		Hook:         hook,
+	})
+	if fromFile {
+		_, err = interpreter.RunFile(context.Background(), fileName)
+	} else {
+		_, err = interpreter.Run(context.Background(), source)
+	}
+	exitCode := exitOK
+	if err != nil {
+		exitCode = reportError(stderr, language, fileName, err)
+	}
+	if profiler != nil {
+		profiler.Stop()
+		profiler.WriteReport(stderr, hotLines)
+		if err := writeReport(*profileFile, profiler.WriteFolded); err != nil {
+			return reportUsageError(stderr, language, usageError{blulang.CodeWriteFile, []any{err}})
+		}
+	}
+	return exitCode
+}
+
+// hotLines is the number of lines a profile reports
+const hotLines = 10
+
+// runTrace runs a script logging its evaluation, in JSON what the script prints
+// becomes output events so every line of stdout is an event
+func runTrace(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := newFlagSet("trace", stderr)
+	asJSON := flags.Bool("json", false, "write the trace as JSON Lines")
+	fileName, source, scriptArgs, err := sourceFlags(flags, args, stdin)
+	language, languageErr := messageLanguage(flags)
+	if err = errors.Join(err, languageErr); err != nil {
+		return reportUsageError(stderr, language, err)
+	}
+	format, file := trace.Text, ""
+	if *asJSON {
+		format = trace.JSON
+	}
+	fromFile := fileName != "-e" && fileName != "<stdin>"
+	if fromFile {
+		file, _ = filepath.Abs(fileName)
+	}
+	tracer := trace.New(stdout, format, file, source)
+	interpreter := blulang.New(blulang.Options{
+		Args:         scriptArgs,
+		Stdin:        stdin,
+		Stdout:       tracer.Output(),
+		Stderr:       stderr,
+		Capabilities: blulang.AllCapabilities,
+		SearchPaths:  searchPaths(),
+		Language:     language,
+		Hook:         tracer,
+	})
+	if fromFile {
+		_, err = interpreter.RunFile(context.Background(), fileName)
+	} else {
+		_, err = interpreter.Run(context.Background(), source)
+	}
+	if err != nil {
+		return reportError(stderr, language, fileName, err)
+	}
+	if err := tracer.Err(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitRuntimeError
+	}
+	return exitOK
+}
+
+func runCheck(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := newFlagSet("check", stderr)
+	if err := flags.Parse(args); err != nil {
+		return reportUsageError(stderr, nil, err)
+	}
+	language, err := messageLanguage(flags)
+	if err != nil {
+		return reportUsageError(stderr, nil, err)
+	}
+	exitCode := exitOK
+	for _, fileName := range scriptNames(flags) {
+		fileName, source, err := readScript(fileName, stdin)
+		if err != nil {
+			exitCode = reportUsageError(stderr, language, err)
+			continue
+		}
+		parser := blulang.NewParser()
+		if _, err := parser.Parse(source); err != nil {
+			exitCode = reportError(stderr, cmp.Or(language, parser.Locale()), fileName, err)
+			continue
+		}
+		fmt.Fprintf(stdout, "%s: ok\n", fileName)
+	}
+	return exitCode
+}
+
+func runAST(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := newFlagSet("ast", stderr)
+	fileName, source, _, err := sourceFlags(flags, args, stdin)
+	language, languageErr := messageLanguage(flags)
+	if err = errors.Join(err, languageErr); err != nil {
+		return reportUsageError(stderr, language, err)
+	}
+	parser := blulang.NewParser()
+	program, err := parser.Parse(source)
+	if err != nil {
+		return reportError(stderr, cmp.Or(language, parser.Locale()), fileName, err)
+	}
+	blulang.DumpAST(stdout, program)
+	return exitOK
+}
+
+func runTranslate(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := newFlagSet("translate", stderr)
+	to := flags.String("to", "", "language to translate to, such as en or vi")
+	fileName, source, _, err := sourceFlags(flags, args, stdin)
+	language, languageErr := messageLanguage(flags)
+	if err = errors.Join(err, languageErr); err != nil {
+		return reportUsageError(stderr, language, err)
+	}
+	locale, err := lookupLocale(*to)
+	if err != nil {
+		return reportUsageError(stderr, language, err)
+	}
+	translated, err := blulang.Translate(source, locale)
+	if err != nil {
+		return reportError(stderr, language, fileName, err)
+	}
+	fmt.Fprint(stdout, translated)
+	return exitOK
+}
+
+// runFormat prints scripts in the canonical layout, rewrites them with -w, or with -check
+// lists the ones that aren't formatted so CI can fail on them
+func runFormat(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := newFlagSet("fmt", stderr)
+	check := flags.Bool("check", false, "list the scripts that aren't formatted instead of printing them")
+	write := flags.Bool("w", false, "write the formatted scripts back to their files")
+	if err := flags.Parse(args); err != nil {
+		return reportUsageError(stderr, nil, err)
+	}
+	language, err := messageLanguage(flags)
+	if err != nil {
+		return reportUsageError(stderr, nil, err)
+	}
+	exitCode := exitOK
+	for _, fileName := range scriptNames(flags) {
+		fileName, source, err := readScript(fileName, stdin)
+		if err != nil {
+			exitCode = reportUsageError(stderr, language, err)
+			continue
+		}
+		formatted, err := blulang.Format(source)
+		if err != nil {
+			exitCode = reportError(stderr, language, fileName, err)
+			continue
+		}
+		switch {
+		case *check:
+			if formatted != source {
+				fmt.Fprintln(stdout, fileName)
+				exitCode = cmp.Or(exitCode, exitUnformatted)
+			}
+		case *write && fileName != "<stdin>":
+			if formatted == source {
+				continue
+			}
+			if err := os.WriteFile(fileName, []byte(formatted), 0o644); err != nil {
+				exitCode = reportUsageError(stderr, language, usageError{blulang.CodeWriteFile, []any{err}})
+			}
+		default:
+			fmt.Fprint(stdout, formatted)
+		}
+	}
+	return exitCode
+}
+
+// runLint reports the problems found by the linter in every script
+func runLint(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := newFlagSet("lint", stderr)
+	configFile := flags.String("config", "", "JSON file turning rules on and off, defaults to "+lintConfigFile)
+	listRules := flags.Bool("rules", false, "list the rules")
+	if err := flags.Parse(args); err != nil {
+		return reportUsageError(stderr, nil, err)
+	}
+	language, err := messageLanguage(flags)
+	if err != nil {
+		return reportUsageError(stderr, nil, err)
+	}
+	if *listRules {
+		rules := slices.Sorted(maps.Keys(blulang.LintRules))
+		for _, rule := range rules {
+			explanation, _ := blulang.Explain(blulang.LintRules[rule], language)
+			summary, _, _ := strings.Cut(explanation, "\n")
+			fmt.Fprintf(stdout, "%-24s %s  %s\n", rule, blulang.LintRules[rule], summary)
+		}
+		return exitOK
+	}
+	config, err := readLintConfig(*configFile)
+	if err != nil {
+		return reportUsageError(stderr, language, err)
+	}
+	exitCode := exitOK
+	for _, fileName := range scriptNames(flags) {
+		fileName, source, err := readScript(fileName, stdin)
+		if err != nil {
+			exitCode = reportUsageError(stderr, language, err)
+			continue
+		}
+		diagnostics, err := blulang.Lint(source, config)
+		if err != nil {
+			exitCode = reportError(stderr, language, fileName, err)
+			continue
+		}
+		for _, diagnostic := range diagnostics {
+			if language != nil {
+				diagnostic = diagnostic.Localize(language)
+			}
+			fmt.Fprintf(stdout, "%s:%v\n", fileName, diagnostic)
+			exitCode = cmp.Or(exitCode, exitLintProblems)
+		}
+	}
+	return exitCode
+}
+
+// readLintConfig reads the rules turned on and off from a JSON file, without a file
+// the one of the working directory is used when there is one
+func readLintConfig(fileName string) (blulang.LintConfig, error) {
+	var config blulang.LintConfig
+	data, err := os.ReadFile(cmp.Or(fileName, lintConfigFile))
+	if errors.Is(err, os.ErrNotExist) && fileName == "" {
+		return config, nil
+	}
+	if err != nil {
+		return config, usageError{blulang.CodeReadFile, []any{err}}
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, usageError{blulang.CodeInvalidLintConfig, []any{err}}
+	}
+	for rule := range config.Rules {
+		if _, found := blulang.LintRules[rule]; !found {
+			return config, usageError{blulang.CodeUnknownLintRule, []any{rule}}
+		}
+	}
+	return config, nil
+}
+
+func runLanguageServer(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := newFlagSet("lsp", stderr)
+	configFile := flags.String("config", "", "JSON file turning lint rules on and off, defaults to "+lintConfigFile)
+	if err := flags.Parse(args); err != nil {
+		return reportUsageError(stderr, nil, err)
+	}
+	language, err := messageLanguage(flags)
+	if err != nil {
+		return reportUsageError(stderr, nil, err)
+	}
+	config, err := readLintConfig(*configFile)
+	if err != nil {
+		return reportUsageError(stderr, language, err)
+	}
+	server := lsp.NewServer(stdin, stdout, lsp.Options{Language: language, Lint: config})
+	if err := server.Run(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitServerError
+	}
+	return exitOK
+}
+
+func runDebugAdapter(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := newFlagSet("dap", stderr)
+	if err := flags.Parse(args); err != nil {
+		return reportUsageError(stderr, nil, err)
+	}
+	language, err := messageLanguage(flags)
+	if err != nil {
+		return reportUsageError(stderr, nil, err)
+	}
+	server := dap.NewServer(stdin, stdout, dap.Options{Language: language, SearchPaths: searchPaths()})
+	if err := server.Run(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitServerError
+	}
+	return exitOK
+}
+
+func runRepl(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := newFlagSet("repl", stderr)
+	if err := flags.Parse(args); err != nil {
+		return reportUsageError(stderr, nil, err)
+	}
+	language, err := messageLanguage(flags)
+	if err != nil {
+		return reportUsageError(stderr, nil, err)
+	}
+	// the reader is shared with the input builtin so lines typed for it aren't read as statements
+	reader := bufio.NewReader(stdin)
+	interpreter := blulang.New(blulang.Options{
+		Stdin:        reader,
+		Stdout:       stdout,
+		Stderr:       stderr,
+		Capabilities: blulang.AllCapabilities,
+		SearchPaths:  searchPaths(),
+		Language:     language,
+	})
+	fmt.Fprintln(stdout, "BluLang REPL, type a statement and press enter, type 'exit()' to quit")
+	for {
+		fmt.Fprint(stdout, "> ")
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Fprintln(stdout)
+			return exitOK
+		}
+		result, err := interpreter.Run(context.Background(), line)
+		var exitError blulang.ExitError
+		if errors.As(err, &exitError) {
+			fmt.Fprintln(stdout, "Good bye")
+			return exitError.Code
+		}
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			continue
+		}
+		fmt.Fprintf(stdout, "%v :: %v\n", result.Kind(), result.Value())
+	}
+}
+
+// runExplain prints the explanation of an error code, or every code when none is given
+func runExplain(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := newFlagSet("explain", stderr)
+	if err := flags.Parse(args); err != nil {
+		return reportUsageError(stderr, nil, err)
+	}
+	language, err := messageLanguage(flags)
+	if err != nil {
+		return reportUsageError(stderr, nil, err)
+	}
+	if flags.NArg() == 0 {
+		for _, code := range blulang.MessageCodes() {
+			explanation, _ := blulang.Explain(code, language)
+			summary, _, _ := strings.Cut(explanation, "\n")
+			fmt.Fprintf(stdout, "%s  %s\n", code, summary)
+		}
+		return exitOK
+	}
+	code := blulang.MessageCode(strings.ToUpper(flags.Arg(0)))
+	explanation, found := blulang.Explain(code, language)
+	if !found {
+		return reportUsageError(stderr, language, usageError{blulang.CodeUnknownCode, []any{flags.Arg(0)}})
+	}
+	fmt.Fprintf(stdout, "%s\n\n%s\n", code, explanation)
+	return exitOK
+}
+
+// searchPaths are the directories listed in BLU_PATH, imports not found next to the importing file are looked up there
+func searchPaths() []string {
+	return filepath.SplitList(os.Getenv("BLU_PATH"))
+}
+
+// reportError prints an error of a script with its code, which blulang explain describes
+func reportError(stderr io.Writer, language *blulang.Locale, fileName string, err error) int {
+	var exitError blulang.ExitError
+	if errors.As(err, &exitError) {
+		return exitError.Code
+	}
+	if language != nil {
+		err = blulang.LocalizeError(err, language)
+	}
+	var code blulang.MessageCode
+	var syntaxError blulang.SyntaxError
+	var runtimeError blulang.RuntimeError
+	exitCode := exitRuntimeError
+	if errors.As(err, &syntaxError) {
+		code, exitCode = syntaxError.Code, exitSyntaxError
+	} else if errors.As(err, &runtimeError) {
+		code = runtimeError.Code
+	}
+	if code != "" {
+		fmt.Fprintf(stderr, "%s: %v [%s]\n", fileName, err, code)
+	} else {
+		fmt.Fprintf(stderr, "%s: %v\n", fileName, err)
+	}
+	return exitCode
+}
+
+func reportUsageError(stderr io.Writer, language *blulang.Locale, err error) int {
+	var diagnostic usageError
+	if errors.As(err, &diagnostic) {
+		fmt.Fprintln(stderr, blulang.Localize(language, diagnostic.code, diagnostic.args...))
+	} else if !errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintln(stderr, err)
+	}
+	return exitUsage
+}
+
+func isTerminal(r io.Reader) bool {
+	file, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunCommandExitCodes(t *testing.T) {
+	cases := map[string]struct {
+		args     []string
+		exitCode int
+	}{
+		"success":       {[]string{"run", "-e", "1 + 1"}, exitOK},
+		"shorthand":     {[]string{"-e", "1 + 1"}, exitOK},
+		"syntax error":  {[]string{"run", "-e", "let a = (1"}, exitSyntaxError},
+		"runtime error": {[]string{"run", "-e", "let a = 1 let a = 2"}, exitRuntimeError},
+		"missing file":  {[]string{"run", "missing.blu"}, exitUsage},
+		"exit code":     {[]string{"run", "-e", "exit(4)"}, 4},
+		"sandbox":       {[]string{"run", "-sandbox", "-e", "exit(4)"}, exitOK},
+		"check sample":  {[]string{"check", "../../sample/hello.blu", "../../sample/chao.blu"}, exitOK},
+	}
+	for name, c := range cases {
+		var stdout, stderr bytes.Buffer
+		exitCode := runCommand(c.args, strings.NewReader(""), &stdout, &stderr)
+		assert.Equalf(t, c.exitCode, exitCode, "%s: %s", name, stderr.String())
+	}
+}
+
+func TestRunCommandStdin(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	exitCode := runCommand([]string{"run", "-"}, strings.NewReader("#!/usr/bin/env blulang\nlet a = (1"), &stdout, &stderr)
+	assert.Equal(t, exitSyntaxError, exitCode)
+	assert.Equal(t, "<stdin>: syntax error at 2:10: unexpected end of input, expected ')' [E0002]\n", stderr.String())
+}
+
+func TestAstCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	exitCode := runCommand([]string{"ast", "-e", "let a = 1"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, exitOK, exitCode)
+	assert.Equal(t, "Program\n  body[0]: VarDeclareExpr name=\"a\"\n    value: IntLiteral value=1\n", stdout.String())
+}
+
+func TestRunCommandOutput(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	exitCode := runCommand([]string{"run", "-e", "print(input() * 2, args)", "x"}, strings.NewReader("21\n"), &stdout, &stderr)
+	assert.Equal(t, exitOK, exitCode)
+	assert.Equal(t, "42 [{x}]\n", stdout.String())
+}
+
+func TestTranslateCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	exitCode := runCommand([]string{"translate", "--to", "vi", "-"}, strings.NewReader("let a = 1 ; one\nprint(a)\n"), &stdout, &stderr)
+	assert.Equal(t, exitOK, exitCode)
+	assert.Equal(t, "cho a = 1 ; one\nin(a)\n", stdout.String())
+
+	stderr.Reset()
+	exitCode = runCommand([]string{"translate", "--to", "xx", "-e", "1"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, exitUsage, exitCode)
+	assert.Equal(t, "unknown language \"xx\", expected one of en, vi\n", stderr.String())
+}
+
+func TestMessageLanguage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	exitCode := runCommand([]string{"run", "-lang", "vi", "-e", "let a = 1 let a = 2"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, exitRuntimeError, exitCode)
+	assert.Equal(t, "-e: lỗi thực thi: biến đã được khai báo: a [E0012]\n", stderr.String())
+
+	t.Setenv("BLU_LANG", "vi")
+	stderr.Reset()
+	exitCode = runCommand([]string{"check", "-"}, strings.NewReader("let = 1"), &stdout, &stderr)
+	assert.Equal(t, exitSyntaxError, exitCode)
+	assert.Equal(t, "<stdin>: lỗi cú pháp tại 1:5: không mong đợi '=', cần tên biến [E0003]\n", stderr.String())
+
+	stderr.Reset()
+	exitCode = runCommand([]string{"run", "missing.blu"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, exitUsage, exitCode)
+	assert.Equal(t, "lỗi đọc tệp: open missing.blu: no such file or directory\n", stderr.String())
+
+	stderr.Reset()
+	exitCode = runCommand([]string{"run", "-lang", "xx", "-e", "1"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, exitUsage, exitCode)
+	// an unknown language can't be used for its own message
+	assert.Equal(t, "unknown language \"xx\", expected one of en, vi\n", stderr.String())
+}
+
+func TestExplainCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	exitCode := runCommand([]string{"explain", "e0012"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, exitOK, exitCode)
+	assert.True(t, strings.HasPrefix(stdout.String(), "E0012\n\nA variable or function was declared twice"))
+
+	stdout.Reset()
+	exitCode = runCommand([]string{"explain", "-lang", "vi", "E0012"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, exitOK, exitCode)
+	assert.Contains(t, stdout.String(), "được khai báo hai lần")
+
+	stdout.Reset()
+	exitCode = runCommand([]string{"explain"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, exitOK, exitCode)
+	assert.Contains(t, stdout.String(), "E0016  An integer was divided by zero.\n")
+
+	exitCode = runCommand([]string{"explain", "E9999"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, exitUsage, exitCode)
+	assert.Equal(t, "unknown error code E9999, run blulang explain to list the codes\n", stderr.String())
+}
+
+func TestFormatCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	exitCode := runCommand([]string{"fmt"}, strings.NewReader("let a=1 ; one\nprint( a )"), &stdout, &stderr)
+	assert.Equal(t, exitOK, exitCode)
+	assert.Equal(t, "let a = 1 ; one\nprint(a)\n", stdout.String())
+
+	file := filepath.Join(t.TempDir(), "script.blu")
+	assert.NoError(t, os.WriteFile(file, []byte("let a=1"), 0o644))
+	stdout.Reset()
+	exitCode = runCommand([]string{"fmt", "--check", file}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, exitUnformatted, exitCode)
+	assert.Equal(t, file+"\n", stdout.String())
+
+	exitCode = runCommand([]string{"fmt", "-w", file}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, exitOK, exitCode)
+	written, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, "let a = 1\n", string(written))
+
+	stdout.Reset()
+	exitCode = runCommand([]string{"fmt", "-check", file}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, exitOK, exitCode)
+	assert.Empty(t, stdout.String())
+
+	exitCode = runCommand([]string{"fmt", "-"}, strings.NewReader("let a = (1"), &stdout, &stderr)
+	assert.Equal(t, exitSyntaxError, exitCode)
+	assert.Equal(t, "<stdin>: syntax error at 1:10: unexpected end of input, expected ')' [E0002]\n", stderr.String())
+}
+
+func TestLintCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	exitCode := runCommand([]string{"lint", "-"}, strings.NewReader("let a = 1\nlet b = 2 b"), &stdout, &stderr)
+	assert.Equal(t, exitLintProblems, exitCode)
+	assert.Equal(t, "<stdin>:1:5: 'a' is declared but never used [unused-variable]\n", stdout.String())
+
+	config := filepath.Join(t.TempDir(), "lint.json")
+	assert.NoError(t, os.WriteFile(config, []byte(`{"rules": {"unused-variable": false}}`), 0o644))
+	stdout.Reset()
+	exitCode = runCommand([]string{"lint", "-config", config, "-"}, strings.NewReader("let a = 1"), &stdout, &stderr)
+	assert.Equal(t, exitOK, exitCode)
+	assert.Empty(t, stdout.String())
+
+	assert.NoError(t, os.WriteFile(config, []byte(`{"rules": {"unused": false}}`), 0o644))
+	exitCode = runCommand([]string{"lint", "-config", config, "-"}, strings.NewReader("let a = 1"), &stdout, &stderr)
+	assert.Equal(t, exitUsage, exitCode)
+	assert.Equal(t, "unknown lint rule \"unused\"\n", stderr.String())
+
+	exitCode = runCommand([]string{"lint", "../../sample/hello.blu", "../../sample/chao.blu"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, exitOK, exitCode)
+}
+
+func TestLanguageServerCommand(t *testing.T) {
+	var input strings.Builder
+	for _, message := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///a.blu","text":"let a = 1"}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	} {
+		fmt.Fprintf(&input, "Content-Length: %d\r\n\r\n%s", len(message), message)
+	}
+	var stdout, stderr bytes.Buffer
+	exitCode := runCommand([]string{"lsp", "-lang", "vi"}, strings.NewReader(input.String()), &stdout, &stderr)
+	assert.Equal(t, exitOK, exitCode)
+	assert.Contains(t, stdout.String(), `"message":"'a' được khai báo nhưng không được dùng"`)
+	assert.Empty(t, stderr.String())
+
+	exitCode = runCommand([]string{"lsp"}, strings.NewReader("Content-Length: 2\r\n\r\n{}"), &stdout, &stderr)
+	assert.Equal(t, exitOK, exitCode)
+}
+
+func TestDebugAdapterCommand(t *testing.T) {
+	var input strings.Builder
+	for _, message := range []string{
+		`{"seq":1,"type":"request","command":"initialize","arguments":{"adapterID":"blulang"}}`,
+		`{"seq":2,"type":"request","command":"launch","arguments":{"program":"missing.blu"}}`,
+		`{"seq":3,"type":"request","command":"disconnect"}`,
+	} {
+		fmt.Fprintf(&input, "Content-Length: %d\r\n\r\n%s", len(message), message)
+	}
+	var stdout, stderr bytes.Buffer
+	exitCode := runCommand([]string{"dap", "-lang", "vi"}, strings.NewReader(input.String()), &stdout, &stderr)
+	assert.Equal(t, exitOK, exitCode)
+	assert.Contains(t, stdout.String(), `"supportsConfigurationDoneRequest":true`)
+	assert.Contains(t, stdout.String(), `"request_seq":2,"success":false,"command":"launch","message":"lỗi đọc tệp`)
+	assert.Contains(t, stdout.String(), `"request_seq":3,"success":true,"command":"disconnect"`)
+	assert.Empty(t, stderr.String())
+}
+
+func TestRunCommandProfile(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "fib.blu")
+	source := "fn fib(n) { if n < 2 { n } else { fib(n - 1) + fib(n - 2) } }\nprint(fib(5))\n"
+	assert.NoError(t, os.WriteFile(script, []byte(source), 0o644))
+	folded := filepath.Join(dir, "fib.folded")
+
+	var stdout, stderr bytes.Buffer
+	exitCode := runCommand([]string{"run", "-profile", folded, script}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, exitOK, exitCode, stderr.String())
+	assert.Equal(t, "5\n", stdout.String())
+	assert.Regexp(t, `\n +15 +\S+ +\S+ +0 +0  fib\n`, stderr.String())
+	assert.Regexp(t, `\n +1 +\S+ +\S+ +0 +0  fib\.blu\n`, stderr.String())
+	assert.Contains(t, stderr.String(), "  fib.blu:1\n")
+	stacks, err := os.ReadFile(folded)
+	assert.NoError(t, err)
+	assert.Regexp(t, `(?m)^fib\.blu;fib;fib;fib;fib;fib \d+$`, string(stacks))
+}
+
+func TestTraceCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	exitCode := runCommand([]string{"trace", "-e", "let b = 9\nwhile b != 10 { b = b + 1 }"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, exitOK, exitCode, stderr.String())
+	assert.Contains(t, stdout.String(), "    assign b = 10\n")
+	assert.True(t, strings.HasSuffix(stdout.String(), "\n2:1-2:28  while b != 10 { b = b + 1 } => 10\n"))
+
+	stdout.Reset()
+	exitCode = runCommand([]string{"trace", "-json", "-"}, strings.NewReader("print(1)"), &stdout, &stderr)
+	assert.Equal(t, exitOK, exitCode, stderr.String())
+	assert.Equal(t, `{"event":"expression","depth":1,"kind":"IntLiteral","start":{"line":1,"column":7},"end":{"line":1,"column":8},"text":"1","value":"1","type":"IntVal"}
+{"event":"output","depth":1,"output":"1\n"}
+{"event":"expression","depth":0,"kind":"FuncCallExpr","start":{"line":1,"column":1},"end":{"line":1,"column":9},"text":"print(1)","value":"[1]","type":"ArrayVal"}
+`, stdout.String())
+}
+
+func TestDebugCommand(t *testing.T) {
+	source := "fn inc(n) {\n    n + 1\n}\nlet b = input()\nwhile b < 3 { b = inc(b) }\nprint(b)"
+	commands := "break 2 if n == 1\nwatch b\nbreakpoints\nc\n0\nscopes\nstack\np n * 10\nout\nunwatch 1\nn\nq\n"
+	var stdout, stderr bytes.Buffer
+	exitCode := runCommand([]string{"debug", "-e", source}, strings.NewReader(commands), &stdout, &stderr)
+	assert.Equal(t, exitOK, exitCode, stderr.String())
+	assert.Equal(t, `Stopped at -e:1 (entry)
+->    1 | fn inc(n) {
+(blu) breakpoint at -e:2
+(blu) 1: b = null
+(blu) -e:2 if n == 1
+(blu) Stopped at -e:2 in inc (breakpoint)
+->    2 |     n + 1
+1: b = 1
+(blu) scope 0:
+  n = 1
+global:
+  b = 1
+  inc = fn inc(n)
+(blu) #0 -e:2 in inc
+#1 -e:5
+(blu) 10
+(blu) Stopped at -e:5 (step)
+->    5 | while b < 3 { b = inc(b) }
+1: b = 2
+(blu) (blu) Stopped at -e:6 (step)
+->    6 | print(b)
+(blu) `, stdout.String())
+
+	exitCode = runCommand([]string{"debug"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, exitUsage, exitCode)
+}
+
+func TestTestCommand(t *testing.T) {
+	dir := t.TempDir()
+	source := "fn double(n) { n * 2 }\ntest \"doubles\" { assertEqual(double(2), 4) }\ntest \"fails\" {\n    print(\"checking\")\n    assertEqual(double(1), 3)\n}\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "double_test.blu"), []byte(source), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "main.blu"), []byte("1 / 0"), 0o644))
+	report := filepath.Join(t.TempDir(), "report.xml")
+
+	var stdout, stderr bytes.Buffer
+	exitCode := runCommand([]string{"test", "-v", "-junit", report, dir}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, exitRuntimeError, exitCode, stderr.String())
+	file := filepath.Join(dir, "double_test.blu")
+	assert.Equal(t, "PASS "+file+":2:1 doubles\nFAIL "+file+":3:1 fails\n    "+file+
+		":5:5: runtime error: values are not equal:\n      expected 3, got 2 [E0056]\n    checking\n2 tests, 1 passed, 1 failed\n", stdout.String())
+	junit, err := os.ReadFile(report)
+	assert.NoError(t, err)
+	assert.Contains(t, string(junit), `<testsuites tests="2" failures="1" errors="0"`)
+
+	stdout.Reset()
+	exitCode = runCommand([]string{"test", "-lang", "vi", filepath.Join(dir, "main.blu")}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, exitOK, exitCode, stderr.String())
+	assert.Equal(t, "0 tests, 0 passed, 0 failed\n", stdout.String())
+
+	stderr.Reset()
+	exitCode = runCommand([]string{"test", t.TempDir()}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, exitUsage, exitCode)
+	assert.Contains(t, stderr.String(), "no test files found in ")
+}
+
+func TestTestCommandCoverage(t *testing.T) {
+	dir := t.TempDir()
+	source := "fn sign(n) { if n < 0 { 0 - 1 } else { 1 } }\ntest \"positive\" { assertEqual(sign(2), 1) }\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "sign_test.blu"), []byte(source), 0o644))
+	reports := t.TempDir()
+
+	var stdout, stderr bytes.Buffer
+	exitCode := runCommand([]string{"test", "-cover", "-coverhtml", filepath.Join(reports, "coverage.html"),
+		"-lcov", filepath.Join(reports, "lcov.info"), dir}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, exitOK, exitCode, stderr.String())
+	file := filepath.Join(dir, "sign_test.blu")
+	assert.Equal(t, "1 tests, 1 passed, 0 failed\n"+
+		"file"+strings.Repeat(" ", len(file)-2)+"statements         branches\n"+
+		file+"  75.0%       (3/4)  50.0%  (1/2)\n"+
+		"total"+strings.Repeat(" ", len(file)-3)+"75.0%       (3/4)  50.0%  (1/2)\n", stdout.String())
+	html, err := os.ReadFile(filepath.Join(reports, "coverage.html"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(html), `class="partial"`)
+	lcov, err := os.ReadFile(filepath.Join(reports, "lcov.info"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(lcov), "SF:"+file+"\nBRDA:1,0,0,0\nBRDA:1,0,1,1\n")
+}
+
+func TestBenchCommand(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "sum.blu")
+	assert.NoError(t, os.WriteFile(script, []byte("; result: 6\nlet a = [1, 2, 3]\na[0] + a[1] + a[2]\n"), 0o644))
+	results := filepath.Join(dir, "results.txt")
+	// a baseline much faster than any run
+	baseline := filepath.Join(dir, "baseline.txt")
+	assert.NoError(t, os.WriteFile(baseline, []byte("BenchmarkEval/sum-8\t1\t0.5 ns/op\t0 B/op\t0 allocs/op\n"), 0o644))
+
+	var stdout, stderr bytes.Buffer
+	exitCode := runCommand([]string{"bench", "-save", results, "-baseline", baseline, "-threshold", "50", script}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, exitRuntimeError, exitCode)
+	assert.Regexp(t, `^BenchmarkEval/sum\t\d+\t\d+ ns/op\t\d+ B/op\t\d+ allocs/op\n\n +old ns/op .*\n +0 +\d+ +\+\d+\.\d% +0 +\d+ .* BenchmarkEval/sum\n$`, stdout.String())
+	assert.Equal(t, "slower than the baseline by more than 50%: BenchmarkEval/sum\n", stderr.String())
+	saved, err := os.ReadFile(results)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.SplitAfter(stdout.String(), "\n")[0], string(saved))
+
+	stderr.Reset()
+	exitCode = runCommand([]string{"bench", "-lang", "vi", "-baseline", baseline, "-threshold", "50", script}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, exitRuntimeError, exitCode)
+	assert.Equal(t, "chậm hơn kết quả gốc quá 50%: BenchmarkEval/sum\n", stderr.String())
+
+	stdout.Reset()
+	stderr.Reset()
+	assert.NoError(t, os.WriteFile(script, []byte("; result: 7\n1 + 2\n"), 0o644))
+	exitCode = runCommand([]string{"bench", script}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, exitRuntimeError, exitCode)
+	assert.Equal(t, "sum: evaluated to 3 instead of 7\n", stderr.String())
+	assert.Empty(t, stdout.String())
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"blulang"
+	"blulang/coverage"
+	"blulang/testrunner"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// runTests runs the tests of the *_test.blu files found in the given files and directories,
+// the failing ones are listed with the statement they failed at, and reports the coverage
+// of the files they ran
+func runTests(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := newFlagSet("test", stderr)
+	verbose := flags.Bool("v", false, "list the tests that pass too")
+	junit := flags.String("junit", "", "write a JUnit XML report to the file")
+	cover := flags.Bool("cover", false, "print the share of the statements and branches the tests ran")
+	coverHTML := flags.String("coverhtml", "", "write an HTML coverage report with the highlighted sources to the file")
+	lcov := flags.String("lcov", "", "write the coverage in the lcov format to the file")
+	if err := flags.Parse(args); err != nil {
+		return reportUsageError(stderr, nil, err)
+	}
+	language, err := messageLanguage(flags)
+	if err != nil {
+		return reportUsageError(stderr, nil, err)
+	}
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	files, err := testrunner.Discover(paths...)
+	if err != nil {
+		return reportUsageError(stderr, language, usageError{blulang.CodeReadFile, []any{err}})
+	}
+	if len(files) == 0 {
+		return reportUsageError(stderr, language, usageError{blulang.CodeNoTestFiles, []any{strings.Join(paths, ", ")}})
+	}
+
+	var recorder *coverage.Recorder
+	var hook blulang.Hook
+	if *cover || *coverHTML != "" || *lcov != "" {
+		recorder = coverage.New()
+		hook = recorder
+	}
+
+	exitCode := exitOK
+	var results []testrunner.Result
+	for _, file := range files {
+		fileResults, err := testrunner.RunFile(context.Background(), file, testrunner.Options{
+			Stdin:        stdin,
+			Capabilities: blulang.AllCapabilities,
+			SearchPaths:  searchPaths(),
+			Language:     language,
+			Hook:         hook,
+		})
+		if err != nil {
+			exitCode = max(exitCode, reportError(stderr, nil, file, err))
+			continue
+		}
+		for _, result := range fileResults {
+			reportTest(stdout, result, *verbose)
+		}
+		results = append(results, fileResults...)
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Status != testrunner.Passed {
+			failed++
+		}
+	}
+	if failed > 0 {
+		exitCode = max(exitCode, exitRuntimeError)
+	}
+	fmt.Fprintf(stdout, "%d tests, %d passed, %d failed\n", len(results), len(results)-failed, failed)
+
+	if *junit != "" {
+		err := writeReport(*junit, func(w io.Writer) error { return testrunner.WriteJUnit(w, results) })
+		if err != nil {
+			return reportUsageError(stderr, language, usageError{blulang.CodeWriteFile, []any{err}})
+		}
+	}
+	if recorder == nil {
+		return exitCode
+	}
+	covered, err := recorder.Files()
+	if err != nil {
+		return reportUsageError(stderr, language, usageError{blulang.CodeReadFile, []any{err}})
+	}
+	for i := range covered {
+		covered[i].Path = relativePath(covered[i].Path)
+	}
+	if *cover {
+		coverage.WriteText(stdout, covered)
+	}
+	reports := []struct {
+		path  string
+		write func(io.Writer, []coverage.File) error
+	}{{*coverHTML, coverage.WriteHTML}, {*lcov, coverage.WriteLCOV}}
+	for _, report := range reports {
+		if report.path == "" {
+			continue
+		}
+		if err := writeReport(report.path, func(w io.Writer) error { return report.write(w, covered) }); err != nil {
+			return reportUsageError(stderr, language, usageError{blulang.CodeWriteFile, []any{err}})
+		}
+	}
+	return exitCode
+}
+
+// writeReport creates a report file and writes it
+func writeReport(path string, write func(w io.Writer) error) error {
+	report, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	return errors.Join(write(report), report.Close())
+}
+
+// relativePath shows the path of a file below the working directory relative to it
+func relativePath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	relative, err := filepath.Rel(wd, path)
+	if err != nil || strings.HasPrefix(relative, "..") {
+		return path
+	}
+	return relative
+}
+
+// reportTest prints a failing test with its error and what it printed, and a passing one when verbose
+func reportTest(stdout io.Writer, result testrunner.Result, verbose bool) {
+	if result.Status == testrunner.Passed {
+		if verbose {
+			fmt.Fprintf(stdout, "PASS %s:%v %s\n", result.File, result.Pos, result.Name)
+		}
+		return
+	}
+	fmt.Fprintf(stdout, "%s %s:%v %s\n", strings.ToUpper(string(result.Status)), result.File, result.Pos, result.Name)
+	message := fmt.Sprintf("%s:%v: %v", result.ErrFile, result.ErrPos, result.Err)
+	var runtimeError blulang.RuntimeError
+	if errors.As(result.Err, &runtimeError) && runtimeError.Code != "" {
+		message += fmt.Sprintf(" [%s]", runtimeError.Code)
+	}
+	fmt.Fprintln(stdout, indent(message))
+	if result.Output != "" {
+		fmt.Fprintln(stdout, indent(strings.TrimSuffix(result.Output, "\n")))
+	}
+}
+
+func indent(text string) string {
+	return "    " + strings.ReplaceAll(text, "\n", "\n    ")
+}
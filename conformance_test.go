@@ -0,0 +1,277 @@
+package blulang_test
+
+import (
+	"blulang"
+	"blulang/debugger"
+	"blulang/trace"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The conformance suite runs the programs of testdata/conformance and compares what they print,
+// their value and their error with the expectations written in their header:
+//
+//	; stdin: a line read by input
+//	; stdout: a line the program prints
+//	; stderr: a line the program prints to stderr
+//	; result: the value of the program as blulang.Inspect shows it
+//	; error: a line of the error of the program, the last one ends with its code
+//	; test: the name of the test block to run
+//
+// Output and errors that aren't expected fail the case, the result is only checked when given.
+// The samples are cases too, their expectations are the same lines without ';' in a sidecar
+// file of testdata/conformance/sample. Every case runs on each backend.
+
+const conformanceDir = "testdata/conformance"
+
+type conformanceCase struct {
+	path   string
+	stdin  []string
+	stdout []string
+	stderr []string
+	result *string
+	err    []string
+	test   string
+}
+
+// readExpectations reads the directives of a header, which ends with the first line that isn't
+// one, or of a sidecar file where every line is a directive
+func readExpectations(c *conformanceCase, text string, header bool) error {
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		if header {
+			if !strings.HasPrefix(line, "; ") {
+				return nil
+			}
+			line = strings.TrimPrefix(line, "; ")
+		}
+		key, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch key {
+		case "stdin":
+			c.stdin = append(c.stdin, value)
+		case "stdout":
+			c.stdout = append(c.stdout, value)
+		case "stderr":
+			c.stderr = append(c.stderr, value)
+		case "result":
+			c.result = &value
+		case "error":
+			c.err = append(c.err, value)
+		case "test":
+			c.test = value
+		default:
+			if header {
+				return nil
+			}
+			return fmt.Errorf("unknown directive %q", line)
+		}
+	}
+	return nil
+}
+
+// conformanceCases are the cases of testdata/conformance followed by the samples
+func conformanceCases(t *testing.T) []conformanceCase {
+	var cases []conformanceCase
+	paths, err := filepath.Glob(filepath.Join(conformanceDir, "*.blu"))
+	require.NoError(t, err)
+	for _, path := range paths {
+		source, err := os.ReadFile(path)
+		require.NoError(t, err)
+		c := conformanceCase{path: path}
+		require.NoError(t, readExpectations(&c, string(source), true), path)
+		cases = append(cases, c)
+	}
+
+	samples, err := filepath.Glob("sample/*.blu")
+	require.NoError(t, err)
+	for _, path := range samples {
+		sidecar := filepath.Join(conformanceDir, "sample", strings.TrimSuffix(filepath.Base(path), ".blu")+".expect")
+		expectations, err := os.ReadFile(sidecar)
+		require.NoErrorf(t, err, "every sample needs the expectations of %s", sidecar)
+		c := conformanceCase{path: path}
+		require.NoError(t, readExpectations(&c, string(expectations), false), sidecar)
+		cases = append(cases, c)
+	}
+	return cases
+}
+
+// conformanceBackends evaluate a case file, with options prepared for the case
+var conformanceBackends = map[string]func(t *testing.T, path string, options blulang.Options) (blulang.RuntimeVal, error){
+	"evaluator": func(t *testing.T, path string, options blulang.Options) (blulang.RuntimeVal, error) {
+		return blulang.New(options).RunFile(context.Background(), path)
+	},
+	// a debugger without breakpoints follows every statement and call
+	"debugger": func(t *testing.T, path string, options blulang.Options) (blulang.RuntimeVal, error) {
+		options.Hook = debugger.New(false, func(*debugger.Stop) debugger.Action { return debugger.Continue })
+		return blulang.New(options).RunFile(context.Background(), path)
+	},
+	// a tracer follows every expression too
+	"trace": func(t *testing.T, path string, options blulang.Options) (blulang.RuntimeVal, error) {
+		source, err := os.ReadFile(path)
+		require.NoError(t, err)
+		absolute, err := filepath.Abs(path)
+		require.NoError(t, err)
+		options.Hook = trace.New(io.Discard, trace.Text, absolute, string(source))
+		return blulang.New(options).RunFile(context.Background(), path)
+	},
+	// the formatted program behaves the same, it is written next to the case for its imports
+	"formatted": func(t *testing.T, path string, options blulang.Options) (blulang.RuntimeVal, error) {
+		source, err := os.ReadFile(path)
+		require.NoError(t, err)
+		formatted, err := blulang.Format(string(source))
+		if err != nil {
+			t.Skip("the case doesn't parse")
+		}
+		file, err := os.CreateTemp(filepath.Dir(path), ".formatted-*.blu")
+		require.NoError(t, err)
+		t.Cleanup(func() { os.Remove(file.Name()) })
+		_, err = file.WriteString(formatted)
+		require.NoError(t, errors.Join(err, file.Close()))
+		return blulang.New(options).RunFile(context.Background(), file.Name())
+	},
+}
+
+// shownError is an error the way blulang run reports it, with its code
+func shownError(err error) string {
+	var syntaxError blulang.SyntaxError
+	var runtimeError blulang.RuntimeError
+	switch {
+	case errors.As(err, &syntaxError) && syntaxError.Code != "":
+		return fmt.Sprintf("%v [%s]", err, syntaxError.Code)
+	case errors.As(err, &runtimeError) && runtimeError.Code != "":
+		return fmt.Sprintf("%v [%s]", err, runtimeError.Code)
+	}
+	return err.Error()
+}
+
+func lines(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return strings.Join(values, "\n") + "\n"
+}
+
+func TestConformance(t *testing.T) {
+	for _, c := range conformanceCases(t) {
+		for name, run := range conformanceBackends {
+			t.Run(c.path+"/"+name, func(t *testing.T) {
+				var stdout, stderr strings.Builder
+				result, err := run(t, c.path, blulang.Options{
+					Stdin:        strings.NewReader(lines(c.stdin)),
+					Stdout:       &stdout,
+					Stderr:       &stderr,
+					Capabilities: blulang.AllCapabilities,
+					Test:         c.test,
+				})
+				assert.Equal(t, lines(c.stdout), stdout.String(), "stdout")
+				assert.Equal(t, lines(c.stderr), stderr.String(), "stderr")
+				if len(c.err) > 0 {
+					if assert.Error(t, err) {
+						assert.Equal(t, strings.Join(c.err, "\n"), shownError(err))
+					}
+					return
+				}
+				require.NoError(t, err)
+				if c.result != nil {
+					assert.Equal(t, *c.result, blulang.Inspect(result), "result")
+				}
+			})
+		}
+	}
+}
+
+// readmeCode lists the code blocks of the README without a language, which are BluLang programs,
+// and the snippets of the syntax column of its table
+func readmeCode(t *testing.T) (blocks []string, snippets []string) {
+	readme, err := os.Open("README.md")
+	require.NoError(t, err)
+	defer readme.Close()
+	var block []string
+	inBlock, inSyntax := false, false
+	scanner := bufio.NewScanner(readme)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			if inBlock && block != nil {
+				blocks = append(blocks, lines(block))
+			}
+			// only the blocks without a language are kept
+			block = nil
+			if !inBlock && trimmed == "```" {
+				block = []string{}
+			}
+			inBlock = !inBlock
+		case inBlock:
+			if block != nil {
+				block = append(block, line)
+			}
+		case strings.HasPrefix(line, "## "):
+			inSyntax = line == "## Syntax"
+		case inSyntax && strings.HasPrefix(line, "|"):
+			cells := strings.Split(line, "|")
+			if len(cells) < 3 || strings.HasPrefix(cells[2], "---") || strings.TrimSpace(cells[1]) == "Construct" {
+				continue
+			}
+			code, _, _ := strings.Cut(cells[2], "<br/>")
+			snippets = append(snippets, splitSnippets(strings.TrimSpace(code))...)
+		}
+	}
+	require.NoError(t, scanner.Err())
+	return blocks, snippets
+}
+
+// splitSnippets splits the examples a cell lists with ", " outside of brackets
+func splitSnippets(code string) []string {
+	var snippets []string
+	depth, start := 0, 0
+	for i, r := range code {
+		switch r {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case ',':
+			if depth == 0 && strings.HasPrefix(code[i:], ", ") {
+				snippets = append(snippets, code[start:i])
+				start = i + 2
+			}
+		}
+	}
+	return append(snippets, code[start:])
+}
+
+func TestReadmeExamples(t *testing.T) {
+	var sources []string
+	for _, c := range conformanceCases(t) {
+		source, err := os.ReadFile(c.path)
+		require.NoError(t, err)
+		sources = append(sources, string(source))
+	}
+	blocks, snippets := readmeCode(t)
+	assert.NotEmpty(t, blocks)
+	for _, block := range blocks {
+		found := false
+		for _, source := range sources {
+			found = found || strings.Contains(source, block)
+		}
+		assert.Truef(t, found, "the README example isn't a conformance case:\n%s", block)
+	}
+
+	assert.Greater(t, len(snippets), 15)
+	parser := blulang.NewParser()
+	for _, snippet := range snippets {
+		_, err := parser.Parse(snippet)
+		assert.NoErrorf(t, err, "the README syntax table example %q", snippet)
+	}
+}
@@ -0,0 +1,304 @@
+package blulang
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	runtimeValType = reflect.TypeOf((*RuntimeVal)(nil)).Elem()
+	errorType      = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// ToGo converts a runtime value to plain Go values: int, float64, bool, string, nil,
+// []any for arrays and map[string]any for objects. Functions are returned as they are.
+func ToGo(val RuntimeVal) any {
+	switch v := val.(type) {
+	case IntVal:
+		return v.value
+	case FloatVal:
+		return v.value
+	case BoolVal:
+		return v.value
+	case StringVal:
+		return v.value
+	case NullVal:
+		return nil
+	case ArrayVal:
+		values := make([]any, len(v.values))
+		for i, element := range v.values {
+			values[i] = ToGo(element)
+		}
+		return values
+	case ObjectVal:
+		props := make(map[string]any, len(v.properties.variables))
+		for name, prop := range v.properties.variables {
+			props[name] = ToGo(prop)
+		}
+		return props
+	}
+	return val
+}
+
+// FromGo converts a Go value to a runtime value. Integers, floats, strings, bools, slices,
+// string keyed maps, structs and functions are supported, pointers are followed.
+// Struct fields are named after the field or its `blu` tag, a tag of "-" skips the field.
+func FromGo(value any) RuntimeVal {
+	if val, ok := value.(RuntimeVal); ok {
+		return val
+	}
+	return fromGoValue(reflect.ValueOf(value))
+}
+
+func fromGoValue(value reflect.Value) RuntimeVal {
+	if !value.IsValid() || ((value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface) && value.IsNil()) {
+		return NullVal{}
+	}
+	if value.Type().Implements(runtimeValType) && value.CanInterface() {
+		return value.Interface().(RuntimeVal)
+	}
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return NewIntVal(int(value.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return NewIntVal(int(value.Uint()))
+	case reflect.Float32, reflect.Float64:
+		return NewFloatVal(value.Float())
+	case reflect.String:
+		return NewStringVal(value.String())
+	case reflect.Bool:
+		return NewBoolVal(value.Bool())
+	case reflect.Pointer, reflect.Interface:
+		return fromGoValue(value.Elem())
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.IsNil() {
+			return NewArrayVal(nil)
+		}
+		values := make([]RuntimeVal, value.Len())
+		for i := range values {
+			values[i] = fromGoValue(value.Index(i))
+		}
+		return NewArrayVal(values)
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			panic(codedError(CodeUnsupportedMapKey, value.Type().Key()))
+		}
+		props := NewScope(nil)
+		iter := value.MapRange()
+		for iter.Next() {
+			props.DeclareVar(iter.Key().String(), fromGoValue(iter.Value()))
+		}
+		return NewObjectVal(props)
+	case reflect.Struct:
+		props := NewScope(nil)
+		for i := 0; i < value.NumField(); i++ {
+			name, ok := structFieldName(value.Type().Field(i))
+			if ok {
+				props.DeclareVar(name, fromGoValue(value.Field(i)))
+			}
+		}
+		return NewObjectVal(props)
+	case reflect.Func:
+		if value.IsNil() {
+			return NullVal{}
+		}
+		funcVal, err := NewGoFuncVal(value.Interface())
+		if err != nil {
+			panic(WrapRuntimeError(err))
+		}
+		return funcVal
+	}
+	panic(codedError(CodeUnsupportedGoType, value.Type()))
+}
+
+func structFieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	tag, _, _ := strings.Cut(field.Tag.Get("blu"), ",")
+	if tag == "-" {
+		return "", false
+	}
+	if tag != "" {
+		return tag, true
+	}
+	return field.Name, true
+}
+
+// toGoValue converts a runtime value into a Go value of the given type
+func toGoValue(val RuntimeVal, target reflect.Type) (reflect.Value, error) {
+	if target == runtimeValType {
+		return reflect.ValueOf(&val).Elem(), nil
+	}
+	if target.Kind() == reflect.Interface && target.NumMethod() == 0 {
+		goVal := ToGo(val)
+		if goVal == nil {
+			return reflect.Zero(target), nil
+		}
+		return reflect.ValueOf(goVal), nil
+	}
+	if val.Kind() == VaNullVal && (target.Kind() == reflect.Pointer || target.Kind() == reflect.Slice || target.Kind() == reflect.Map) {
+		return reflect.Zero(target), nil
+	}
+	mismatch := func() (reflect.Value, error) {
+		return reflect.Value{}, fmt.Errorf("cannot use %v as %v", val.Kind(), target)
+	}
+
+	switch target.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		intVal, ok := val.(IntVal)
+		if !ok {
+			return mismatch()
+		}
+		return reflect.ValueOf(intVal.value).Convert(target), nil
+	case reflect.Float32, reflect.Float64:
+		floatVal, ok := toFloat(val)
+		if !ok {
+			return mismatch()
+		}
+		return reflect.ValueOf(floatVal).Convert(target), nil
+	case reflect.String:
+		stringVal, ok := val.(StringVal)
+		if !ok {
+			return mismatch()
+		}
+		return reflect.ValueOf(stringVal.value).Convert(target), nil
+	case reflect.Bool:
+		boolVal, ok := val.(BoolVal)
+		if !ok {
+			return mismatch()
+		}
+		return reflect.ValueOf(boolVal.value).Convert(target), nil
+	case reflect.Slice:
+		arrayVal, ok := val.(ArrayVal)
+		if !ok {
+			return mismatch()
+		}
+		slice := reflect.MakeSlice(target, len(arrayVal.values), len(arrayVal.values))
+		for i, element := range arrayVal.values {
+			elementVal, err := toGoValue(element, target.Elem())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("element %d: %w", i, err)
+			}
+			slice.Index(i).Set(elementVal)
+		}
+		return slice, nil
+	case reflect.Map:
+		objectVal, ok := val.(ObjectVal)
+		if !ok || target.Key().Kind() != reflect.String {
+			return mismatch()
+		}
+		result := reflect.MakeMapWithSize(target, len(objectVal.properties.variables))
+		for name, prop := range objectVal.properties.variables {
+			propVal, err := toGoValue(prop, target.Elem())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("property %s: %w", name, err)
+			}
+			result.SetMapIndex(reflect.ValueOf(name).Convert(target.Key()), propVal)
+		}
+		return result, nil
+	case reflect.Struct:
+		objectVal, ok := val.(ObjectVal)
+		if !ok {
+			return mismatch()
+		}
+		result := reflect.New(target).Elem()
+		for i := 0; i < target.NumField(); i++ {
+			name, ok := structFieldName(target.Field(i))
+			prop := objectVal.properties.variables[name]
+			if !ok || prop == nil {
+				continue
+			}
+			propVal, err := toGoValue(prop, target.Field(i).Type)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("property %s: %w", name, err)
+			}
+			result.Field(i).Set(propVal)
+		}
+		return result, nil
+	case reflect.Pointer:
+		elem, err := toGoValue(val, target.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		pointer := reflect.New(target.Elem())
+		pointer.Elem().Set(elem)
+		return pointer, nil
+	}
+	return mismatch()
+}
+
+// NewGoFuncVal adapts any Go function to a native function. Arguments are converted
+// from runtime values to the parameter types, variadic functions are supported.
+// The function may return nothing, a value, an error or a value and an error,
+// a non nil error is raised as a runtime error.
+func NewGoFuncVal(fn any) (NativeFuncVal, error) {
+	fnVal := reflect.ValueOf(fn)
+	if fnVal.Kind() != reflect.Func {
+		return NativeFuncVal{}, fmt.Errorf("expected a function, got %T", fn)
+	}
+	fnType := fnVal.Type()
+	switch fnType.NumOut() {
+	case 0, 1:
+	case 2:
+		if fnType.Out(1) != errorType {
+			return NativeFuncVal{}, fmt.Errorf("the second result of %v must be an error", fnType)
+		}
+	default:
+		return NativeFuncVal{}, fmt.Errorf("%v returns too many results", fnType)
+	}
+
+	return NewNativeFuncVal(func(scope *Scope, args ...RuntimeVal) RuntimeVal {
+		fixed := fnType.NumIn()
+		if fnType.IsVariadic() {
+			fixed--
+		}
+		if len(args) < fixed || (!fnType.IsVariadic() && len(args) > fixed) {
+			panic(codedError(CodeGoArgumentCount, fixed, len(args)))
+		}
+		in := make([]reflect.Value, len(args))
+		for i, arg := range args {
+			var paramType reflect.Type
+			if i < fixed {
+				paramType = fnType.In(i)
+			} else {
+				paramType = fnType.In(fixed).Elem()
+			}
+			argVal, err := toGoValue(arg, paramType)
+			if err != nil {
+				panic(codedError(CodeGoArgument, i+1, err))
+			}
+			in[i] = argVal
+		}
+		return fromGoResults(fnVal.Call(in))
+	}), nil
+}
+
+func fromGoResults(out []reflect.Value) RuntimeVal {
+	if len(out) == 0 {
+		return NullVal{}
+	}
+	last := out[len(out)-1]
+	if last.Type() == errorType {
+		if !last.IsNil() {
+			panic(WrapRuntimeError(last.Interface().(error)))
+		}
+		out = out[:len(out)-1]
+	}
+	if len(out) == 0 {
+		return NullVal{}
+	}
+	return fromGoValue(out[0])
+}
+
+// RegisterFunc declares a Go function as a global native function, see NewGoFuncVal
+func (in *Interpreter) RegisterFunc(name string, fn any) error {
+	funcVal, err := NewGoFuncVal(fn)
+	if err != nil {
+		return err
+	}
+	in.Set(name, funcVal)
+	return nil
+}
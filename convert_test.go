@@ -0,0 +1,64 @@
+package blulang_test
+
+import (
+	"blulang"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+type student struct {
+	Name   string `blu:"name"`
+	Scores []int  `blu:"scores"`
+	Active bool   `blu:"active"`
+	secret string
+}
+
+func TestFromGoAndToGo(t *testing.T) {
+	interpreter := blulang.New(blulang.Options{})
+	interpreter.Set("s", blulang.FromGo(&student{Name: "Lan", Scores: []int{7, 9}, Active: true}))
+	interpreter.Set("limits", blulang.FromGo(map[string]int{"max": 10}))
+	result, err := interpreter.Run(context.Background(), `
+	let total = s.scores[0] + s.scores[1] + limits.max
+	let result = [s.name, total, s.active]
+	result
+	`)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{"Lan", 26, true}, blulang.ToGo(result))
+
+	object, err := interpreter.Run(context.Background(), `{ name: "Minh", scores: [1, 2], nested: { a: 1 } }`)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"name": "Minh", "scores": []any{1, 2}, "nested": map[string]any{"a": 1}}, blulang.ToGo(object))
+	assert.Nil(t, blulang.ToGo(blulang.FromGo(nil)))
+}
+
+func TestRegisterFunc(t *testing.T) {
+	interpreter := blulang.New(blulang.Options{})
+	assert.NoError(t, interpreter.RegisterFunc("join", strings.Join))
+	assert.NoError(t, interpreter.RegisterFunc("sum", func(first int, rest ...int) int {
+		for _, v := range rest {
+			first += v
+		}
+		return first
+	}))
+	assert.NoError(t, interpreter.RegisterFunc("average", func(s student) (int, error) {
+		if len(s.Scores) == 0 {
+			return 0, errors.New("no scores")
+		}
+		return (s.Scores[0] + s.Scores[1]) / len(s.Scores), nil
+	}))
+	assert.Error(t, interpreter.RegisterFunc("notAFunc", 1))
+
+	result, err := interpreter.Run(context.Background(), `[join(["a", "b"], "-"), sum(1, 2, 3), average({ scores: [4, 8] })]`)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{"a-b", 6, 6}, blulang.ToGo(result))
+
+	_, err = interpreter.Run(context.Background(), `average({ name: "empty" })`)
+	assert.EqualError(t, err, "runtime error: no scores")
+	_, err = interpreter.Run(context.Background(), `sum("one")`)
+	assert.EqualError(t, err, "runtime error: argument 1: cannot use StringVal as int")
+	_, err = interpreter.Run(context.Background(), `join(["a"])`)
+	assert.EqualError(t, err, "runtime error: expected 2 arguments but got 1")
+}
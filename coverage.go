@@ -0,0 +1,66 @@
+package blulang
+
+// Coverable lists what coverage counts in a program: the statements a Hook is told about,
+// in the order they are written, and the ifs, whose two bodies are its branches. The break
+// and return statements aren't counted, they only end a body, nor the tests and their statements.
+func (p Program) Coverable() (statements []Statement, conditionals []ConditionalExpression) {
+	c := &coverable{}
+	c.body(p.body)
+	return c.statements, c.conditionals
+}
+
+type coverable struct {
+	statements   []Statement
+	conditionals []ConditionalExpression
+}
+
+// body adds the statements of a body and what they hold
+func (c *coverable) body(body []Statement) {
+	for _, statement := range body {
+		switch statement.Kind() {
+		case StmtBreak, StmtReturn, StmtTestBlock:
+			continue
+		}
+		c.statements = append(c.statements, statement)
+		c.expression(statement)
+	}
+}
+
+// expression adds the bodies found in an expression, the ones of functions and of ifs and whiles
+func (c *coverable) expression(expression Statement) {
+	switch expression := expression.(type) {
+	case VarDeclareExpression:
+		c.expression(expression.valueExpr)
+	case FuncDeclareExpression:
+		c.body(expression.body)
+	case ConditionalExpression:
+		c.conditionals = append(c.conditionals, expression)
+		c.expression(expression.condition)
+		c.body(expression.trueBody)
+		c.body(expression.falseBody)
+	case WhileLoopExpression:
+		c.expression(expression.condition)
+		c.body(expression.body)
+	case BinaryExpression:
+		c.expression(expression.left)
+		c.expression(expression.right)
+	case FuncCallExpression:
+		for _, argument := range expression.arguments {
+			c.expression(argument)
+		}
+	case ArrayAccessExpr:
+		c.expression(expression.index)
+	case ArrayLiteral:
+		for _, value := range expression.values {
+			c.expression(value)
+		}
+	case ObjectDeclareExpr:
+		for _, value := range expression.values {
+			c.expression(value)
+		}
+	case ObjectAccessExpr:
+		c.expression(expression.property)
+	case ExportExpr:
+		c.expression(expression.declaration)
+	}
+}
@@ -0,0 +1,199 @@
+// Package coverage records which statements of BluLang runs are evaluated and which bodies
+// of their ifs are chosen, so teachers see what the tests of a student exercise. A Recorder is
+// a blulang.BranchHook gathering the runs of any number of interpreters, its files are reported
+// as a text summary, an HTML page of the highlighted sources or an lcov file.
+package coverage
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"blulang"
+)
+
+// Recorder counts the statements and the branches evaluated in files, sources run without
+// a file aren't counted
+type Recorder struct {
+	mu    sync.Mutex
+	files map[string]*hits
+}
+
+// hits are the counts of a file by the position of the statements and of the ifs
+type hits struct {
+	statements map[blulang.Position]int
+	branches   map[blulang.Position]*[2]int
+}
+
+// New creates a recorder, the same one may follow several runs one after the other or at once
+func New() *Recorder {
+	return &Recorder{files: make(map[string]*hits)}
+}
+
+func (r *Recorder) Statement(statement blulang.Statement, stack []*blulang.Frame) {
+	if hits := r.file(stack); hits != nil {
+		hits.statements[statement.Pos()]++
+		r.mu.Unlock()
+	}
+}
+
+func (r *Recorder) Call([]*blulang.Frame) {}
+
+func (r *Recorder) Return([]*blulang.Frame, blulang.RuntimeVal) {}
+
+func (r *Recorder) Branch(conditional blulang.ConditionalExpression, taken bool, stack []*blulang.Frame) {
+	if hits := r.file(stack); hits != nil {
+		counts := hits.branches[conditional.Pos()]
+		if counts == nil {
+			counts = &[2]int{}
+			hits.branches[conditional.Pos()] = counts
+		}
+		if taken {
+			counts[0]++
+		} else {
+			counts[1]++
+		}
+		r.mu.Unlock()
+	}
+}
+
+// file locks the recorder and gives the hits of the file running the last frame,
+// it is nil and the recorder unlocked when the source isn't a file
+func (r *Recorder) file(stack []*blulang.Frame) *hits {
+	path := stack[len(stack)-1].File
+	if path == "" {
+		return nil
+	}
+	r.mu.Lock()
+	if r.files[path] == nil {
+		r.files[path] = &hits{statements: make(map[blulang.Position]int), branches: make(map[blulang.Position]*[2]int)}
+	}
+	return r.files[path]
+}
+
+// File is the coverage of a source file
+type File struct {
+	// Path is the path of the file, absolute unless it was changed for the report
+	Path  string
+	Lines []string
+	// Statements and Branches are in the order they are written
+	Statements []Statement
+	Branches   []Branch
+}
+
+// Statement is how many times a statement was evaluated
+type Statement struct {
+	Start blulang.Position
+	End   blulang.Position
+	Hits  int
+}
+
+// Branch is how many times each body of an if was chosen, an if without else
+// has a false body too
+type Branch struct {
+	Pos   blulang.Position
+	True  int
+	False int
+}
+
+// Summary counts the statements and the branches of files and how many of them were evaluated
+type Summary struct {
+	Statements        int
+	CoveredStatements int
+	Branches          int
+	CoveredBranches   int
+}
+
+// Files gives the coverage of every file the runs evaluated, sorted by path. The files
+// are read again, so they must not change between the runs and the report.
+func (r *Recorder) Files() ([]File, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var files []File
+	for path, hits := range r.files {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		parser := blulang.NewParser()
+		program, err := parser.Parse(string(source))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		file := File{Path: path, Lines: strings.Split(strings.TrimSuffix(string(source), "\n"), "\n")}
+		statements, conditionals := program.Coverable()
+		for _, statement := range statements {
+			file.Statements = append(file.Statements, Statement{Start: statement.Pos(), End: statement.End(), Hits: hits.statements[statement.Pos()]})
+		}
+		for _, conditional := range conditionals {
+			branch := Branch{Pos: conditional.Pos()}
+			if counts := hits.branches[conditional.Pos()]; counts != nil {
+				branch.True, branch.False = counts[0], counts[1]
+			}
+			file.Branches = append(file.Branches, branch)
+		}
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// Summary counts the statements and the branches of the file
+func (f File) Summary() Summary {
+	summary := Summary{Statements: len(f.Statements), Branches: 2 * len(f.Branches)}
+	for _, statement := range f.Statements {
+		if statement.Hits > 0 {
+			summary.CoveredStatements++
+		}
+	}
+	for _, branch := range f.Branches {
+		summary.CoveredBranches += min(branch.True, 1) + min(branch.False, 1)
+	}
+	return summary
+}
+
+// Add gives the summary of the files of both summaries
+func (s Summary) Add(other Summary) Summary {
+	return Summary{
+		Statements:        s.Statements + other.Statements,
+		CoveredStatements: s.CoveredStatements + other.CoveredStatements,
+		Branches:          s.Branches + other.Branches,
+		CoveredBranches:   s.CoveredBranches + other.CoveredBranches,
+	}
+}
+
+// line is what a line of a file holds: the hits of the statements starting on it
+// and the branches of the ifs starting on it
+type line struct {
+	statements []int
+	branches   []Branch
+}
+
+// lines groups the statements and the ifs of the file by the line they start on, from 1
+func (f File) lines() map[int]*line {
+	lines := make(map[int]*line)
+	at := func(number int) *line {
+		if lines[number] == nil {
+			lines[number] = &line{}
+		}
+		return lines[number]
+	}
+	for _, statement := range f.Statements {
+		at(statement.Start.Line).statements = append(at(statement.Start.Line).statements, statement.Hits)
+	}
+	for _, branch := range f.Branches {
+		at(branch.Pos.Line).branches = append(at(branch.Pos.Line).branches, branch)
+	}
+	return lines
+}
+
+// hits is the number of times the line ran, the one of its statement that ran the most
+func (l *line) hits() int {
+	hits := 0
+	for _, statementHits := range l.statements {
+		hits = max(hits, statementHits)
+	}
+	return hits
+}
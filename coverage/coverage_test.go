@@ -0,0 +1,115 @@
+package coverage_test
+
+import (
+	"blulang"
+	"blulang/coverage"
+	"blulang/testrunner"
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const gradeSource = `export fn grade(score) {
+    if score >= 90 {
+        "A"
+    } else if score >= 50 {
+        "B"
+    } else {
+        "F"
+    }
+}
+export fn unused(x) {
+    x * 2
+}
+`
+
+const gradeTests = `import "grade.blu"
+test "top" {
+    assertEqual(grade.grade(95), "A")
+}
+test "middle" {
+    assertEqual(grade.grade(60), "B")
+}
+`
+
+// covered runs the tests of grade_test.blu and gives the coverage of grade.blu
+func covered(t *testing.T) coverage.File {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "grade.blu"), []byte(gradeSource), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "grade_test.blu"), []byte(gradeTests), 0o644))
+	recorder := coverage.New()
+	results, err := testrunner.RunFile(context.Background(), filepath.Join(dir, "grade_test.blu"), testrunner.Options{Capabilities: blulang.CapFilesystem, Hook: recorder})
+	require.NoError(t, err)
+	for _, result := range results {
+		require.Equal(t, testrunner.Passed, result.Status, result.Err)
+	}
+	files, err := recorder.Files()
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, filepath.Join(dir, "grade_test.blu"), files[1].Path)
+	// the statements of the tests aren't counted
+	assert.Equal(t, coverage.Summary{Statements: 1, CoveredStatements: 1}, files[1].Summary())
+	files[0].Path = "grade.blu"
+	return files[0]
+}
+
+func TestRecorder(t *testing.T) {
+	file := covered(t)
+	var hits []int
+	for _, statement := range file.Statements {
+		hits = append(hits, statement.Hits)
+	}
+	// the else if is a statement of the false body of the first if
+	assert.Equal(t, []int{2, 2, 1, 1, 1, 0, 2, 0}, hits)
+	assert.Equal(t, []coverage.Branch{
+		{Pos: blulang.Position{Line: 2, Column: 5}, True: 1, False: 1},
+		{Pos: blulang.Position{Line: 4, Column: 12}, True: 1, False: 0},
+	}, file.Branches)
+	assert.Equal(t, coverage.Summary{Statements: 8, CoveredStatements: 6, Branches: 4, CoveredBranches: 3}, file.Summary())
+}
+
+func TestRecorderWithoutFile(t *testing.T) {
+	recorder := coverage.New()
+	_, err := blulang.New(blulang.Options{Hook: recorder}).Run(context.Background(), "if 1 == 1 { 2 }")
+	require.NoError(t, err)
+	files, err := recorder.Files()
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestWriteText(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, coverage.WriteText(&out, []coverage.File{covered(t), {Path: "empty.blu"}}))
+	assert.Equal(t, `file       statements         branches
+grade.blu  75.0%       (6/8)  75.0%  (3/4)
+empty.blu  -           (0/0)  -      (0/0)
+total      75.0%       (6/8)  75.0%  (3/4)
+`, out.String())
+}
+
+func TestWriteLCOV(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, coverage.WriteLCOV(&out, []coverage.File{covered(t)}))
+	assert.Equal(t, strings.Join([]string{
+		"TN:", "SF:grade.blu",
+		"BRDA:2,0,0,1", "BRDA:2,0,1,1", "BRDA:4,1,0,1", "BRDA:4,1,1,0", "BRF:4", "BRH:3",
+		"DA:1,2", "DA:2,2", "DA:3,1", "DA:4,1", "DA:5,1", "DA:7,0", "DA:10,2", "DA:11,0", "LF:8", "LH:6",
+		"end_of_record", "",
+	}, "\n"), out.String())
+}
+
+func TestWriteHTML(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, coverage.WriteHTML(&out, []coverage.File{covered(t)}))
+	html := out.String()
+	assert.Contains(t, html, `<tr><td><a href="#file0">grade.blu</a></td><td>75.0% (6/8)</td><td>75.0% (3/4)</td></tr>`)
+	assert.Contains(t, html, `<span class="covered" title="runs: 2, if: true 1, false 1"><span class="number">2</span>    if score &gt;= 90 {</span>`)
+	assert.Contains(t, html, `<span class="partial" title="runs: 1, if: true 1, false 0"><span class="number">4</span>`)
+	assert.Contains(t, html, `<span class="uncovered" title="runs: 0"><span class="number">7</span>        &#34;F&#34;</span>`)
+	assert.Contains(t, html, `<span><span class="number">8</span>    }</span>`)
+}
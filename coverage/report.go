@@ -0,0 +1,187 @@
+package coverage
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Percent shows the share of covered statements or branches, or "-" when there are none
+func Percent(covered int, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(covered)/float64(total))
+}
+
+// WriteText writes a line per file with the share of its statements and branches that were
+// evaluated, and the total
+func WriteText(w io.Writer, files []File) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "file\tstatements\t\tbranches")
+	var total Summary
+	for _, file := range files {
+		summary := file.Summary()
+		total = total.Add(summary)
+		writeSummary(table, file.Path, summary)
+	}
+	writeSummary(table, "total", total)
+	return table.Flush()
+}
+
+func writeSummary(w io.Writer, name string, summary Summary) {
+	fmt.Fprintf(w, "%s\t%s\t(%d/%d)\t%s\t(%d/%d)\n", name,
+		Percent(summary.CoveredStatements, summary.Statements), summary.CoveredStatements, summary.Statements,
+		Percent(summary.CoveredBranches, summary.Branches), summary.CoveredBranches, summary.Branches)
+}
+
+// WriteLCOV writes the files in the lcov tracefile format read by genhtml and CI services:
+// the lines are the ones statements start on, run as many times as their statement that ran
+// the most, and each if has two branches
+func WriteLCOV(w io.Writer, files []File) error {
+	var out strings.Builder
+	out.WriteString("TN:\n")
+	for _, file := range files {
+		fmt.Fprintf(&out, "SF:%s\n", file.Path)
+		summary := file.Summary()
+		for i, branch := range file.Branches {
+			if branch.True+branch.False == 0 {
+				fmt.Fprintf(&out, "BRDA:%d,%d,0,-\nBRDA:%d,%d,1,-\n", branch.Pos.Line, i, branch.Pos.Line, i)
+				continue
+			}
+			fmt.Fprintf(&out, "BRDA:%d,%d,0,%d\nBRDA:%d,%d,1,%d\n", branch.Pos.Line, i, branch.True, branch.Pos.Line, i, branch.False)
+		}
+		fmt.Fprintf(&out, "BRF:%d\nBRH:%d\n", summary.Branches, summary.CoveredBranches)
+		lines := file.lines()
+		found, hit := 0, 0
+		for number := 1; number <= len(file.Lines); number++ {
+			line := lines[number]
+			if line == nil || len(line.statements) == 0 {
+				continue
+			}
+			found++
+			if line.hits() > 0 {
+				hit++
+			}
+			fmt.Fprintf(&out, "DA:%d,%d\n", number, line.hits())
+		}
+		fmt.Fprintf(&out, "LF:%d\nLH:%d\nend_of_record\n", found, hit)
+	}
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// htmlFile and htmlLine are what the HTML report shows of a file and of its lines
+type htmlFile struct {
+	ID      string
+	Path    string
+	Summary Summary
+	Lines   []htmlLine
+}
+
+type htmlLine struct {
+	Number int
+	Text   string
+	// Class is covered, uncovered or partial for lines starting statements or ifs, and Title
+	// tells how many times they ran and which bodies the ifs chose
+	Class string
+	Title string
+}
+
+// WriteHTML writes a page showing the summary of every file and its source, the lines starting
+// statements or ifs are green when they all ran and both bodies of the ifs were chosen, red when
+// nothing did and yellow otherwise
+func WriteHTML(w io.Writer, files []File) error {
+	var report struct {
+		Files []htmlFile
+		Total Summary
+	}
+	for i, file := range files {
+		shown := htmlFile{ID: fmt.Sprintf("file%d", i), Path: file.Path, Summary: file.Summary()}
+		report.Total = report.Total.Add(shown.Summary)
+		lines := file.lines()
+		for number, text := range file.Lines {
+			shown.Lines = append(shown.Lines, lines[number+1].html(number+1, text))
+		}
+		report.Files = append(report.Files, shown)
+	}
+	return htmlReport.Execute(w, report)
+}
+
+// html shows a line, which holds nothing to count when l is nil
+func (l *line) html(number int, text string) htmlLine {
+	shown := htmlLine{Number: number, Text: text}
+	if l == nil {
+		return shown
+	}
+	// the statements and both bodies of the ifs are counted alike
+	covered, missed := 0, 0
+	count := func(hits int) {
+		if hits > 0 {
+			covered++
+		} else {
+			missed++
+		}
+	}
+	var titles []string
+	for _, hits := range l.statements {
+		count(hits)
+	}
+	if len(l.statements) > 0 {
+		titles = append(titles, fmt.Sprintf("runs: %d", l.hits()))
+	}
+	for _, branch := range l.branches {
+		count(branch.True)
+		count(branch.False)
+		titles = append(titles, fmt.Sprintf("if: true %d, false %d", branch.True, branch.False))
+	}
+	switch {
+	case missed == 0:
+		shown.Class = "covered"
+	case covered == 0:
+		shown.Class = "uncovered"
+	default:
+		shown.Class = "partial"
+	}
+	shown.Title = strings.Join(titles, ", ")
+	return shown
+}
+
+var htmlReport = template.Must(template.New("coverage").Funcs(template.FuncMap{"percent": Percent}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>BluLang coverage</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table.summary td, table.summary th { padding: 0.2em 1em; text-align: left; }
+pre { background: #f8f8f8; padding: 0.5em; line-height: 1.4; }
+.number { display: inline-block; width: 4em; color: #999; text-align: right; padding-right: 1em; user-select: none; }
+.covered { background: #d7f5d7; }
+.uncovered { background: #f8d0d0; }
+.partial { background: #faf0c0; }
+</style>
+</head>
+<body>
+<h1>Coverage</h1>
+<table class="summary">
+<tr><th>File</th><th>Statements</th><th>Branches</th></tr>
+{{- range .Files}}
+<tr><td><a href="#{{.ID}}">{{.Path}}</a></td><td>{{template "share" .Summary}}</td><td>{{template "branches" .Summary}}</td></tr>
+{{- end}}
+<tr><th>Total</th><th>{{template "share" .Total}}</th><th>{{template "branches" .Total}}</th></tr>
+</table>
+{{- range .Files}}
+<h2 id="{{.ID}}">{{.Path}}</h2>
+<pre>
+{{- range .Lines}}
+<span{{if .Class}} class="{{.Class}}" title="{{.Title}}"{{end}}><span class="number">{{.Number}}</span>{{.Text}}</span>
+{{- end}}
+</pre>
+{{- end}}
+</body>
+</html>
+{{- define "share"}}{{percent .CoveredStatements .Statements}} ({{.CoveredStatements}}/{{.Statements}}){{end}}
+{{- define "branches"}}{{percent .CoveredBranches .Branches}} ({{.CoveredBranches}}/{{.Branches}}){{end}}`))
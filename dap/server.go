@@ -0,0 +1,494 @@
+// Package dap is a debug adapter for BluLang speaking the debug adapter protocol over a pair of
+// streams, so editors such as VS Code and Neovim can run .blu files under the debugger.
+package dap
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"blulang"
+	"blulang/debugger"
+	"blulang/internal/jsonrpc"
+)
+
+// Options configure the runs of a server
+type Options struct {
+	// Language forces the language of error messages, by default they are in the language of each file
+	Language *blulang.Locale
+	// Stdin is read by the input builtin, nothing by default since the streams of the server
+	// carry the protocol
+	Stdin io.Reader
+	// SearchPaths are the directories imports are looked up in after the one of the importing file
+	SearchPaths []string
+}
+
+// Server runs one program for an editor, which sets its breakpoints, steps through it
+// and inspects its frames and variables
+type Server struct {
+	in      *bufio.Reader
+	out     io.Writer
+	options Options
+	// lineOffset and columnOffset are subtracted from the positions sent to the client,
+	// 1 when it counts from 0
+	lineOffset   int
+	columnOffset int
+
+	program  string
+	launch   launchArguments
+	debugger *debugger.Debugger
+	// after is called once the response to the current request is written, so events
+	// of the run follow the response that started them
+	after func()
+	// references are the scopes and values the client can ask the variables of,
+	// they are valid until the run goes on
+	references []any
+	actions    chan debugger.Action
+	cancel     context.CancelFunc
+	done       chan struct{}
+
+	// mu guards the output, the sequence numbers and the stop shared with the run
+	mu       sync.Mutex
+	seq      int
+	stop     *debugger.Stop
+	quitting bool
+}
+
+// NewServer creates a server reading messages from in and writing to out, usually stdin and stdout
+func NewServer(in io.Reader, out io.Writer, options Options) *Server {
+	return &Server{in: bufio.NewReader(in), out: out, options: options, actions: make(chan debugger.Action)}
+}
+
+// Run answers requests until the client disconnects or closes the input, a program still
+// running is then ended
+func (s *Server) Run() error {
+	defer s.quit()
+	for {
+		content, err := jsonrpc.ReadMessage(s.in)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var message request
+		if err := json.Unmarshal(content, &message); err != nil {
+			return fmt.Errorf("dap: invalid message: %w", err)
+		}
+		body, err := s.dispatch(message)
+		if err := s.respond(message, body, err); err != nil {
+			return err
+		}
+		if s.after != nil {
+			after := s.after
+			s.after = nil
+			after()
+		}
+		if message.Command == "disconnect" {
+			return nil
+		}
+	}
+}
+
+func (s *Server) dispatch(message request) (body any, err error) {
+	defer func() {
+		// a failing request must not stop the client from using the others
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	switch message.Command {
+	case "initialize":
+		return withArguments(message, s.initialize)
+	case "launch":
+		return withArguments(message, s.launchProgram)
+	case "setBreakpoints":
+		return withArguments(message, s.setBreakpoints)
+	case "configurationDone":
+		return nil, s.configurationDone()
+	case "threads":
+		return map[string]any{"threads": []thread{{ID: threadID, Name: "main"}}}, nil
+	case "stackTrace":
+		return s.stackTrace()
+	case "scopes":
+		return withArguments(message, s.scopes)
+	case "variables":
+		return withArguments(message, s.variables)
+	case "evaluate":
+		return withArguments(message, s.evaluate)
+	case "continue":
+		return map[string]any{"allThreadsContinued": true}, s.resume(debugger.Continue)
+	case "next":
+		return nil, s.resume(debugger.StepOver)
+	case "stepIn":
+		return nil, s.resume(debugger.StepIn)
+	case "stepOut":
+		return nil, s.resume(debugger.StepOut)
+	case "pause":
+		if _, err := s.current(); err != nil && s.debugger != nil {
+			s.debugger.Pause()
+		}
+		return nil, nil
+	case "terminate", "disconnect":
+		s.quit()
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unsupported request: %s", message.Command)
+}
+
+func withArguments[A any](message request, handler func(A) (any, error)) (any, error) {
+	var arguments A
+	if len(message.Arguments) > 0 {
+		if err := json.Unmarshal(message.Arguments, &arguments); err != nil {
+			return nil, err
+		}
+	}
+	return handler(arguments)
+}
+
+func (s *Server) respond(message request, body any, err error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	answer := response{Seq: s.seq, Type: "response", RequestSeq: message.Seq, Success: err == nil, Command: message.Command, Body: body}
+	if err != nil {
+		answer.Message = err.Error()
+	}
+	return jsonrpc.WriteMessage(s.out, answer)
+}
+
+// send writes an event, from the goroutine of the server or of the run
+func (s *Server) send(name string, body any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	return jsonrpc.WriteMessage(s.out, event{Seq: s.seq, Type: "event", Event: name, Body: body})
+}
+
+func (s *Server) initialize(arguments initializeArguments) (any, error) {
+	if arguments.LinesStartAt1 != nil && !*arguments.LinesStartAt1 {
+		s.lineOffset = 1
+	}
+	if arguments.ColumnsStartAt1 != nil && !*arguments.ColumnsStartAt1 {
+		s.columnOffset = 1
+	}
+	return map[string]any{
+		"supportsConfigurationDoneRequest": true,
+		"supportsConditionalBreakpoints":   true,
+		"supportsEvaluateForHovers":        true,
+		"supportsTerminateRequest":         true,
+	}, nil
+}
+
+// launchProgram prepares the run of a program, which starts once the client is done
+// setting the breakpoints
+func (s *Server) launchProgram(arguments launchArguments) (any, error) {
+	if s.program != "" {
+		return nil, errors.New("a program is already launched")
+	}
+	program, _ := filepath.Abs(arguments.Program)
+	if _, err := os.Stat(program); err != nil {
+		return nil, errors.New(blulang.Localize(s.options.Language, blulang.CodeReadFile, err))
+	}
+	s.program, s.launch = program, arguments
+	s.debugger = debugger.New(arguments.StopOnEntry, s.stopped)
+	s.after = func() { s.send("initialized", nil) }
+	return nil, nil
+}
+
+func (s *Server) setBreakpoints(arguments setBreakpointsArguments) (any, error) {
+	if s.debugger == nil {
+		return nil, errors.New("breakpoints are set once the program is launched")
+	}
+	file, _ := filepath.Abs(arguments.Source.Path)
+	breakpoints := make([]debugger.Breakpoint, len(arguments.Breakpoints))
+	verified := make([]breakpoint, len(arguments.Breakpoints))
+	for i, requested := range arguments.Breakpoints {
+		breakpoints[i] = debugger.Breakpoint{Line: requested.Line + s.lineOffset, Condition: requested.Condition}
+		verified[i] = breakpoint{Verified: true, Line: requested.Line}
+	}
+	s.debugger.SetBreakpoints(file, breakpoints)
+	return map[string]any{"breakpoints": verified}, nil
+}
+
+func (s *Server) configurationDone() error {
+	if s.program == "" {
+		return errors.New("no program is launched")
+	}
+	if s.done != nil {
+		return nil
+	}
+	var ctx context.Context
+	ctx, s.cancel = context.WithCancel(context.Background())
+	s.done = make(chan struct{})
+	go s.run(ctx)
+	return nil
+}
+
+// run runs the program on its own goroutine, the server goes on answering requests
+func (s *Server) run(ctx context.Context) {
+	defer close(s.done)
+	stdin := s.options.Stdin
+	if stdin == nil {
+		stdin = strings.NewReader("")
+	}
+	options := blulang.Options{
+		Args:         s.launch.Args,
+		Stdin:        stdin,
+		Stdout:       output{s, "stdout"},
+		Stderr:       output{s, "stderr"},
+		Capabilities: blulang.AllCapabilities,
+		SearchPaths:  s.options.SearchPaths,
+		Language:     s.options.Language,
+	}
+	if !s.launch.NoDebug {
+		options.Hook = s.debugger
+	}
+	_, err := blulang.New(options).RunFile(ctx, s.program)
+	exitCode := 0
+	var exitError blulang.ExitError
+	switch {
+	case errors.As(err, &exitError):
+		exitCode = exitError.Code
+	case errors.Is(err, debugger.ErrQuit), errors.Is(err, context.Canceled):
+	case err != nil:
+		s.send("output", outputEvent{Category: "stderr", Output: s.describe(err) + "\n"})
+		exitCode = 1
+	}
+	s.send("exited", map[string]any{"exitCode": exitCode})
+	s.send("terminated", nil)
+}
+
+// describe shows an error of the program with its file and code, as the command line does
+func (s *Server) describe(err error) string {
+	if s.options.Language != nil {
+		err = blulang.LocalizeError(err, s.options.Language)
+	}
+	var code blulang.MessageCode
+	var syntaxError blulang.SyntaxError
+	var runtimeError blulang.RuntimeError
+	if errors.As(err, &syntaxError) {
+		code = syntaxError.Code
+	} else if errors.As(err, &runtimeError) {
+		code = runtimeError.Code
+	}
+	if code == "" {
+		return fmt.Sprintf("%s: %v", filepath.Base(s.program), err)
+	}
+	return fmt.Sprintf("%s: %v [%s]", filepath.Base(s.program), err, code)
+}
+
+// stopped is called by the debugger on the goroutine of the run, which waits for the client to resume it
+func (s *Server) stopped(stop *debugger.Stop) debugger.Action {
+	s.mu.Lock()
+	if s.quitting {
+		s.mu.Unlock()
+		return debugger.Quit
+	}
+	s.stop = stop
+	s.mu.Unlock()
+	body := stoppedEvent{Reason: string(stop.Reason), ThreadID: threadID, AllThreadsStopped: true}
+	if stop.ConditionErr != nil {
+		body.Description = fmt.Sprintf("the condition %q failed: %v", stop.Breakpoint.Condition, stop.ConditionErr)
+	}
+	s.send("stopped", body)
+	return <-s.actions
+}
+
+// current is the stop the run is waiting in
+func (s *Server) current() (*debugger.Stop, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop == nil {
+		return nil, errors.New("the program is running")
+	}
+	return s.stop, nil
+}
+
+// resume lets the run go on with an action once the response is written
+func (s *Server) resume(action debugger.Action) error {
+	if _, err := s.current(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.stop = nil
+	s.mu.Unlock()
+	s.references = nil
+	s.after = func() { s.actions <- action }
+	return nil
+}
+
+// quit ends the run and waits for it
+func (s *Server) quit() {
+	if s.done == nil {
+		return
+	}
+	s.cancel()
+	s.mu.Lock()
+	s.quitting = true
+	stopped := s.stop != nil
+	s.stop = nil
+	s.mu.Unlock()
+	if stopped {
+		s.actions <- debugger.Quit
+	}
+	<-s.done
+}
+
+// frameIndex finds a frame of the stop by the id given in the stack trace
+func frameIndex(stop *debugger.Stop, id int) (int, error) {
+	if id < 1 || id > len(stop.Stack) {
+		return 0, fmt.Errorf("no frame %d", id)
+	}
+	return id - 1, nil
+}
+
+func (s *Server) stackTrace() (any, error) {
+	stop, err := s.current()
+	if err != nil {
+		return nil, err
+	}
+	frames := make([]stackFrame, 0, len(stop.Stack))
+	for i := len(stop.Stack) - 1; i >= 0; i-- {
+		frame := stop.Stack[i]
+		shown := stackFrame{
+			ID:     len(stop.Stack) - i,
+			Name:   frame.Function,
+			Line:   frame.Pos.Line - s.lineOffset,
+			Column: frame.Pos.Column - s.columnOffset,
+		}
+		if shown.Name == "" {
+			shown.Name = "(top level)"
+		}
+		if frame.File != "" {
+			shown.Source = &source{Name: filepath.Base(frame.File), Path: frame.File}
+		}
+		frames = append(frames, shown)
+	}
+	return map[string]any{"stackFrames": frames, "totalFrames": len(frames)}, nil
+}
+
+// scopes lists the scopes of a frame from the innermost block to the global scope
+func (s *Server) scopes(arguments frameArguments) (any, error) {
+	stop, err := s.current()
+	if err != nil {
+		return nil, err
+	}
+	index, err := frameIndex(stop, arguments.FrameID)
+	if err != nil {
+		return nil, err
+	}
+	scopes := []scope{}
+	level := 0
+	for current := stop.Stack[len(stop.Stack)-1-index].Scope; current != nil; current = current.Parent() {
+		name := "Locals"
+		switch {
+		case current.Parent() == nil:
+			name = "Globals"
+		case level > 0:
+			name = "Enclosing " + strconv.Itoa(level)
+		}
+		scopes = append(scopes, scope{Name: name, VariablesReference: s.reference(current)})
+		level++
+	}
+	return map[string]any{"scopes": scopes}, nil
+}
+
+// reference gives an id to a scope or a value whose variables the client may ask for
+func (s *Server) reference(container any) int {
+	s.references = append(s.references, container)
+	return len(s.references)
+}
+
+func (s *Server) variables(arguments variablesArguments) (any, error) {
+	if _, err := s.current(); err != nil {
+		return nil, err
+	}
+	if arguments.VariablesReference < 1 || arguments.VariablesReference > len(s.references) {
+		return nil, fmt.Errorf("no variables %d", arguments.VariablesReference)
+	}
+	variables := []variable{}
+	switch container := s.references[arguments.VariablesReference-1].(type) {
+	case *blulang.Scope:
+		declared := container.Variables()
+		for _, name := range slices.Sorted(maps.Keys(declared)) {
+			variables = append(variables, s.variable(name, declared[name]))
+		}
+	case blulang.ArrayVal:
+		for i, element := range container.Value().([]blulang.RuntimeVal) {
+			variables = append(variables, s.variable(strconv.Itoa(i), element))
+		}
+	case blulang.ObjectVal:
+		properties := container.Value().(map[string]blulang.RuntimeVal)
+		for _, name := range slices.Sorted(maps.Keys(properties)) {
+			variables = append(variables, s.variable(name, properties[name]))
+		}
+	}
+	return map[string]any{"variables": variables}, nil
+}
+
+// variable shows a value, arrays and objects with elements can be expanded
+func (s *Server) variable(name string, value blulang.RuntimeVal) variable {
+	return variable{Name: name, Value: blulang.Inspect(value), Type: string(value.Kind()), VariablesReference: s.expand(value)}
+}
+
+func (s *Server) expand(value blulang.RuntimeVal) int {
+	switch value := value.(type) {
+	case blulang.ArrayVal:
+		if len(value.Value().([]blulang.RuntimeVal)) > 0 {
+			return s.reference(value)
+		}
+	case blulang.ObjectVal:
+		if len(value.Value().(map[string]blulang.RuntimeVal)) > 0 {
+			return s.reference(value)
+		}
+	}
+	return 0
+}
+
+// evaluate evaluates an expression in the scope of a frame, the last one when no frame is given
+func (s *Server) evaluate(arguments evaluateArguments) (any, error) {
+	stop, err := s.current()
+	if err != nil {
+		return nil, err
+	}
+	index := 0
+	if arguments.FrameID != 0 {
+		if index, err = frameIndex(stop, arguments.FrameID); err != nil {
+			return nil, err
+		}
+	}
+	value, err := stop.Evaluate(arguments.Expression, index)
+	if err != nil {
+		if s.options.Language != nil {
+			err = blulang.LocalizeError(err, s.options.Language)
+		}
+		return nil, err
+	}
+	return map[string]any{
+		"result":             blulang.Inspect(value),
+		"type":               string(value.Kind()),
+		"variablesReference": s.expand(value),
+	}, nil
+}
+
+// output sends what the program prints as output events
+type output struct {
+	server   *Server
+	category string
+}
+
+func (o output) Write(p []byte) (int, error) {
+	o.server.send("output", outputEvent{Category: o.category, Output: string(p)})
+	return len(p), nil
+}
@@ -0,0 +1,231 @@
+package dap_test
+
+import (
+	"blulang/dap"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+const program = `fn total(order) {
+    let sum = 0
+    let i = 0
+    while i < count(order.items) {
+        sum = sum + order.items[i]
+        i = i + 1
+    }
+    sum
+}
+let order = { id: 7, items: [2, 3] }
+print(total(order))
+`
+
+// client is a scripted debugger front end talking to a server over pipes
+type client struct {
+	t        *testing.T
+	in       *io.PipeWriter
+	messages chan map[string]any
+	// pending are the messages read while waiting for another one
+	pending []map[string]any
+	seq     int
+	done    chan error
+}
+
+func start(t *testing.T) *client {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	c := &client{t: t, in: clientOut, messages: make(chan map[string]any, 100), done: make(chan error, 1)}
+	go func() {
+		c.done <- dap.NewServer(serverIn, serverOut, dap.Options{}).Run()
+		serverOut.Close()
+	}()
+	go func() {
+		defer close(c.messages)
+		reader := bufio.NewReader(clientIn)
+		for {
+			header, err := textproto.NewReader(reader).ReadMIMEHeader()
+			if err != nil {
+				return
+			}
+			length, _ := strconv.Atoi(header.Get("Content-Length"))
+			content := make([]byte, length)
+			if _, err := io.ReadFull(reader, content); err != nil {
+				return
+			}
+			var message map[string]any
+			if err := json.Unmarshal(content, &message); err != nil {
+				return
+			}
+			c.messages <- message
+		}
+	}()
+	return c
+}
+
+// next returns the first message matching, the others are kept for later
+func (c *client) next(matches func(message map[string]any) bool) map[string]any {
+	for i, message := range c.pending {
+		if matches(message) {
+			c.pending = append(c.pending[:i], c.pending[i+1:]...)
+			return message
+		}
+	}
+	for message := range c.messages {
+		if matches(message) {
+			return message
+		}
+		c.pending = append(c.pending, message)
+	}
+	require.FailNow(c.t, "the server closed the connection")
+	return nil
+}
+
+// request sends a request and waits for its response, it returns the body of a successful one
+func (c *client) request(command string, arguments any) map[string]any {
+	c.seq++
+	content, err := json.Marshal(map[string]any{"seq": c.seq, "type": "request", "command": command, "arguments": arguments})
+	require.NoError(c.t, err)
+	_, err = fmt.Fprintf(c.in, "Content-Length: %d\r\n\r\n%s", len(content), content)
+	require.NoError(c.t, err)
+	seq := float64(c.seq)
+	response := c.next(func(message map[string]any) bool {
+		return message["type"] == "response" && message["request_seq"] == seq
+	})
+	require.Equal(c.t, true, response["success"], "%s: %v", command, response["message"])
+	body, _ := response["body"].(map[string]any)
+	return body
+}
+
+func (c *client) failing(command string, arguments any) string {
+	c.seq++
+	content, _ := json.Marshal(map[string]any{"seq": c.seq, "type": "request", "command": command, "arguments": arguments})
+	fmt.Fprintf(c.in, "Content-Length: %d\r\n\r\n%s", len(content), content)
+	seq := float64(c.seq)
+	response := c.next(func(message map[string]any) bool {
+		return message["type"] == "response" && message["request_seq"] == seq
+	})
+	assert.Equal(c.t, false, response["success"])
+	return response["message"].(string)
+}
+
+// event waits for an event and returns its body
+func (c *client) event(name string) map[string]any {
+	event := c.next(func(message map[string]any) bool {
+		return message["type"] == "event" && message["event"] == name
+	})
+	body, _ := event["body"].(map[string]any)
+	return body
+}
+
+// launch starts a program and sets its breakpoints
+func (c *client) launch(t *testing.T, source string, stopOnEntry bool, breakpoints ...map[string]any) string {
+	path := filepath.Join(t.TempDir(), "main.blu")
+	require.NoError(t, os.WriteFile(path, []byte(source), 0o644))
+	capabilities := c.request("initialize", map[string]any{"adapterID": "blulang", "linesStartAt1": true})
+	assert.Equal(t, true, capabilities["supportsConditionalBreakpoints"])
+	c.request("launch", map[string]any{"program": path, "stopOnEntry": stopOnEntry})
+	c.event("initialized")
+	body := c.request("setBreakpoints", map[string]any{"source": map[string]any{"path": path}, "breakpoints": breakpoints})
+	assert.Len(t, body["breakpoints"], len(breakpoints))
+	c.request("configurationDone", nil)
+	return path
+}
+
+// names lists a property of the items of a list of the body
+func names(body map[string]any, list string, property string) []any {
+	var names []any
+	for _, item := range body[list].([]any) {
+		names = append(names, item.(map[string]any)[property])
+	}
+	return names
+}
+
+func TestBreakpointsAndVariables(t *testing.T) {
+	c := start(t)
+	path := c.launch(t, program, false, map[string]any{"line": 5, "condition": "i == 1"})
+
+	assert.Equal(t, "breakpoint", c.event("stopped")["reason"])
+	assert.Equal(t, []any{"main"}, names(c.request("threads", nil), "threads", "name"))
+	stack := c.request("stackTrace", map[string]any{"threadId": 1})
+	assert.Equal(t, []any{"total", "(top level)"}, names(stack, "stackFrames", "name"))
+	assert.Equal(t, []any{float64(5), float64(11)}, names(stack, "stackFrames", "line"))
+	assert.Equal(t, path, stack["stackFrames"].([]any)[0].(map[string]any)["source"].(map[string]any)["path"])
+
+	// the body of the loop, the function and the global scope
+	scopes := c.request("scopes", map[string]any{"frameId": 1})
+	assert.Equal(t, []any{"Locals", "Enclosing 1", "Globals"}, names(scopes, "scopes", "name"))
+	references := names(scopes, "scopes", "variablesReference")
+	locals := c.request("variables", map[string]any{"variablesReference": references[1]})
+	assert.Equal(t, []any{"i", "order", "sum"}, names(locals, "variables", "name"))
+	assert.Equal(t, []any{"1", "{ id: 7, items: [2, 3] }", "2"}, names(locals, "variables", "value"))
+	assert.Equal(t, float64(0), names(locals, "variables", "variablesReference")[0], "numbers don't expand")
+
+	// objects and arrays expand
+	order := c.request("variables", map[string]any{"variablesReference": names(locals, "variables", "variablesReference")[1]})
+	assert.Equal(t, []any{"id", "items"}, names(order, "variables", "name"))
+	items := c.request("variables", map[string]any{"variablesReference": names(order, "variables", "variablesReference")[1]})
+	assert.Equal(t, []any{"0", "1"}, names(items, "variables", "name"))
+	assert.Equal(t, []any{"2", "3"}, names(items, "variables", "value"))
+	globals := c.request("variables", map[string]any{"variablesReference": references[2]})
+	assert.Equal(t, []any{"order", "total"}, names(globals, "variables", "name"))
+	assert.Equal(t, []any{"ObjectVal", "FuncVal"}, names(globals, "variables", "type"))
+
+	assert.Equal(t, "12", c.request("evaluate", map[string]any{"expression": "sum + 10", "frameId": 1})["result"])
+	assert.Equal(t, "7", c.request("evaluate", map[string]any{"expression": "order.id", "frameId": 2})["result"])
+	assert.NotEmpty(t, c.failing("evaluate", map[string]any{"expression": "sum +", "frameId": 1}))
+
+	c.request("next", map[string]any{"threadId": 1})
+	assert.Equal(t, "step", c.event("stopped")["reason"])
+	assert.Equal(t, []any{float64(6), float64(11)}, names(c.request("stackTrace", map[string]any{"threadId": 1}), "stackFrames", "line"))
+	c.request("stepOut", map[string]any{"threadId": 1})
+	assert.Equal(t, map[string]any{"category": "stdout", "output": "5\n"}, c.event("output"))
+	assert.Equal(t, float64(0), c.event("exited")["exitCode"])
+	c.event("terminated")
+	assert.Equal(t, "the program is running", c.failing("stackTrace", map[string]any{"threadId": 1}))
+	c.request("disconnect", nil)
+	assert.NoError(t, <-c.done)
+}
+
+func TestSteppingIntoCalls(t *testing.T) {
+	c := start(t)
+	c.launch(t, program, true)
+	assert.Equal(t, "entry", c.event("stopped")["reason"])
+	for _, line := range []float64{1, 10, 11} {
+		stack := c.request("stackTrace", map[string]any{"threadId": 1})
+		assert.Equal(t, line, names(stack, "stackFrames", "line")[0])
+		c.request("stepIn", map[string]any{"threadId": 1})
+		c.event("stopped")
+	}
+	stack := c.request("stackTrace", map[string]any{"threadId": 1})
+	assert.Equal(t, []any{"total", "(top level)"}, names(stack, "stackFrames", "name"))
+	assert.Equal(t, []any{float64(2), float64(11)}, names(stack, "stackFrames", "line"))
+	// disconnecting ends the stopped run
+	c.request("disconnect", nil)
+	assert.NoError(t, <-c.done)
+}
+
+func TestProgramErrors(t *testing.T) {
+	c := start(t)
+	c.launch(t, "let a = 1\nprint(a)\nlet a = 2\n", false)
+	assert.Equal(t, "1\n", c.event("output")["output"])
+	output := c.event("output")
+	assert.Equal(t, "stderr", output["category"])
+	assert.Equal(t, "main.blu: runtime error: variable already defined: a [E0012]\n", output["output"])
+	assert.Equal(t, float64(1), c.event("exited")["exitCode"])
+	c.request("disconnect", nil)
+	assert.NoError(t, <-c.done)
+
+	c = start(t)
+	assert.Contains(t, c.failing("launch", map[string]any{"program": "missing.blu"}), "missing.blu")
+	assert.Equal(t, "unsupported request: restartFrame", c.failing("restartFrame", nil))
+	c.in.Close()
+	assert.NoError(t, <-c.done)
+}
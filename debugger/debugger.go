@@ -0,0 +1,254 @@
+// Package debugger stops BluLang runs at breakpoints and after steps. It is a blulang.Hook,
+// front ends such as the blulang debug command and the debug adapter decide what to do when
+// a run stops.
+package debugger
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"blulang"
+)
+
+// Action tells a stopped run how to go on
+type Action int
+
+const (
+	// Continue runs until the next breakpoint
+	Continue Action = iota
+	// StepIn stops at the next line, inside the functions it calls
+	StepIn
+	// StepOver stops at the next line of the same function or of its callers
+	StepOver
+	// StepOut stops once the function returns, at the next line of its caller
+	StepOut
+	// Quit ends the run with ErrQuit
+	Quit
+)
+
+// ErrQuit is the cause of the error of a run ended with Quit
+var ErrQuit = errors.New("debugger: run ended by the user")
+
+// Reason is why a run stopped
+type Reason string
+
+const (
+	ReasonEntry      Reason = "entry"
+	ReasonBreakpoint Reason = "breakpoint"
+	ReasonStep       Reason = "step"
+	ReasonPause      Reason = "pause"
+)
+
+// Breakpoint stops a run before the statements of a line, when its condition holds if it has one
+type Breakpoint struct {
+	// File is the path of the module, empty for the source run without a file
+	File string
+	Line int
+	// Condition is an expression evaluated in the scope of the statement
+	Condition string
+}
+
+// Stop is a run waiting for an action
+type Stop struct {
+	Reason     Reason
+	Breakpoint *Breakpoint
+	// ConditionErr is the error of the condition of the breakpoint, which stops the run
+	ConditionErr error
+	Statement    blulang.Statement
+	// Stack holds the frames from the top level to the one running the statement
+	Stack    []blulang.Frame
+	live     []*blulang.Frame
+	debugger *Debugger
+}
+
+// Frame is the frame running the statement
+func (s *Stop) Frame() blulang.Frame {
+	return s.Stack[len(s.Stack)-1]
+}
+
+// Evaluate evaluates an expression in the scope of a frame of the stack, 0 being the last one
+func (s *Stop) Evaluate(expression string, frame int) (blulang.RuntimeVal, error) {
+	return s.debugger.evaluate(expression, s.Stack[len(s.Stack)-1-frame].Scope, s.live)
+}
+
+// Debugger follows a run to stop it at breakpoints and after steps
+type Debugger struct {
+	// Stopped is called on the goroutine of the run when it stops, the run goes on with the action returned
+	Stopped func(stop *Stop) Action
+
+	mu          sync.Mutex
+	breakpoints []*Breakpoint
+	pause       atomic.Bool
+	action      Action
+	started     bool
+	// depth is the number of frames when the last step started
+	depth int
+	// the statement the run was last seen on, a line is entered once however many statements
+	// it holds unless a loop or a call goes back to one of them
+	file       string
+	line       int
+	column     int
+	lineDepth  int
+	evaluating bool
+}
+
+// New creates a debugger, stopOnEntry stops the run on its first statement
+// so breakpoints can be set before it goes on
+func New(stopOnEntry bool, stopped func(stop *Stop) Action) *Debugger {
+	action := Continue
+	if stopOnEntry {
+		action = StepIn
+	}
+	return &Debugger{Stopped: stopped, action: action}
+}
+
+// SetBreakpoints replaces the breakpoints of a file
+func (d *Debugger) SetBreakpoints(file string, breakpoints []Breakpoint) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	kept := d.breakpoints[:0]
+	for _, breakpoint := range d.breakpoints {
+		if !sameFile(breakpoint.File, file) {
+			kept = append(kept, breakpoint)
+		}
+	}
+	for _, breakpoint := range breakpoints {
+		breakpoint.File = file
+		kept = append(kept, &breakpoint)
+	}
+	d.breakpoints = kept
+}
+
+// AddBreakpoint adds a breakpoint, replacing one on the same line
+func (d *Debugger) AddBreakpoint(breakpoint Breakpoint) {
+	d.RemoveBreakpoint(breakpoint.File, breakpoint.Line)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.breakpoints = append(d.breakpoints, &breakpoint)
+}
+
+// RemoveBreakpoint removes the breakpoint of a line, it reports whether there was one
+func (d *Debugger) RemoveBreakpoint(file string, line int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i, breakpoint := range d.breakpoints {
+		if sameFile(breakpoint.File, file) && breakpoint.Line == line {
+			d.breakpoints = append(d.breakpoints[:i], d.breakpoints[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Breakpoints are the breakpoints in the order they were set
+func (d *Debugger) Breakpoints() []Breakpoint {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	breakpoints := make([]Breakpoint, len(d.breakpoints))
+	for i, breakpoint := range d.breakpoints {
+		breakpoints[i] = *breakpoint
+	}
+	return breakpoints
+}
+
+// Pause stops the run at its next statement, it may be called from any goroutine
+func (d *Debugger) Pause() {
+	d.pause.Store(true)
+}
+
+func sameFile(a string, b string) bool {
+	return a == b || a != "" && b != "" && filepath.Clean(a) == filepath.Clean(b)
+}
+
+func (d *Debugger) breakpoint(file string, line int) *Breakpoint {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, breakpoint := range d.breakpoints {
+		if sameFile(breakpoint.File, file) && breakpoint.Line == line {
+			copied := *breakpoint
+			return &copied
+		}
+	}
+	return nil
+}
+
+// Statement stops the run when it enters a line with a breakpoint or ends a step
+func (d *Debugger) Statement(statement blulang.Statement, stack []*blulang.Frame) {
+	if d.evaluating {
+		return
+	}
+	frame := stack[len(stack)-1]
+	newLine := frame.File != d.file || frame.Pos.Line != d.line || len(stack) != d.lineDepth || frame.Pos.Column <= d.column
+	d.file, d.line, d.column, d.lineDepth = frame.File, frame.Pos.Line, frame.Pos.Column, len(stack)
+	if !newLine {
+		return
+	}
+
+	stop := &Stop{Statement: statement, live: stack, debugger: d}
+	switch {
+	case d.pause.Swap(false):
+		stop.Reason = ReasonPause
+	case d.action == StepIn && !d.started:
+		stop.Reason = ReasonEntry
+	case d.action == StepIn,
+		d.action == StepOver && len(stack) <= d.depth,
+		d.action == StepOut && len(stack) < d.depth:
+		stop.Reason = ReasonStep
+	}
+	if breakpoint := d.breakpoint(frame.File, frame.Pos.Line); breakpoint != nil && stop.Reason == "" {
+		hit := true
+		if breakpoint.Condition != "" {
+			value, err := d.evaluate(breakpoint.Condition, frame.Scope, stack)
+			hit = err != nil || value.Value() == true
+			stop.ConditionErr = err
+		}
+		if hit {
+			stop.Reason, stop.Breakpoint = ReasonBreakpoint, breakpoint
+		}
+	}
+	d.started = true
+	if stop.Reason == "" {
+		return
+	}
+
+	stop.Stack = make([]blulang.Frame, len(stack))
+	for i, frame := range stack {
+		stop.Stack[i] = *frame
+	}
+	d.action = d.Stopped(stop)
+	d.depth = len(stack)
+	if d.action == Quit {
+		panic(ErrQuit)
+	}
+}
+
+// Call is part of blulang.Hook, calls are followed through their statements
+func (d *Debugger) Call([]*blulang.Frame) {}
+
+// Return goes back to the statement of the caller, so the rest of its line isn't entered again
+func (d *Debugger) Return(stack []*blulang.Frame, _ blulang.RuntimeVal) {
+	if len(stack) > 1 && !d.evaluating {
+		caller := stack[len(stack)-2]
+		d.file, d.line, d.column, d.lineDepth = caller.File, caller.Pos.Line, caller.Pos.Column, len(stack)-1
+	}
+}
+
+// evaluate runs an expression in a child of a scope without stopping at the statements it
+// evaluates, the frame of the stopped statement is left as it was. Expressions that declare
+// or assign are refused, so that watching a variable doesn't change the program.
+func (d *Debugger) evaluate(expression string, scope *blulang.Scope, stack []*blulang.Frame) (blulang.RuntimeVal, error) {
+	parser := blulang.NewParser()
+	program, err := parser.ParseExpression(expression)
+	if err != nil {
+		return blulang.NullVal{}, err
+	}
+	d.evaluating = true
+	frame := *stack[len(stack)-1]
+	defer func() {
+		d.evaluating = false
+		*stack[len(stack)-1] = frame
+	}()
+	return blulang.Execute(program, blulang.NewScope(scope))
+}
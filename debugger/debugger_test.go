@@ -0,0 +1,152 @@
+package debugger_test
+
+import (
+	"blulang"
+	"blulang/debugger"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const source = `fn inc(n) {
+    let next = n + 1
+    next
+}
+let b = 0
+while b != 3 { b = inc(b) }
+let c = inc(b)
+c
+`
+
+// debug runs the source answering every stop with the next action, it returns
+// the stops as "reason line function" and the error of the run
+func debug(t *testing.T, d *debugger.Debugger, actions ...debugger.Action) ([]string, error) {
+	var stops []string
+	d.Stopped = func(stop *debugger.Stop) debugger.Action {
+		frame := stop.Frame()
+		stops = append(stops, fmt.Sprintf("%s %d %s", stop.Reason, frame.Pos.Line, frame.Function))
+		if len(actions) == 0 {
+			return debugger.Continue
+		}
+		action := actions[0]
+		actions = actions[1:]
+		return action
+	}
+	interpreter := blulang.New(blulang.Options{Hook: d})
+	_, err := interpreter.Run(context.Background(), source)
+	return stops, err
+}
+
+func TestStepping(t *testing.T) {
+	stops, err := debug(t, debugger.New(true, nil), debugger.StepOver, debugger.StepOver, debugger.StepIn, debugger.StepIn,
+		debugger.StepOut, debugger.StepOver)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"entry 1 ", "step 5 ", "step 6 ",
+		// into the call of the loop body
+		"step 2 inc", "step 3 inc",
+		// out to the loop, whose body is entered again on the same line
+		"step 6 ", "step 6 ",
+	}, stops)
+}
+
+func TestBreakpoints(t *testing.T) {
+	d := debugger.New(false, nil)
+	d.AddBreakpoint(debugger.Breakpoint{Line: 2, Condition: "n == 1"})
+	d.AddBreakpoint(debugger.Breakpoint{Line: 6})
+	stops, err := debug(t, d)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"breakpoint 6 ", "breakpoint 6 ", "breakpoint 2 inc", "breakpoint 6 "}, stops)
+
+	assert.True(t, d.RemoveBreakpoint("", 6))
+	assert.False(t, d.RemoveBreakpoint("", 6))
+	d.SetBreakpoints("", []debugger.Breakpoint{{Line: 7}, {Line: 2, Condition: "missing("}})
+	assert.Equal(t, []debugger.Breakpoint{{Line: 7}, {Line: 2, Condition: "missing("}}, d.Breakpoints())
+	var conditionErr error
+	d.Stopped = func(stop *debugger.Stop) debugger.Action {
+		conditionErr = errors.Join(conditionErr, stop.ConditionErr)
+		return debugger.Quit
+	}
+	interpreter := blulang.New(blulang.Options{Hook: d})
+	_, err = interpreter.Run(context.Background(), source)
+	assert.ErrorIs(t, err, debugger.ErrQuit)
+	assert.Error(t, conditionErr, "a condition failing to parse stops the run")
+}
+
+func variables(scope *blulang.Scope) string {
+	variables := scope.Variables()
+	var shown []string
+	for _, name := range slices.Sorted(maps.Keys(variables)) {
+		shown = append(shown, name+": "+blulang.Inspect(variables[name]))
+	}
+	return "{" + strings.Join(shown, ", ") + "}"
+}
+
+func TestInspection(t *testing.T) {
+	d := debugger.New(false, nil)
+	d.AddBreakpoint(debugger.Breakpoint{Line: 3, Condition: "n == 2"})
+	var inspected []string
+	d.Stopped = func(stop *debugger.Stop) debugger.Action {
+		for i := len(stop.Stack) - 1; i >= 0; i-- {
+			frame := stop.Stack[i]
+			for scope := frame.Scope; scope != nil; scope = scope.Parent() {
+				inspected = append(inspected, fmt.Sprintf("%q %v", frame.Function, variables(scope)))
+			}
+		}
+		value, err := stop.Evaluate("next * 10", 0)
+		assert.NoError(t, err)
+		inspected = append(inspected, blulang.Inspect(value))
+		value, err = stop.Evaluate("b", 1)
+		assert.NoError(t, err)
+		inspected = append(inspected, blulang.Inspect(value))
+		return debugger.Continue
+	}
+	interpreter := blulang.New(blulang.Options{Hook: d})
+	_, err := interpreter.Run(context.Background(), source)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		`"inc" {n: 2, next: 3}`,
+		`"inc" {b: 2, inc: fn inc(n)}`,
+		`"" {}`,
+		`"" {b: 2, inc: fn inc(n)}`,
+		"30", "2",
+	}, inspected)
+}
+
+func TestWatchesDontChangeState(t *testing.T) {
+	const notAnExpression = "expected an expression that doesn't declare, assign, import, export, return or break"
+	d := debugger.New(false, nil)
+	d.AddBreakpoint(debugger.Breakpoint{Line: 3, Condition: "n = 5"})
+	d.AddBreakpoint(debugger.Breakpoint{Line: 7})
+	d.Stopped = func(stop *debugger.Stop) debugger.Action {
+		if stop.Frame().Pos.Line == 3 {
+			assert.EqualError(t, stop.ConditionErr, "syntax error at 1:1: "+notAnExpression)
+			return debugger.Continue
+		}
+		watches := map[string]string{
+			"b = 10":          "1:1",
+			"let b = 10":      "1:1",
+			"fn b() { 10 }":   "1:1",
+			"if b { b = 10 }": "1:8",
+			"1 b = 10":        "1:3",
+			"break":           "1:1",
+		}
+		for watch, pos := range watches {
+			_, err := stop.Evaluate(watch, 0)
+			assert.EqualError(t, err, "syntax error at "+pos+": "+notAnExpression, watch)
+		}
+		value, err := stop.Evaluate("b + 1", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, "4", blulang.Inspect(value))
+		return debugger.Continue
+	}
+	interpreter := blulang.New(blulang.Options{Hook: d})
+	result, err := interpreter.Run(context.Background(), source)
+	assert.NoError(t, err)
+	assert.Equal(t, "4", blulang.Inspect(result))
+}
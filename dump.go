@@ -0,0 +1,95 @@
+package blulang
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DumpAST writes an indented tree of the statement and all of its children
+func DumpAST(w io.Writer, statement Statement) {
+	dumpNode(w, statement, "", 0)
+}
+
+func dumpNode(w io.Writer, statement Statement, label string, depth int) {
+	indent := strings.Repeat("  ", depth)
+	line := func(details string) {
+		fmt.Fprintf(w, "%s%s%s%s\n", indent, label, statement.Kind(), details)
+	}
+
+	switch node := statement.(type) {
+	case Program:
+		line("")
+		dumpChildren(w, "body", node.body, depth+1)
+	case BinaryExpression:
+		line(fmt.Sprintf(" operator=%q", node.operator))
+		dumpNode(w, node.left, "left: ", depth+1)
+		dumpNode(w, node.right, "right: ", depth+1)
+	case IntLiteral:
+		line(fmt.Sprintf(" value=%d", node.value))
+	case FloatLiteral:
+		line(fmt.Sprintf(" value=%v", node.value))
+	case StringLiteral:
+		line(fmt.Sprintf(" value=%q", node.value))
+	case BoolLiteral:
+		line(fmt.Sprintf(" value=%t", node.value))
+	case Identifier:
+		line(fmt.Sprintf(" name=%q", node.name))
+	case VarDeclareExpression:
+		line(fmt.Sprintf(" name=%q", node.name))
+		dumpNode(w, node.valueExpr, "value: ", depth+1)
+	case FuncDeclareExpression:
+		line(fmt.Sprintf(" name=%q arguments=%v", node.name, identifierNames(node.arguments)))
+		dumpChildren(w, "body", node.body, depth+1)
+	case FuncCallExpression:
+		line(fmt.Sprintf(" name=%q", node.name))
+		dumpChildren(w, "arguments", node.arguments, depth+1)
+	case ConditionalExpression:
+		line("")
+		dumpNode(w, node.condition, "condition: ", depth+1)
+		dumpChildren(w, "trueBody", node.trueBody, depth+1)
+		dumpChildren(w, "falseBody", node.falseBody, depth+1)
+	case WhileLoopExpression:
+		line("")
+		dumpNode(w, node.condition, "condition: ", depth+1)
+		dumpChildren(w, "body", node.body, depth+1)
+	case ArrayLiteral:
+		line("")
+		dumpChildren(w, "values", node.values, depth+1)
+	case ArrayAccessExpr:
+		line(fmt.Sprintf(" name=%q", node.name))
+		dumpNode(w, node.index, "index: ", depth+1)
+	case ObjectDeclareExpr:
+		line("")
+		for i, name := range node.names {
+			dumpNode(w, node.values[i], name+": ", depth+1)
+		}
+	case ObjectAccessExpr:
+		line(fmt.Sprintf(" owner=%q", node.owner.name))
+		dumpNode(w, node.property, "property: ", depth+1)
+	case ImportExpr:
+		line(fmt.Sprintf(" path=%q alias=%q", node.path, node.alias))
+	case ExportExpr:
+		line("")
+		dumpNode(w, node.declaration, "declaration: ", depth+1)
+	case TestBlock:
+		line(fmt.Sprintf(" name=%q", node.name))
+		dumpChildren(w, "body", node.body, depth+1)
+	default:
+		line("")
+	}
+}
+
+func dumpChildren[T Statement](w io.Writer, name string, statements []T, depth int) {
+	for i, child := range statements {
+		dumpNode(w, child, fmt.Sprintf("%s[%d]: ", name, i), depth)
+	}
+}
+
+func identifierNames(identifiers []Identifier) []string {
+	var names []string
+	for _, identifier := range identifiers {
+		names = append(names, identifier.name)
+	}
+	return names
+}
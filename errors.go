@@ -0,0 +1,111 @@
+package blulang
+
+import (
+	"fmt"
+)
+
+// SyntaxError is raised by the parser when the source can't be turned into an AST
+type SyntaxError struct {
+	Pos     Position
+	Message string
+	// Code identifies the message in the catalogue, see MessageCode
+	Code MessageCode
+	// args are kept so the message can be translated, see LocalizeError
+	args   []any
+	locale *Locale
+}
+
+func (e SyntaxError) Error() string {
+	return fmt.Sprintf(e.locale.message(msgSyntaxError), e.Pos, e.Message)
+}
+
+func NewSyntaxError(pos Position, code MessageCode, args ...any) SyntaxError {
+	return SyntaxError{Pos: pos, Message: Localize(nil, code, args...), Code: code, args: args}
+}
+
+// RuntimeError is raised while evaluating a program
+type RuntimeError struct {
+	Message string
+	// Code identifies the message in the catalogue, it is empty for messages of the host
+	Code MessageCode
+	// Cause is the Go error a native function failed with, if any
+	Cause  error
+	args   []any
+	locale *Locale
+}
+
+func (e RuntimeError) Error() string {
+	return fmt.Sprintf(e.locale.message(msgRuntimeError), e.Message)
+}
+
+func (e RuntimeError) Unwrap() error {
+	return e.Cause
+}
+
+// NewRuntimeError creates an error with a message of the host, it isn't translated
+func NewRuntimeError(format string, args ...any) RuntimeError {
+	return RuntimeError{Message: fmt.Sprintf(format, args...)}
+}
+
+// codedError creates an error with a message of the catalogue
+func codedError(code MessageCode, args ...any) RuntimeError {
+	return RuntimeError{Message: Localize(nil, code, args...), Code: code, args: args}
+}
+
+// causedError creates an error with a message of the catalogue caused by a Go error
+func causedError(cause error, code MessageCode, args ...any) RuntimeError {
+	err := codedError(code, args...)
+	err.Cause = cause
+	return err
+}
+
+// WrapRuntimeError raises a Go error returned by native code as a runtime error
+func WrapRuntimeError(err error) RuntimeError {
+	return causedError(err, CodeHostError, err)
+}
+
+// LocalizeError translates the message of a syntax or runtime error into the language of the locale,
+// errors of imported modules are translated with it. Other errors are returned as they are.
+func LocalizeError(err error, locale *Locale) error {
+	switch e := err.(type) {
+	case SyntaxError:
+		e.locale = locale
+		if e.Code != "" {
+			e.Message = Localize(locale, e.Code, e.args...)
+		}
+		return e
+	case RuntimeError:
+		e.locale = locale
+		if e.Code != "" {
+			args := make([]any, len(e.args))
+			for i, arg := range e.args {
+				if argErr, ok := arg.(error); ok {
+					arg = LocalizeError(argErr, locale)
+				}
+				args[i] = arg
+			}
+			e.Message = Localize(locale, e.Code, args...)
+		}
+		if e.Cause != nil {
+			e.Cause = LocalizeError(e.Cause, locale)
+		}
+		return e
+	}
+	return err
+}
+
+// recoverError turns a panic raised by the parser or the interpreter into an error,
+// any other panic such as a Go runtime error is reported as a RuntimeError
+func recoverError(r any) error {
+	switch e := r.(type) {
+	case SyntaxError:
+		return e
+	case RuntimeError:
+		return e
+	case ExitError:
+		return e
+	case error:
+		return WrapRuntimeError(e)
+	}
+	return codedError(CodeHostError, r)
+}
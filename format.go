@@ -0,0 +1,344 @@
+package blulang
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Format prints a source in the canonical layout: one statement per line, blocks indented with
+// four spaces, single spaces around operators and after commas, opening braces on the line of
+// the construct they belong to and '} else {' on one line. Comments, the shebang line and single
+// blank lines between statements are kept, keywords stay in the language they are written in.
+func Format(source string) (formatted string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recoverError(r)
+		}
+	}()
+	parser := NewParser()
+	program := parser.CreateAST(source)
+	runeArr := []rune(source)
+	p := &printer{source: runeArr, tokens: program.tokens, positionOf: positionFinder(runeArr)}
+	if strings.HasPrefix(source, "#!") {
+		shebang, _, _ := strings.Cut(source, "\n")
+		p.out.WriteString(strings.TrimRightFunc(shebang, unicode.IsSpace))
+		p.line, p.broken = 1, true
+	}
+	p.statements(program.body)
+	p.comments(program.comments)
+	if p.out.Len() > 0 {
+		p.out.WriteString("\n")
+	}
+	return p.out.String(), nil
+}
+
+// printer writes the statements of a program back as source. It walks the tokens of the
+// program along with the statements, so keywords, literals and comments are printed as written.
+type printer struct {
+	source     []rune
+	tokens     []Token
+	positionOf func(offset int) Position
+	out        strings.Builder
+	// next is the index of the next token to print, the comments of the tokens
+	// before commented are already printed
+	next      int
+	commented int
+	depth     int
+	// line is the source line the last printed text ends on
+	line int
+	// broken starts the next text on a new line, spaced puts a space before it
+	broken bool
+	spaced bool
+	// tight drops the blank line before the next text, after an opening brace and before a closing one
+	tight bool
+}
+
+func (p *printer) text(text string, line int, endLine int) {
+	if p.broken {
+		if p.out.Len() > 0 {
+			p.out.WriteString("\n")
+			if line > p.line+1 && !p.tight {
+				p.out.WriteString("\n")
+			}
+		}
+		p.out.WriteString(strings.Repeat("    ", p.depth))
+	} else if p.spaced {
+		p.out.WriteString(" ")
+	}
+	p.out.WriteString(text)
+	p.broken, p.spaced, p.tight = false, false, false
+	p.line = endLine
+}
+
+// write prints punctuation the source may have left out, such as an optional comma
+func (p *printer) write(text string) {
+	p.text(text, p.line, p.line)
+}
+
+func (p *printer) space() {
+	p.spaced = true
+}
+
+func (p *printer) newline() {
+	p.broken = true
+}
+
+func (p *printer) comments(comments []Comment) {
+	for _, comment := range comments {
+		if comment.Pos.Line == p.line && p.out.Len() > 0 {
+			// a comment following code stays at the end of its line
+			p.out.WriteString(" " + comment.Text)
+		} else {
+			p.broken = true
+			p.text(comment.Text, comment.Pos.Line, comment.Pos.Line)
+		}
+		p.broken = true
+	}
+}
+
+// leading prints the comments written before the next token, once
+func (p *printer) leading() {
+	if p.commented <= p.next && p.next < len(p.tokens) {
+		p.comments(p.tokens[p.next].comments)
+		p.commented = p.next + 1
+	}
+}
+
+func (p *printer) peek() TokenType {
+	if p.next < len(p.tokens) {
+		return p.tokens[p.next].name
+	}
+	return ""
+}
+
+// skip moves past the next token, which must be of the given type, printing only its comments
+func (p *printer) skip(name TokenType) Token {
+	if p.peek() != name {
+		panic(fmt.Errorf("format: expected %s but found %q", name, p.peek()))
+	}
+	p.leading()
+	token := p.tokens[p.next]
+	p.next++
+	return token
+}
+
+// token prints the next token, which must be of the given type
+func (p *printer) token(name TokenType) Token {
+	token := p.skip(name)
+	if name == TkCloseCurly {
+		p.tight = true
+	}
+	text := token.value
+	if name == TkString || name == TkNumber {
+		// literals are printed as written to keep their escapes and digits
+		text = string(p.source[token.start:token.end])
+	}
+	p.text(text, token.pos.Line, p.positionOf(token.end).Line)
+	return token
+}
+
+func (p *printer) statements(body []Statement) {
+	for _, statement := range body {
+		p.newline()
+		p.statement(statement)
+	}
+}
+
+func (p *printer) block(body []Statement) {
+	p.space()
+	p.token(TkOpenCurly)
+	p.depth++
+	p.tight = true
+	p.statements(body)
+	empty := len(body) == 0 && len(p.tokens[p.next].comments) == 0
+	p.leading()
+	p.depth--
+	if !empty {
+		p.newline()
+	}
+	p.tight = true
+	p.token(TkCloseCurly)
+}
+
+// list prints elements between brackets, on one line unless the first element is written
+// on a line of its own or a comment is written between them, then every element gets its own
+// line and a trailing comma
+func (p *printer) list(open TokenType, close TokenType, count int, padded bool, element func(i int)) {
+	opening := p.token(open)
+	first := p.tokens[p.next]
+	multiline := count > 0 && (first.pos.Line > opening.pos.Line || p.commentedList())
+	if multiline {
+		p.depth++
+	} else if padded && count > 0 {
+		p.space()
+	}
+	for i := 0; i < count; i++ {
+		if multiline {
+			p.newline()
+		}
+		element(i)
+		if p.peek() == TkComma {
+			p.skip(TkComma)
+		}
+		if multiline || i < count-1 {
+			p.write(",")
+			p.space()
+		}
+	}
+	if multiline {
+		p.leading()
+		p.depth--
+		p.newline()
+	} else if padded && count > 0 {
+		p.space()
+	}
+	p.token(close)
+}
+
+// commentedList tells whether comments are written between the brackets of the list starting
+// at the next token, outside of the brackets nested in it
+func (p *printer) commentedList() bool {
+	level := 0
+	for _, token := range p.tokens[p.next:] {
+		if level == 0 && len(token.comments) > 0 {
+			return true
+		}
+		switch token.name {
+		case TkOpenRound, TKOpenSquare, TkOpenCurly:
+			level++
+		case TkCloseRound, TkCloseSquare, TkCloseCurly:
+			if level == 0 {
+				return false
+			}
+			level--
+		}
+	}
+	return false
+}
+
+// startToken is the index of the first token of a parsed statement
+func startToken(statement Statement) int {
+	return statement.(interface{ startToken() int }).startToken()
+}
+
+func (p *printer) statement(statement Statement) {
+	// the parentheses grouping a statement come before its first token
+	groups := 0
+	for ; p.next < startToken(statement); groups++ {
+		p.token(TkOpenRound)
+	}
+
+	switch statement := statement.(type) {
+	case VarDeclareExpression:
+		p.token(TkDeclareVar)
+		p.space()
+		p.token(TkIdentifier)
+		p.space()
+		p.token(TkBinaryOperator)
+		p.space()
+		p.statement(statement.valueExpr)
+	case FuncDeclareExpression:
+		p.token(TkDeclareFunc)
+		p.space()
+		if statement.name != "" {
+			p.token(TkIdentifier)
+		}
+		p.list(TkOpenRound, TkCloseRound, len(statement.arguments), false, func(int) {
+			p.token(TkIdentifier)
+		})
+		p.block(statement.body)
+	case FuncCallExpression:
+		p.token(TkIdentifier)
+		p.list(TkOpenRound, TkCloseRound, len(statement.arguments), false, func(i int) {
+			p.statement(statement.arguments[i])
+		})
+	case ConditionalExpression:
+		p.token(TkIf)
+		p.space()
+		p.statement(statement.condition)
+		p.block(statement.trueBody)
+		if p.peek() == TkElse {
+			p.space()
+			p.token(TkElse)
+			if p.peek() == TkIf {
+				p.space()
+				p.statement(statement.falseBody[0])
+			} else {
+				p.block(statement.falseBody)
+			}
+		}
+	case WhileLoopExpression:
+		p.token(TkWhile)
+		p.space()
+		p.statement(statement.condition)
+		p.block(statement.body)
+	case BinaryExpression:
+		if p.peek() == TkNot {
+			// '!a' is parsed as 'a != true'
+			p.token(TkNot)
+			p.statement(statement.left)
+			break
+		}
+		p.statement(statement.left)
+		p.space()
+		p.token(TkBinaryOperator)
+		p.space()
+		p.statement(statement.right)
+	case ArrayLiteral:
+		p.list(TKOpenSquare, TkCloseSquare, len(statement.values), false, func(i int) {
+			p.statement(statement.values[i])
+		})
+	case ArrayAccessExpr:
+		p.token(TkIdentifier)
+		p.token(TKOpenSquare)
+		p.statement(statement.index)
+		p.token(TkCloseSquare)
+	case ObjectDeclareExpr:
+		p.list(TkOpenCurly, TkCloseCurly, len(statement.names), true, func(i int) {
+			p.token(TkIdentifier)
+			p.token(TKColon)
+			p.space()
+			p.statement(statement.values[i])
+		})
+	case ObjectAccessExpr:
+		p.token(TkIdentifier)
+		p.token(TkDot)
+		p.statement(statement.property)
+	case ImportExpr:
+		p.token(TkImport)
+		p.space()
+		p.token(TkString)
+		if p.peek() == TkAs {
+			p.space()
+			p.token(TkAs)
+			p.space()
+			p.token(TkIdentifier)
+		}
+	case ExportExpr:
+		p.token(TkExport)
+		p.space()
+		p.statement(statement.declaration)
+	case TestBlock:
+		p.token(TkTest)
+		p.space()
+		p.token(TkString)
+		p.block(statement.body)
+	case IntLiteral, FloatLiteral:
+		p.token(TkNumber)
+	case StringLiteral:
+		p.token(TkString)
+	case Identifier:
+		p.token(TkIdentifier)
+	case BreakStatement:
+		p.token(TkBreak)
+	case ReturnStatement:
+		p.token(TkReturn)
+	default:
+		panic(codedError(CodeInvalidStatement, statement))
+	}
+
+	for ; groups > 0; groups-- {
+		p.token(TkCloseRound)
+	}
+}
@@ -0,0 +1,125 @@
+package blulang_test
+
+import (
+	"blulang"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	sources := map[string]string{
+		"let a=1+2*3":                                 "let a = 1 + 2 * 3\n",
+		"let a = 1 let b = 2":                         "let a = 1\nlet b = 2\n",
+		"fn add(a,b){a+b}":                            "fn add(a, b) {\n    a + b\n}\n",
+		"let f = fn(a) {}":                            "let f = fn (a) {}\n",
+		"print(a,b c)":                                "print(a, b, c)\n",
+		"let x = !(a==1) && (b+1)*2 > 3":              "let x = !(a == 1) && (b + 1) * 2 > 3\n",
+		"if a {1}\nelse if b {2} else {3}":            "if a {\n    1\n} else if b {\n    2\n} else {\n    3\n}\n",
+		"while i<3 {i = i+1 if i == 2 {i return}}":    "while i < 3 {\n    i = i + 1\n    if i == 2 {\n        i\n        return\n    }\n}\n",
+		"let o = {b:1,a:[1,2,],c:o.d[0]}":             "let o = { b: 1, a: [1, 2], c: o.d[0] }\n",
+		"let a = [\n1,\n2]":                           "let a = [\n    1,\n    2,\n]\n",
+		"nhập   khẩu \"math\" là m\nm.pow(2,0.50)":    "nhập khẩu \"math\" là m\nm.pow(2, 0.50)\n",
+		"export  fn f ( ) { \"a\\\"b\" }":             "export fn f() {\n    \"a\\\"b\"\n}\n",
+		"test   \"adds\"{assertEqual(1+1,2)}":         "test \"adds\" {\n    assertEqual(1 + 1, 2)\n}\n",
+		"#!/usr/bin/env blulang\n\n\nlet a = 1\n\n\n": "#!/usr/bin/env blulang\n\nlet a = 1\n",
+	}
+	for source, expected := range sources {
+		formatted, err := blulang.Format(source)
+		assert.NoErrorf(t, err, source)
+		assert.Equalf(t, expected, formatted, source)
+	}
+}
+
+func TestFormatComments(t *testing.T) {
+	source := `; lang: en
+; adds numbers
+
+
+fn add(a, b) { ; the body
+  ; before
+  a+b ; sum
+
+  ; end of the body
+
+}
+let a = [ ; values
+  1, ; one
+  2
+]
+; the end
+`
+	expected := `; lang: en
+; adds numbers
+
+fn add(a, b) { ; the body
+    ; before
+    a + b ; sum
+
+    ; end of the body
+}
+let a = [ ; values
+    1, ; one
+    2,
+]
+; the end
+`
+	formatted, err := blulang.Format(source)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, formatted)
+}
+
+func TestFormatCommentsInBrackets(t *testing.T) {
+	source := `let a = [1, ; one
+2]
+print(a, ; x
+3)
+let o = { a: 1, ; c
+b: 2 }
+map(a, fn (x) { ; doubles
+x * 2 })
+`
+	expected := `let a = [
+    1, ; one
+    2,
+]
+print(
+    a, ; x
+    3,
+)
+let o = {
+    a: 1, ; c
+    b: 2,
+}
+map(a, fn (x) { ; doubles
+    x * 2
+})
+`
+	formatted, err := blulang.Format(source)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, formatted)
+
+	formatted, err = blulang.Format(expected)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, formatted)
+}
+
+func TestFormatSamples(t *testing.T) {
+	files, err := filepath.Glob("sample/*.blu")
+	assert.NoError(t, err)
+	for _, file := range files {
+		source, err := os.ReadFile(file)
+		assert.NoError(t, err)
+		formatted, err := blulang.Format(string(source))
+		assert.NoErrorf(t, err, file)
+		again, err := blulang.Format(formatted)
+		assert.NoErrorf(t, err, file)
+		assert.Equalf(t, formatted, again, "formatting %s twice changed it", file)
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	_, err := blulang.Format("let a = (1")
+	assert.EqualError(t, err, "syntax error at 1:10: unexpected end of input, expected ')'")
+}
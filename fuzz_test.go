@@ -0,0 +1,142 @@
+package blulang_test
+
+import (
+	"blulang"
+	"blulang/trace"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The fuzz targets check properties of the lexer, the parser and the evaluator over any source:
+//
+//	go test -fuzz FuzzParse -fuzztime 1m
+//
+// Their corpus starts from the samples, the conformance cases and fuzzCrashers, the inputs
+// the fuzzers found crashing once, minimised, which every go test runs again.
+
+var fuzzCrashers = []string{
+	// an unterminated string ran to the end of the source
+	`a "0`,
+	// a parameter list ending with the source
+	`fn (`,
+	// indexing a value that isn't an array, with an index that isn't an integer or out of range
+	`let i = 0 i[0]`,
+	`let a = [1] a["0"] a[1] = 2`,
+	// comparing arrays compared Go slices
+	`[1] == [1]`,
+	// an array assigned into itself was shown forever
+	`let a = [1] a[0] = a a`,
+}
+
+// addSeeds adds the samples, the conformance cases and the crashers to the corpus of a fuzz target
+func addSeeds(f *testing.F) {
+	for _, pattern := range []string{"sample/*.blu", "testdata/conformance/*.blu", "testdata/conformance/modules/*.blu"} {
+		paths, err := filepath.Glob(pattern)
+		require.NoError(f, err)
+		for _, path := range paths {
+			source, err := os.ReadFile(path)
+			require.NoError(f, err)
+			f.Add(string(source))
+		}
+	}
+	for _, crasher := range fuzzCrashers {
+		f.Add(crasher)
+	}
+}
+
+// crashed tells whether an error comes from a Go runtime panic, such as an index out of range,
+// rather than from the checks of the lexer, the parser or the evaluator
+func crashed(err error) bool {
+	var goError runtime.Error
+	return errors.As(err, &goError)
+}
+
+func FuzzTokenize(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, source string) {
+		defer func() {
+			// the lexer panics with syntax errors that Parse reports
+			if r := recover(); r != nil {
+				_, isSyntaxError := r.(blulang.SyntaxError)
+				assert.Truef(t, isSyntaxError, "tokenizing %q panicked with %v", source, r)
+			}
+		}()
+		blulang.Tokenize(source)
+	})
+}
+
+func FuzzParse(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, source string) {
+		parser := blulang.NewParser()
+		_, err := parser.Parse(source)
+		require.Falsef(t, crashed(err), "parsing %q crashed: %v", source, err)
+		tolerant := blulang.NewParser()
+		_, errs := tolerant.ParseTolerant(source)
+		if err != nil {
+			assert.NotEmptyf(t, errs, "%q has a syntax error but parses tolerantly", source)
+			return
+		}
+		assert.Emptyf(t, errs, "%q parses but not tolerantly", source)
+
+		formatted, err := blulang.Format(source)
+		require.NoErrorf(t, err, "formatting %q", source)
+		again, err := blulang.Format(formatted)
+		require.NoErrorf(t, err, "formatting %q formatted as %q", source, formatted)
+		assert.Equalf(t, formatted, again, "formatting %q isn't stable", source)
+	})
+}
+
+// fuzzRun is what a run gave
+type fuzzRun struct {
+	result string
+	err    string
+	output string
+}
+
+// runFuzzed runs a source with the given hook, bounded so that any program ends quickly
+func runFuzzed(t *testing.T, source string, hook blulang.Hook) fuzzRun {
+	var output strings.Builder
+	interpreter := blulang.New(blulang.Options{
+		Stdin:        strings.NewReader(""),
+		Stdout:       &output,
+		Stderr:       &output,
+		Capabilities: blulang.CapIO | blulang.CapInput,
+		Limits:       blulang.Limits{MaxSteps: 20000, MaxCallDepth: 64, MaxCollectionSize: 1000, Timeout: 10 * time.Second},
+		Hook:         hook,
+	})
+	result, err := interpreter.Run(context.Background(), source)
+	require.Falsef(t, crashed(err), "running %q crashed: %v", source, err)
+	run := fuzzRun{result: blulang.Inspect(result), output: output.String()}
+	if err != nil {
+		run.err = err.Error()
+	}
+	return run
+}
+
+func FuzzEval(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, source string) {
+		parser := blulang.NewParser()
+		if _, err := parser.Parse(source); err != nil {
+			return
+		}
+		// the evaluator takes another path when a hook follows the expressions
+		plain := runFuzzed(t, source, nil)
+		traced := runFuzzed(t, source, trace.New(io.Discard, trace.Text, "", source))
+		assert.Equalf(t, plain, traced, "%q runs differently when traced", source)
+
+		formatted, err := blulang.Format(source)
+		require.NoError(t, err)
+		assert.Equalf(t, plain, runFuzzed(t, formatted, nil), "%q runs differently once formatted as %q", source, formatted)
+	})
+}
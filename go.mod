@@ -2,7 +2,10 @@ module blulang
 
 go 1.23.0
 
-require github.com/stretchr/testify v1.9.0
+require (
+	github.com/stretchr/testify v1.9.0
+	golang.org/x/text v0.28.0
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
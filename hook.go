@@ -0,0 +1,210 @@
+package blulang
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Hook follows a run for debuggers and tracers, it is given to an interpreter with Options.Hook.
+// Eval calls it before each statement of the program, of a function body and of the bodies
+// of if and while, and around the calls of user functions. The hook runs on the goroutine of
+// the run, which waits for it to return.
+type Hook interface {
+	// Statement is called before a statement is evaluated, the last frame of the stack runs it
+	Statement(statement Statement, stack []*Frame)
+	// Call is called when a user function starts, before its parameters are declared,
+	// its frame is the last one of the stack
+	Call(stack []*Frame)
+	// Return is called when a user function ends with its value, its frame is still the last one.
+	// A function stopped by an error doesn't return.
+	Return(stack []*Frame, value RuntimeVal)
+}
+
+// ExpressionHook is a Hook that also follows the evaluation of every expression
+// and the variables it changes, for tracers
+type ExpressionHook interface {
+	Hook
+	// Evaluate is called before an expression is evaluated
+	Evaluate(expression Statement)
+	// Evaluated is called once an expression is evaluated with its value, an expression
+	// stopped by an error isn't evaluated
+	Evaluated(expression Statement, value RuntimeVal)
+	// Variable is called when a variable is declared, with a parameter or an import too, or assigned
+	Variable(name string, value RuntimeVal, declared bool)
+}
+
+// BranchHook is a Hook that is also told which body of each if is evaluated, for coverage
+type BranchHook interface {
+	Hook
+	// Branch is called once the condition of an if is evaluated, before the body it chose,
+	// the last frame of the stack runs it. An if without else has an empty false body.
+	Branch(conditional ConditionalExpression, taken bool, stack []*Frame)
+}
+
+// AllocationHook is a Hook that is also told about the arrays and objects a run creates, for profilers
+type AllocationHook interface {
+	Hook
+	// Allocate is called with an array or an object created by a literal, by '+' or by a builtin,
+	// the last frame of the stack creates it
+	Allocate(value RuntimeVal, stack []*Frame)
+}
+
+// Frame is the top level of a run or a call of a user function in progress
+type Frame struct {
+	// Function is the name of the called function, empty for the top level
+	// and "fn" for anonymous functions
+	Function string
+	// File is the path of the module running the statement, empty when the source isn't a file
+	File string
+	// Pos is the position of the statement being evaluated, the one calling the
+	// next frame for the frames below the last one
+	Pos Position
+	// Scope is the scope of the statement being evaluated, its parents are the enclosing
+	// blocks, then the scope the function was declared in up to the global scope
+	Scope *Scope
+}
+
+// statement calls the hook before a statement of a body
+func (e *execution) statement(statement Statement, scope *Scope) {
+	if e == nil || e.hook == nil {
+		return
+	}
+	if len(e.frames) == 0 {
+		e.frames = append(e.frames, &Frame{})
+	}
+	frame := e.frames[len(e.frames)-1]
+	frame.Pos, frame.Scope = statement.Pos(), scope
+	if scope.module != nil {
+		frame.File = scope.module.path
+	}
+	e.hook.Statement(statement, e.frames)
+}
+
+// eval evaluates a statement between the calls of an expression hook,
+// the programs of the run and of the imported modules aren't expressions
+func (e *execution) eval(statement Statement, scope *Scope) RuntimeVal {
+	if statement.Kind() == StmtProgram {
+		return evalStatement(statement, scope)
+	}
+	e.expressions.Evaluate(statement)
+	value := evalStatement(statement, scope)
+	e.expressions.Evaluated(statement, value)
+	return value
+}
+
+// variable tells an expression hook a variable was declared or assigned
+func (e *execution) variable(name string, value RuntimeVal, declared bool) {
+	if e != nil && e.expressions != nil {
+		e.expressions.Variable(name, value, declared)
+	}
+}
+
+// branch tells a branch hook which body of an if is evaluated
+func (e *execution) branch(conditional ConditionalExpression, taken bool) {
+	if e != nil && e.branches != nil {
+		e.branches.Branch(conditional, taken, e.frames)
+	}
+}
+
+// allocated tells an allocation hook about a new array or object and gives it back
+func (e *execution) allocated(value RuntimeVal) RuntimeVal {
+	if e != nil && e.allocations != nil {
+		e.allocations.Allocate(value, e.frames)
+	}
+	return value
+}
+
+// call pushes the frame of a user function starting in the given scope
+func (e *execution) call(function FunctionVal, scope *Scope) {
+	if e == nil || e.hook == nil {
+		return
+	}
+	name := function.name
+	if name == "" {
+		name = "fn"
+	}
+	frame := &Frame{Function: name, Scope: scope}
+	if scope.module != nil {
+		frame.File = scope.module.path
+	}
+	e.frames = append(e.frames, frame)
+	e.hook.Call(e.frames)
+}
+
+// returned tells the hook the last called function returned a value
+func (e *execution) returned(value RuntimeVal) {
+	if e != nil && e.hook != nil {
+		e.hook.Return(e.frames, value)
+	}
+}
+
+// leave pops the frame of a user function, when it returns or fails
+func (e *execution) leave() {
+	if e != nil && e.hook != nil {
+		e.frames = e.frames[:len(e.frames)-1]
+	}
+}
+
+// Parent is the scope enclosing this one, nil for a global scope, the scope of the builtins
+// enclosing it being left out
+func (s *Scope) Parent() *Scope {
+	if s.module != nil && s.module.scope == s {
+		return nil
+	}
+	return s.parent
+}
+
+// Variables are the variables declared in the scope
+func (s *Scope) Variables() map[string]RuntimeVal {
+	variables := make(map[string]RuntimeVal)
+	for name, value := range s.variables {
+		variables[name] = value
+	}
+	return variables
+}
+
+// Inspect shows a value the way it is written in source, strings quoted and
+// the properties of objects sorted by name
+func Inspect(value RuntimeVal) string {
+	switch value := value.(type) {
+	case StringVal:
+		return fmt.Sprintf("%q", value.value)
+	case NullVal:
+		return "null"
+	case ArrayVal:
+		elements := make([]string, len(value.values))
+		for i, element := range value.values {
+			elements[i] = Inspect(element)
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case ObjectVal:
+		names := make([]string, 0, len(value.properties.variables))
+		for name := range value.properties.variables {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		properties := make([]string, len(names))
+		for i, name := range names {
+			properties[i] = name + ": " + Inspect(value.properties.variables[name])
+		}
+		if len(properties) == 0 {
+			return "{}"
+		}
+		return "{ " + strings.Join(properties, ", ") + " }"
+	case FunctionVal:
+		parameters := make([]string, len(value.arguments))
+		for i, parameter := range value.arguments {
+			parameters[i] = parameter.name
+		}
+		if value.name == "" {
+			return fmt.Sprintf("fn (%s)", strings.Join(parameters, ", "))
+		}
+		return fmt.Sprintf("fn %s(%s)", value.name, strings.Join(parameters, ", "))
+	case NativeFuncVal:
+		return "builtin function"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprint(value.Value())
+}
@@ -0,0 +1,38 @@
+// Package jsonrpc frames the JSON messages of the language server and of the debug adapter,
+// which both send each message after a Content-Length header as in
+// https://microsoft.github.io/language-server-protocol/specification#baseProtocol
+package jsonrpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+)
+
+// ReadMessage reads the content of the next message, which follows a Content-Length header
+func ReadMessage(in *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(in).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length: %q", header.Get("Content-Length"))
+	}
+	content := make([]byte, length)
+	_, err = io.ReadFull(in, content)
+	return content, err
+}
+
+// WriteMessage writes a message as JSON after its Content-Length header
+func WriteMessage(out io.Writer, message any) error {
+	content, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "Content-Length: %d\r\n\r\n%s", len(content), content)
+	return err
+}
@@ -0,0 +1,31 @@
+package jsonrpc_test
+
+import (
+	"blulang/internal/jsonrpc"
+	"bufio"
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMessages(t *testing.T) {
+	var out bytes.Buffer
+	assert.NoError(t, jsonrpc.WriteMessage(&out, map[string]string{"text": "xin chào"}))
+	assert.NoError(t, jsonrpc.WriteMessage(&out, []int{1}))
+	assert.Equal(t, "Content-Length: 20\r\n\r\n{\"text\":\"xin chào\"}Content-Length: 3\r\n\r\n[1]", out.String())
+
+	in := bufio.NewReader(&out)
+	content, err := jsonrpc.ReadMessage(in)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"text":"xin chào"}`, string(content))
+	content, err = jsonrpc.ReadMessage(in)
+	assert.NoError(t, err)
+	assert.Equal(t, "[1]", string(content))
+	_, err = jsonrpc.ReadMessage(in)
+	assert.ErrorIs(t, err, io.EOF)
+
+	_, err = jsonrpc.ReadMessage(bufio.NewReader(strings.NewReader("Content-Type: json\r\n\r\n{}")))
+	assert.EqualError(t, err, `invalid Content-Length: ""`)
+}
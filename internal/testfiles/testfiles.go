@@ -0,0 +1,19 @@
+// Package testfiles writes the scripts used by the tests of several packages
+package testfiles
+
+import (
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// WriteFiles writes each source to its path relative to dir, creating the directories on the way
+func WriteFiles(t testing.TB, dir string, files map[string]string) {
+	t.Helper()
+	for name, source := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(source), 0o644))
+	}
+}
@@ -1,10 +1,18 @@
-package main
+package blulang
 
 import (
-	"log"
+	"context"
 )
 
 func Eval(statement Statement, scope *Scope) RuntimeVal {
+	scope.exec.step()
+	if scope.exec != nil && scope.exec.expressions != nil {
+		return scope.exec.eval(statement, scope)
+	}
+	return evalStatement(statement, scope)
+}
+
+func evalStatement(statement Statement, scope *Scope) RuntimeVal {
 	switch statement.Kind() {
 	case StmtProgram:
 		return EvalProgram(statement.(Program), scope)
@@ -12,6 +20,8 @@ func Eval(statement Statement, scope *Scope) RuntimeVal {
 		return EvalBinaryExpression(statement.(BinaryExpression), scope)
 	case StmtIntLiteral:
 		return NewIntVal(statement.(IntLiteral).value)
+	case StmtFloatLiteral:
+		return NewFloatVal(statement.(FloatLiteral).value)
 	case StmtStringLiteral:
 		return NewStringVal(statement.(StringLiteral).value)
 	case StmtArrayLiteral:
@@ -34,44 +44,126 @@ func Eval(statement Statement, scope *Scope) RuntimeVal {
 		return EvalObjectDeclareExpression(statement.(ObjectDeclareExpr), scope)
 	case StmtObjAccessExpr:
 		return EvalObjectAccessExpression(statement.(ObjectAccessExpr), scope)
+	case StmtImportExpr:
+		return EvalImportExpression(statement.(ImportExpr), scope)
+	case StmtExportExpr:
+		return EvalExportExpression(statement.(ExportExpr), scope)
+	case StmtTestBlock:
+		return EvalTestBlock(statement.(TestBlock), scope)
 	case StmtNullLiteral:
 		return NullVal{}
+	case StmtBoolLiteral:
+		return NewBoolVal(statement.(BoolLiteral).value)
+	}
+	panic(codedError(CodeInvalidStatement, statement))
+}
+
+// Execute evaluates a program, reporting failures as errors instead of panicking
+func Execute(program Program, scope *Scope) (result RuntimeVal, err error) {
+	if scope.exec == nil {
+		return ExecuteContext(context.Background(), program, scope, Limits{})
 	}
-	log.Panicf("Invalid statement: %v", statement)
-	return nil
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = NullVal{}, recoverError(r)
+		}
+	}()
+	return Eval(program, scope), nil
 }
 
 func EvalObjectAccessExpression(objAccess ObjectAccessExpr, scope *Scope) RuntimeVal {
 	ownerName := objAccess.owner.name
-	owningObj := scope.GetVarVal(ownerName)
-	return Eval(objAccess.property, owningObj.(ObjectVal).properties)
+	return EvalPropertyExpression(objAccess.property, scope.GetVarVal(ownerName), ownerName, scope)
+}
+
+// EvalPropertyExpression looks up a property of an object, arguments and indexes
+// used in the property are evaluated in the scope of the caller
+func EvalPropertyExpression(property Expression, owner RuntimeVal, ownerName string, scope *Scope) RuntimeVal {
+	object, ok := owner.(ObjectVal)
+	if !ok {
+		panic(codedError(CodeNotAnObject, ownerName))
+	}
+	properties := object.properties
+	switch property := property.(type) {
+	case Identifier:
+		return properties.GetVarVal(property.name)
+	case FuncCallExpression:
+		return CallFunction(properties.GetVarVal(property.name), EvalArguments(property.arguments, scope), scope)
+	case ArrayAccessExpr:
+		index := Eval(property.index, scope)
+		array, i := arrayElement(properties.GetVarVal(property.name), index, property.name)
+		return array.values[i]
+	case ObjectAccessExpr:
+		return EvalPropertyExpression(property.property, properties.GetVarVal(property.owner.name), property.owner.name, scope)
+	}
+	panic(codedError(CodeInvalidProperty, property))
 }
 
 func EvalObjectDeclareExpression(objDeclare ObjectDeclareExpr, scope *Scope) RuntimeVal {
 	objProps := NewScope(nil)
-	for name, expr := range objDeclare.props {
-		objProps.DeclareVar(name, Eval(expr, scope))
+	scope.exec.checkCollectionSize(len(objDeclare.names))
+	for i, name := range objDeclare.names {
+		// a property written twice keeps its last value
+		objProps.variables[name] = Eval(objDeclare.values[i], scope)
 	}
-	return NewObjectVal(objProps)
+	return scope.exec.allocated(NewObjectVal(objProps))
 }
 
 func EvalArrayAccessExpression(expr ArrayAccessExpr, scope *Scope) RuntimeVal {
-	index := Eval(expr.index, scope).(IntVal)
-	arrayVal := EvalIdentifier(NewIdentifier(expr.name), scope).(ArrayVal)
-	return arrayVal.values[index.value]
+	index := Eval(expr.index, scope)
+	array, i := arrayElement(EvalIdentifier(NewIdentifier(expr.name), scope), index, expr.name)
+	return array.values[i]
+}
+
+// arrayElement checks that the value of name is an array and index the position of one of its elements
+func arrayElement(value RuntimeVal, index RuntimeVal, name string) (ArrayVal, int) {
+	array, ok := value.(ArrayVal)
+	if !ok {
+		panic(codedError(CodeNotAnArray, name))
+	}
+	if i, ok := index.(IntVal); ok && i.value >= 0 && i.value < len(array.values) {
+		return array, i.value
+	}
+	panic(codedError(CodeInvalidIndex, Inspect(index), name, len(array.values)))
+}
+
+// reaches tells whether value is the array or holds it, arrays sharing their elements being
+// the same array. Arrays are the only values changed after they are created, the check
+// keeps them from holding themselves.
+func reaches(value RuntimeVal, array ArrayVal) bool {
+	switch value := value.(type) {
+	case ArrayVal:
+		if len(value.values) > 0 && &value.values[0] == &array.values[0] {
+			return true
+		}
+		for _, element := range value.values {
+			if reaches(element, array) {
+				return true
+			}
+		}
+	case ObjectVal:
+		for _, property := range value.properties.variables {
+			if reaches(property, array) {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 func EvalArrayLiteral(statement ArrayLiteral, scope *Scope) RuntimeVal {
+	scope.exec.checkCollectionSize(len(statement.values))
 	var runTimeValues []RuntimeVal
 	for _, expr := range statement.values {
 		runTimeValues = append(runTimeValues, Eval(expr, scope))
 	}
-	return NewArrayVal(runTimeValues)
+	return scope.exec.allocated(NewArrayVal(runTimeValues))
 }
 
 func EvalProgram(program Program, scope *Scope) RuntimeVal {
 	var lastValue RuntimeVal = NullVal{}
 	for _, statement := range program.body {
+		scope.exec.statement(statement, scope)
 		lastValue = Eval(statement, scope)
 	}
 	return lastValue
@@ -86,6 +178,7 @@ func EvalConditionalBody(body []Statement, scope *Scope) RuntimeVal {
 		if statement.Kind() == StmtReturn {
 			return NewReturnVal(lastValue)
 		}
+		scope.exec.statement(statement, scope)
 		lastValue = Eval(statement, scope)
 	}
 	return lastValue
@@ -93,6 +186,7 @@ func EvalConditionalBody(body []Statement, scope *Scope) RuntimeVal {
 func EvalConditionalExpression(conditionStatement ConditionalExpression, scope *Scope) RuntimeVal {
 	conditionResult := Eval(conditionStatement.condition, scope)
 	bodyScope := NewScope(scope)
+	scope.exec.branch(conditionStatement, conditionResult.Value() == true)
 	if conditionResult.Value() == true {
 		return EvalConditionalBody(conditionStatement.trueBody, bodyScope)
 	} else {
@@ -106,6 +200,7 @@ func EvalWhileLoopExpression(expression WhileLoopExpression, scope *Scope) Runti
 	var lastValue RuntimeVal = NullVal{}
 	for conditionResult.Value() == true {
 		for _, statement := range expression.body {
+			bodyScope.exec.statement(statement, bodyScope)
 			lastValue = Eval(statement, bodyScope)
 			if lastValue.Kind() == VaBreakVal {
 				// return the actual value
@@ -132,21 +227,66 @@ func EvalFuncCallExpression(call FuncCallExpression, scope *Scope) RuntimeVal {
 	return NullVal{}
 }
 
+// CallFunction calls a user or native function with already evaluated arguments,
+// calling any other value gives null
+func CallFunction(funcVal RuntimeVal, args []RuntimeVal, scope *Scope) RuntimeVal {
+	switch funcVal := funcVal.(type) {
+	case FunctionVal:
+		return CallUserFunc(funcVal, args, scope)
+	case NativeFuncVal:
+		return funcVal.Invoke(scope, args...)
+	}
+	return NullVal{}
+}
+
 func EvalNativeFuncCallExpression(funcVal NativeFuncVal, argExpressions []Expression, scope *Scope) RuntimeVal {
+	return funcVal.Invoke(scope, EvalArguments(argExpressions, scope)...)
+}
+
+func EvalUserFuncCallExpression(functionVal FunctionVal, argExpressions []Expression, scope *Scope) RuntimeVal {
+	return CallUserFunc(functionVal, EvalArguments(argExpressions, scope), scope)
+}
+
+// EvalArguments evaluates the arguments of a call in the caller's scope
+func EvalArguments(argExpressions []Expression, scope *Scope) []RuntimeVal {
 	var argsVal []RuntimeVal
 	for _, expr := range argExpressions {
 		argsVal = append(argsVal, Eval(expr, scope))
 	}
-	return funcVal.Invoke(scope, argsVal...)
+	return argsVal
 }
 
-func EvalUserFuncCallExpression(functionVal FunctionVal, argExpressions []Expression, scope *Scope) RuntimeVal {
-	funcScope := NewScope(scope)
-	var lastValue RuntimeVal = NullVal{}
+// CallUserFunc runs the body of a user function with already evaluated arguments, extra
+// arguments are ignored. The body sees the variables of the scope the function was declared
+// in rather than the ones of the caller, the limits of the run are the ones of the caller.
+func CallUserFunc(functionVal FunctionVal, args []RuntimeVal, scope *Scope) RuntimeVal {
+	if len(args) < len(functionVal.arguments) {
+		panic(codedError(CodeMissingArguments, Inspect(functionVal), len(functionVal.arguments), len(args)))
+	}
+	scope.exec.enterCall()
+	defer scope.exec.exitCall()
+	parent := functionVal.closure
+	if parent == nil {
+		parent = scope
+	}
+	funcScope := NewScope(parent)
+	funcScope.exec = scope.exec
+	scope.exec.call(functionVal, funcScope)
+	defer scope.exec.leave()
 	for i, identifier := range functionVal.arguments {
-		funcScope.DeclareVar(identifier.name, Eval(argExpressions[i], funcScope))
+		argVal := args[i]
+		funcScope.DeclareVar(identifier.name, argVal)
+		scope.exec.variable(identifier.name, argVal, true)
 	}
-	for _, statement := range functionVal.body {
+	value := evalFunctionBody(functionVal.body, funcScope)
+	scope.exec.returned(value)
+	return value
+}
+
+func evalFunctionBody(body []Statement, funcScope *Scope) RuntimeVal {
+	var lastValue RuntimeVal = NullVal{}
+	for _, statement := range body {
+		funcScope.exec.statement(statement, funcScope)
 		lastValue = Eval(statement, funcScope)
 		if lastValue.Kind() == VaReturnVal {
 			// return the actual value
@@ -175,14 +315,23 @@ func EvalBinaryExpression(binaryExp BinaryExpression, scope *Scope) RuntimeVal {
 	if rhs.Kind() == VaIntVal {
 		if lhs.Kind() == VaIntVal {
 			return EvalIntBinaryExpression(lhs.(IntVal), rhs.(IntVal), operator)
-		} else if operator == "-" {
+		} else if operator == "-" && lhs.Kind() != VaFloatVal {
 			return EvalIntBinaryExpression(NewIntVal(0), rhs.(IntVal), operator)
 		}
 	}
+	// an int mixed with a float gives a float
+	if rhsFloat, ok := toFloat(rhs); ok {
+		if lhsFloat, ok := toFloat(lhs); ok {
+			return EvalFloatBinaryExpression(lhsFloat, rhsFloat, operator)
+		} else if operator == "-" {
+			return EvalFloatBinaryExpression(0, rhsFloat, operator)
+		}
+	}
 
 	// array math operator
 	if lhs.Kind() == rhs.Kind() && rhs.Kind() == VaArrayVal {
-		return EvalArrayBinaryExpression(lhs.(ArrayVal), rhs.(ArrayVal), operator)
+		scope.exec.checkCollectionSize(len(lhs.(ArrayVal).values) + len(rhs.(ArrayVal).values))
+		return scope.exec.allocated(EvalArrayBinaryExpression(lhs.(ArrayVal), rhs.(ArrayVal), operator))
 	}
 	return NullVal{}
 }
@@ -208,33 +357,97 @@ func EvalLogicalBinaryExpression(lhs RuntimeVal, rhs RuntimeVal, operator string
 func EvalArrayBinaryExpression(lhs ArrayVal, rhs ArrayVal, operator string) RuntimeVal {
 	switch operator {
 	case "+":
-		return NewArrayVal(append(lhs.values, rhs.values...))
+		// a new slice so the result never shares storage with lhs, x + [] copies x
+		values := make([]RuntimeVal, 0, len(lhs.values)+len(rhs.values))
+		return NewArrayVal(append(append(values, lhs.values...), rhs.values...))
 	}
-	log.Panic("Unsupported operator for array: ", operator)
-	return NullVal{}
+	panic(codedError(CodeArrayOperator, operator))
 }
 
 func EvalComparisonBinaryExpression(lhs RuntimeVal, rhs RuntimeVal, operator string) RuntimeVal {
-	if operator == "==" {
-		if lhs.Kind() == rhs.Kind() {
-			return NewBoolVal(lhs.Value() == rhs.Value())
-		} else {
-			return FalseVal
-		}
+	// ints and floats compare by their numeric value
+	lhsFloat, lhsIsNumber := toFloat(lhs)
+	rhsFloat, rhsIsNumber := toFloat(rhs)
+	if lhsIsNumber && rhsIsNumber && lhs.Kind() != rhs.Kind() {
+		return EvalFloatComparisonExpression(lhsFloat, rhsFloat, operator)
 	}
-	if operator == "!=" {
-		if lhs.Kind() == rhs.Kind() {
-			return NewBoolVal(lhs.Value() != rhs.Value())
-		} else {
-			return TrueVal
-		}
+	if operator == "==" || operator == "!=" {
+		return NewBoolVal(equal(lhs, rhs, operator) == (operator == "=="))
 	}
 	if lhs.Kind() == rhs.Kind() && lhs.Kind() == VaIntVal {
 		return EvalIntComparisonExpression(lhs.(IntVal), rhs.(IntVal), operator)
 	}
+	if lhs.Kind() == rhs.Kind() && lhs.Kind() == VaFloatVal {
+		return EvalFloatComparisonExpression(lhsFloat, rhsFloat, operator)
+	}
 	return NullVal{}
 }
 
+// equal compares values for '==' and '!=': numbers by their numeric value, arrays and objects
+// by their elements, and functions can't be compared
+func equal(lhs RuntimeVal, rhs RuntimeVal, operator string) bool {
+	switch lhs.(type) {
+	case FunctionVal, NativeFuncVal:
+		panic(codedError(CodeNotComparable, operator))
+	}
+	switch rhs.(type) {
+	case FunctionVal, NativeFuncVal:
+		panic(codedError(CodeNotComparable, operator))
+	}
+	switch lhs := lhs.(type) {
+	case IntVal:
+		if rhs, ok := rhs.(IntVal); ok {
+			return lhs.value == rhs.value
+		}
+	case ArrayVal:
+		rhs, ok := rhs.(ArrayVal)
+		if !ok || len(lhs.values) != len(rhs.values) {
+			return false
+		}
+		for i := range lhs.values {
+			if !equal(lhs.values[i], rhs.values[i], operator) {
+				return false
+			}
+		}
+		return true
+	case ObjectVal:
+		rhs, ok := rhs.(ObjectVal)
+		if !ok || len(lhs.properties.variables) != len(rhs.properties.variables) {
+			return false
+		}
+		for name, value := range lhs.properties.variables {
+			other, found := rhs.properties.variables[name]
+			if !found || !equal(value, other, operator) {
+				return false
+			}
+		}
+		return true
+	}
+	if lhsFloat, ok := toFloat(lhs); ok {
+		rhsFloat, ok := toFloat(rhs)
+		return ok && lhsFloat == rhsFloat
+	}
+	return lhs.Kind() == rhs.Kind() && lhs.Value() == rhs.Value()
+}
+
+func EvalFloatComparisonExpression(lhsVal float64, rhsVal float64, operator string) RuntimeVal {
+	switch operator {
+	case "==":
+		return NewBoolVal(lhsVal == rhsVal)
+	case "!=":
+		return NewBoolVal(lhsVal != rhsVal)
+	case "<=":
+		return NewBoolVal(lhsVal <= rhsVal)
+	case ">=":
+		return NewBoolVal(lhsVal >= rhsVal)
+	case "<":
+		return NewBoolVal(lhsVal < rhsVal)
+	case ">":
+		return NewBoolVal(lhsVal > rhsVal)
+	}
+	panic(codedError(CodeUnsupportedOperator, operator))
+}
+
 func EvalIntComparisonExpression(lhs IntVal, rhs IntVal, operator string) RuntimeVal {
 	lhsVal := lhs.value
 	rhsVal := rhs.value
@@ -248,8 +461,7 @@ func EvalIntComparisonExpression(lhs IntVal, rhs IntVal, operator string) Runtim
 	case ">":
 		return NewBoolVal(lhsVal > rhsVal)
 	}
-	log.Panic("Unsupported operator: ", operator)
-	return NullVal{}
+	panic(codedError(CodeUnsupportedOperator, operator))
 }
 
 func EvalIdentifier(identifier Identifier, scope *Scope) RuntimeVal {
@@ -261,13 +473,18 @@ func EvalVarDeclareExpression(varDeclareExpr VarDeclareExpression, scope *Scope)
 	varValue := Eval(varDeclareExpr.valueExpr, scope)
 	// create variable in scope
 	scope.DeclareVar(varName, varValue)
+	scope.exec.variable(varName, varValue, true)
 	return varValue
 }
 
 func EvalFuncDeclareExpression(funcDeclareExpr FuncDeclareExpression, scope *Scope) RuntimeVal {
 	funcName := funcDeclareExpr.name
-	funcVal := NewFuncVal(funcName, funcDeclareExpr.arguments, funcDeclareExpr.body)
-	scope.DeclareVar(funcName, funcVal)
+	funcVal := NewFuncVal(funcName, funcDeclareExpr.arguments, funcDeclareExpr.body, scope)
+	// anonymous functions are only declared when assigned to a variable
+	if funcName != "" {
+		scope.DeclareVar(funcName, funcVal)
+		scope.exec.variable(funcName, funcVal, true)
+	}
 	return funcVal
 }
 
@@ -275,13 +492,21 @@ func EvalAssignmentExpression(expr Expression, varValue RuntimeVal, scope *Scope
 	switch expr.Kind() {
 	case StmtIdentifier:
 		// assign variable in scope
-		scope.AssignVar(expr.(Identifier).name, varValue)
+		name := expr.(Identifier).name
+		if _, err := scope.resolve(name); err == nil {
+			scope.AssignVar(name, varValue)
+			scope.exec.variable(name, varValue, false)
+		}
 		return varValue
 	case StmtArrayAccessExpr:
 		arrayAccessExpr := expr.(ArrayAccessExpr)
-		index := Eval(arrayAccessExpr.index, scope).(IntVal)
-		arrayVal := EvalIdentifier(NewIdentifier(arrayAccessExpr.name), scope).(ArrayVal)
-		arrayVal.values[index.value] = varValue
+		index := Eval(arrayAccessExpr.index, scope)
+		arrayVal, i := arrayElement(EvalIdentifier(NewIdentifier(arrayAccessExpr.name), scope), index, arrayAccessExpr.name)
+		if reaches(varValue, arrayVal) {
+			panic(codedError(CodeArrayContainsItself, arrayAccessExpr.name))
+		}
+		arrayVal.values[i] = varValue
+		scope.exec.variable(arrayAccessExpr.name, arrayVal, false)
 		return varValue
 	}
 
@@ -303,9 +528,26 @@ func EvalIntBinaryExpression(val IntVal, val2 IntVal, operator string) RuntimeVa
 			value: val.value * val2.value,
 		}
 	case "/":
+		if val2.value == 0 {
+			panic(codedError(CodeDivisionByZero))
+		}
 		return IntVal{
 			value: val.value / val2.value,
 		}
 	}
 	return NullVal{}
 }
+
+func EvalFloatBinaryExpression(val float64, val2 float64, operator string) RuntimeVal {
+	switch operator {
+	case "+":
+		return NewFloatVal(val + val2)
+	case "-":
+		return NewFloatVal(val - val2)
+	case "*":
+		return NewFloatVal(val * val2)
+	case "/":
+		return NewFloatVal(val / val2)
+	}
+	return NullVal{}
+}
@@ -1,6 +1,9 @@
-package main
+package blulang
 
 import (
+	"fmt"
+	"sort"
+	"strings"
 	"unicode"
 )
 
@@ -9,6 +12,31 @@ type TokenType string
 type Token struct {
 	name  TokenType
 	value string
+	pos   Position
+	// start and end are the rune offsets of the token in the source
+	start int
+	end   int
+	// endPos is the position just after the token
+	endPos Position
+	// comments are the comments between the previous token and this one
+	comments []Comment
+}
+
+// Comment is a comment of the source, from ';' to the end of the line. Comments are kept
+// as trivia of the token following them so tools can print them back.
+type Comment struct {
+	Text string
+	Pos  Position
+}
+
+// Position is a 1-based line and column in the source, counted in runes
+type Position struct {
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
 }
 
 const (
@@ -33,29 +61,17 @@ const (
 	TkCloseCurly     TokenType = "CloseCurlyBrace"
 	TKOpenSquare     TokenType = "OpenSquareBrace"
 	TkCloseSquare    TokenType = "CloseSquareBrace"
+	TkImport         TokenType = "Import"
+	TkExport         TokenType = "Export"
+	TkAs             TokenType = "As"
+	TkTest           TokenType = "Test"
 )
 
-var Keywords = map[string]TokenType{
-	"let":    TkDeclareVar,
-	"cho":    TkDeclareVar,
-	"fn":     TkDeclareFunc,
-	"hàm":    TkDeclareFunc,
-	"if":     TkIf,
-	"nếu":    TkIf,
-	"else":   TkElse,
-	"hay":    TkElse,
-	"while":  TkWhile,
-	"khi":    TkWhile,
-	"return": TkReturn,
-	"trả":    TkReturn,
-	"break":  TkBreak,
-	"nghỉ":   TkBreak,
-}
-
-func NewToken(name TokenType, value string) Token {
+func NewToken(name TokenType, value string, pos Position) Token {
 	return Token{
 		name:  name,
 		value: value,
+		pos:   pos,
 	}
 }
 
@@ -98,17 +114,107 @@ func isTwoCharBinaryOperator(first rune, second rune) bool {
 	return false
 }
 
+// positionFinder maps rune offsets of the source to line and column
+func positionFinder(runeArr []rune) func(offset int) Position {
+	lineStarts := []int{0}
+	for i, ch := range runeArr {
+		if ch == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	return func(offset int) Position {
+		line := sort.Search(len(lineStarts), func(l int) bool { return lineStarts[l] > offset }) - 1
+		return Position{Line: line + 1, Column: offset - lineStarts[line] + 1}
+	}
+}
+
+// isIdentifierPart accepts combining marks so identifiers typed with decomposed accents stay one word
+func isIdentifierPart(ch rune) bool {
+	return isAlpha(ch) || isDigit(ch) || unicode.Is(unicode.Mn, ch)
+}
+
+// readWord reads the identifier starting at offset, it returns the word in NFC
+// and the offset following it
+func readWord(runeArr []rune, offset int) (string, int) {
+	end := offset
+	for end < len(runeArr) && isIdentifierPart(runeArr[end]) {
+		end++
+	}
+	return NormalizeNFC(string(runeArr[offset:end])), end
+}
+
+// matchCompoundKeyword finds a keyword made of several words starting with word,
+// it returns the keyword and the offset following it or 0 when there is none
+func matchCompoundKeyword(runeArr []rune, keywords map[string]TokenType, word string, offset int) (string, int) {
+	for keyword := range keywords {
+		words := strings.Fields(keyword)
+		if len(words) < 2 || words[0] != word {
+			continue
+		}
+		i := offset
+		matched := true
+		for _, expected := range words[1:] {
+			if i >= len(runeArr) || (runeArr[i] != ' ' && runeArr[i] != '\t') {
+				matched = false
+				break
+			}
+			for i < len(runeArr) && (runeArr[i] == ' ' || runeArr[i] == '\t') {
+				i++
+			}
+			var next string
+			next, i = readWord(runeArr, i)
+			if next != expected {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return keyword, i
+		}
+	}
+	return "", 0
+}
+
+// Tokenize splits the source into tokens with the keywords of the default locales
 func Tokenize(source string) []Token {
+	tokens, _ := tokenize(source, mergeKeywords(DefaultLocales()))
+	return tokens
+}
+
+// tokenize splits the source into tokens, it also returns the comments following the last token
+func tokenize(source string, keywords map[string]TokenType) ([]Token, []Comment) {
 	var tokens []Token
+	var comments []Comment
 	runeArr := []rune(source)
-	for i := 0; i < len(runeArr); i++ {
+	positionOf := positionFinder(runeArr)
+	start := 0
+	// skip the shebang line so scripts can be executed directly
+	if len(runeArr) > 1 && runeArr[0] == '#' && runeArr[1] == '!' {
+		for start < len(runeArr) && runeArr[start] != '\n' {
+			start++
+		}
+	}
+	// the span of a token is known once the lexer moved past it, the comments read
+	// before it are attached at the same time
+	tokenStart, spanned := 0, 0
+	closeSpans := func(end int) {
+		for ; spanned < len(tokens); spanned++ {
+			tokens[spanned].start, tokens[spanned].end = tokenStart, min(end, len(runeArr))
+			tokens[spanned].endPos = positionOf(tokens[spanned].end)
+			tokens[spanned].comments, comments = comments, nil
+		}
+	}
+	for i := start; i < len(runeArr); i++ {
+		closeSpans(i)
 		ch := runeArr[i]
 		if isIgnored(ch) {
 			continue
 		}
+		tokenStart = i
+		pos := positionOf(i)
 
 		if i+1 < len(runeArr) && isTwoCharBinaryOperator(ch, runeArr[i+1]) {
-			tokens = append(tokens, NewToken(TkBinaryOperator, string(ch)+string(runeArr[i+1])))
+			tokens = append(tokens, NewToken(TkBinaryOperator, string(ch)+string(runeArr[i+1]), pos))
 			i++
 			continue
 		}
@@ -118,42 +224,45 @@ func Tokenize(source string) []Token {
 			for i+1 < len(runeArr) && runeArr[i+1] != '\n' {
 				i++
 			}
+			text := strings.TrimRightFunc(string(runeArr[tokenStart:i+1]), unicode.IsSpace)
+			comments = append(comments, Comment{Text: text, Pos: pos})
+			continue
 		}
 
 		if ch == '[' {
-			tokens = append(tokens, NewToken(TKOpenSquare, string(ch)))
+			tokens = append(tokens, NewToken(TKOpenSquare, string(ch), pos))
 			continue
 		}
 		if ch == ']' {
-			tokens = append(tokens, NewToken(TkCloseSquare, string(ch)))
+			tokens = append(tokens, NewToken(TkCloseSquare, string(ch), pos))
 			continue
 		}
 		if ch == '(' {
-			tokens = append(tokens, NewToken(TkOpenRound, string(ch)))
+			tokens = append(tokens, NewToken(TkOpenRound, string(ch), pos))
 			continue
 		}
 		if ch == ')' {
-			tokens = append(tokens, NewToken(TkCloseRound, string(ch)))
+			tokens = append(tokens, NewToken(TkCloseRound, string(ch), pos))
 			continue
 		}
 		if ch == '{' {
-			tokens = append(tokens, NewToken(TkOpenCurly, string(ch)))
+			tokens = append(tokens, NewToken(TkOpenCurly, string(ch), pos))
 			continue
 		}
 		if ch == '}' {
-			tokens = append(tokens, NewToken(TkCloseCurly, string(ch)))
+			tokens = append(tokens, NewToken(TkCloseCurly, string(ch), pos))
 			continue
 		}
 		if ch == ',' {
-			tokens = append(tokens, NewToken(TkComma, string(ch)))
+			tokens = append(tokens, NewToken(TkComma, string(ch), pos))
 			continue
 		}
 		if ch == ':' {
-			tokens = append(tokens, NewToken(TKColon, string(ch)))
+			tokens = append(tokens, NewToken(TKColon, string(ch), pos))
 			continue
 		}
 		if ch == '.' {
-			tokens = append(tokens, NewToken(TkDot, string(ch)))
+			tokens = append(tokens, NewToken(TkDot, string(ch), pos))
 			continue
 		}
 		if ch == '"' {
@@ -165,17 +274,20 @@ func Tokenize(source string) []Token {
 					str += string(runeArr[i])
 				}
 			}
+			if i+1 >= len(runeArr) {
+				panic(NewSyntaxError(pos, CodeUnterminatedString))
+			}
 			i++ // skip closing quote
-			tokens = append(tokens, NewToken(TkString, str))
+			tokens = append(tokens, NewToken(TkString, str, pos))
 			continue
 		}
 		if ch == '!' {
-			tokens = append(tokens, NewToken(TkNot, string(ch)))
+			tokens = append(tokens, NewToken(TkNot, string(ch), pos))
 			continue
 		}
 
 		if isOneCharBinaryOperator(ch) {
-			tokens = append(tokens, NewToken(TkBinaryOperator, string(ch)))
+			tokens = append(tokens, NewToken(TkBinaryOperator, string(ch), pos))
 			continue
 		}
 
@@ -185,24 +297,35 @@ func Tokenize(source string) []Token {
 				i++
 				numStr = numStr + string(runeArr[i])
 			}
-			tokens = append(tokens, NewToken(TkNumber, numStr))
+			// a dot followed by a digit is a decimal point rather than a property access
+			if i+2 < len(runeArr) && runeArr[i+1] == '.' && isDigit(runeArr[i+2]) {
+				i++
+				numStr = numStr + "."
+				for i+1 < len(runeArr) && isDigit(runeArr[i+1]) {
+					i++
+					numStr = numStr + string(runeArr[i])
+				}
+			}
+			tokens = append(tokens, NewToken(TkNumber, numStr, pos))
 			continue
 		}
 
 		if isAlpha(ch) {
-			word := string(ch)
-			for i+1 < len(runeArr) && (isAlpha(runeArr[i+1]) || isDigit(runeArr[i+1])) {
-				i++
-				word = word + string(runeArr[i])
+			// identifiers and keywords are compared in NFC so decomposed accents match precomposed ones
+			word, end := readWord(runeArr, i)
+			i = end - 1
+			if compound, end := matchCompoundKeyword(runeArr, keywords, word, i+1); end > 0 {
+				word, i = compound, end-1
 			}
-			keywordType, found := Keywords[word]
+			keywordType, found := keywords[word]
 			if found {
-				tokens = append(tokens, NewToken(keywordType, word))
+				tokens = append(tokens, NewToken(keywordType, word, pos))
 			} else {
-				tokens = append(tokens, NewToken(TkIdentifier, word))
+				tokens = append(tokens, NewToken(TkIdentifier, word, pos))
 			}
 			continue
 		}
 	}
-	return tokens
+	closeSpans(len(runeArr))
+	return tokens, comments
 }
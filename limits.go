@@ -0,0 +1,141 @@
+package blulang
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+// DefaultMaxCallDepth keeps deep recursion from overflowing the Go stack
+const DefaultMaxCallDepth = 10000
+
+var (
+	ErrStepLimit       = errors.New("step limit exceeded")
+	ErrCallDepthLimit  = errors.New("call depth limit exceeded")
+	ErrCollectionLimit = errors.New("collection size limit exceeded")
+)
+
+// Limits bound the resources a program may use, a zero value means unlimited
+// except for MaxCallDepth which defaults to DefaultMaxCallDepth
+type Limits struct {
+	// MaxSteps is the number of statements and expressions that may be evaluated
+	MaxSteps int
+	// MaxCallDepth is the number of nested user function calls
+	MaxCallDepth int
+	// MaxCollectionSize is the number of elements an array or object may hold
+	MaxCollectionSize int
+	// Timeout is the wall clock time a program may run for
+	Timeout time.Duration
+}
+
+// how many steps are evaluated between two checks of the context
+const contextCheckInterval = 256
+
+// execution tracks the limits of one run, it is shared by every scope created during the run
+type execution struct {
+	ctx    context.Context
+	limits Limits
+	steps  int
+	depth  int
+	// hook follows the run when one is given, frames are only tracked for it
+	hook   Hook
+	frames []*Frame
+	// expressions is the hook when it also follows expressions
+	expressions ExpressionHook
+	// branches is the hook when it also follows the bodies of if
+	branches BranchHook
+	// allocations is the hook when it also follows the arrays and objects created
+	allocations AllocationHook
+}
+
+func newExecution(ctx context.Context, limits Limits, hook Hook) *execution {
+	if limits.MaxCallDepth == 0 {
+		limits.MaxCallDepth = DefaultMaxCallDepth
+	}
+	execution := &execution{ctx: ctx, limits: limits, hook: hook}
+	execution.expressions, _ = hook.(ExpressionHook)
+	execution.branches, _ = hook.(BranchHook)
+	execution.allocations, _ = hook.(AllocationHook)
+	return execution
+}
+
+func (e *execution) step() {
+	if e == nil {
+		return
+	}
+	e.steps++
+	if e.limits.MaxSteps > 0 && e.steps > e.limits.MaxSteps {
+		panic(causedError(ErrStepLimit, CodeStepLimit))
+	}
+	if e.steps%contextCheckInterval == 0 {
+		e.checkContext()
+	}
+}
+
+func (e *execution) checkContext() {
+	if e == nil {
+		return
+	}
+	if err := e.ctx.Err(); err != nil {
+		panic(causedError(err, CodeStopped, err))
+	}
+}
+
+func (e *execution) enterCall() {
+	if e == nil {
+		return
+	}
+	e.depth++
+	if e.limits.MaxCallDepth > 0 && e.depth > e.limits.MaxCallDepth {
+		panic(causedError(ErrCallDepthLimit, CodeCallDepthLimit))
+	}
+}
+
+func (e *execution) exitCall() {
+	if e != nil {
+		e.depth--
+	}
+}
+
+func (e *execution) checkCollectionSize(size int) {
+	if e != nil && e.limits.MaxCollectionSize > 0 && size > e.limits.MaxCollectionSize {
+		panic(causedError(ErrCollectionLimit, CodeCollectionLimit))
+	}
+}
+
+// checkRepeatedSize is checkCollectionSize for count copies of size elements, checked
+// before multiplying so that a large count can't overflow the size
+func (e *execution) checkRepeatedSize(size int, count int) {
+	limit := math.MaxInt
+	if e != nil && e.limits.MaxCollectionSize > 0 {
+		limit = e.limits.MaxCollectionSize
+	}
+	if size > 0 && count > limit/size {
+		panic(causedError(ErrCollectionLimit, CodeCollectionLimit))
+	}
+}
+
+// ExecuteContext evaluates a program like Execute, stopping when the context is done
+// or one of the limits is exceeded
+func ExecuteContext(ctx context.Context, program Program, scope *Scope, limits Limits) (result RuntimeVal, err error) {
+	return executeHooked(ctx, program, scope, limits, nil)
+}
+
+// executeHooked is ExecuteContext with a hook following the run
+func executeHooked(ctx context.Context, program Program, scope *Scope, limits Limits, hook Hook) (result RuntimeVal, err error) {
+	if limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, limits.Timeout)
+		defer cancel()
+	}
+	if err := ctx.Err(); err != nil {
+		return NullVal{}, causedError(err, CodeStopped, err)
+	}
+	previous := scope.exec
+	scope.exec = newExecution(ctx, limits, hook)
+	defer func() {
+		scope.exec = previous
+	}()
+	return Execute(program, scope)
+}
@@ -0,0 +1,59 @@
+package blulang_test
+
+import (
+	"blulang"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestStepLimit(t *testing.T) {
+	interpreter := blulang.New(blulang.Options{Limits: blulang.Limits{MaxSteps: 1000}})
+	_, err := interpreter.Run(context.Background(), `while 1 == 1 {}`)
+	assert.ErrorIs(t, err, blulang.ErrStepLimit)
+	result, err := interpreter.Run(context.Background(), `let a = 0 while a < 10 { a = a + 1 }`)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, result.Value())
+}
+
+func TestCallDepthLimit(t *testing.T) {
+	interpreter := blulang.New(blulang.Options{Limits: blulang.Limits{MaxCallDepth: 50}})
+	_, err := interpreter.Run(context.Background(), `
+	fn down(n) { if n == 0 { 0 } else { down(n - 1) } }
+	down(40)
+	`)
+	assert.NoError(t, err)
+	_, err = interpreter.Run(context.Background(), `down(60)`)
+	assert.ErrorIs(t, err, blulang.ErrCallDepthLimit)
+	_, err = interpreter.Call("down", blulang.NewIntVal(60))
+	assert.ErrorIs(t, err, blulang.ErrCallDepthLimit)
+
+	// runaway recursion fails with the default depth instead of overflowing the stack
+	_, err = blulang.New(blulang.Options{}).Run(context.Background(), `fn forever(n) { forever(n + 1) } forever(0)`)
+	assert.ErrorIs(t, err, blulang.ErrCallDepthLimit)
+}
+
+func TestCollectionLimit(t *testing.T) {
+	interpreter := blulang.New(blulang.Options{Limits: blulang.Limits{MaxCollectionSize: 5}})
+	_, err := interpreter.Run(context.Background(), `let arr = [1, 2, 3] arr = arr + [4, 5]`)
+	assert.NoError(t, err)
+	_, err = interpreter.Run(context.Background(), `arr = arr + [6]`)
+	assert.ErrorIs(t, err, blulang.ErrCollectionLimit)
+	_, err = interpreter.Run(context.Background(), `{ a: 1, b: 2, c: 3, d: 4, e: 5, f: 6 }`)
+	assert.ErrorIs(t, err, blulang.ErrCollectionLimit)
+}
+
+func TestTimeoutAndCancel(t *testing.T) {
+	interpreter := blulang.New(blulang.Options{Limits: blulang.Limits{Timeout: 20 * time.Millisecond}})
+	_, err := interpreter.Run(context.Background(), `while true {}`)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	_, err = blulang.New(blulang.Options{}).Run(ctx, `let i = 0 while true { i = i + 1 }`)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,453 @@
+package blulang
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// LintRule names a check of the linter. Rules are turned off by name in a LintConfig,
+// or for a part of a file with a comment, a comment without rule names applies to every rule:
+//
+//	; lint-disable unused-variable, shadowed-builtin   until the end of the file or a lint-enable
+//	; lint-enable unused-variable
+//	let a = 1 ; lint-disable-line unused-variable
+//	; lint-disable-next-line
+type LintRule string
+
+const (
+	LintUnusedVariable        LintRule = "unused-variable"
+	LintShadowedBuiltin       LintRule = "shadowed-builtin"
+	LintUndeclaredAssignment  LintRule = "undeclared-assignment"
+	LintUnreachableCode       LintRule = "unreachable-code"
+	LintUnusedIfValue         LintRule = "unused-if-value"
+	LintAssignmentInCondition LintRule = "assignment-in-condition"
+)
+
+// LintRules are the rules of the linter with the code of their diagnostic
+var LintRules = map[LintRule]MessageCode{
+	LintUnusedVariable:        CodeUnusedVariable,
+	LintShadowedBuiltin:       CodeShadowedBuiltin,
+	LintUndeclaredAssignment:  CodeUndeclaredAssignment,
+	LintUnreachableCode:       CodeUnreachableCode,
+	LintUnusedIfValue:         CodeUnusedIfValue,
+	LintAssignmentInCondition: CodeAssignmentInCondition,
+}
+
+// LintConfig turns rules on and off by name, the rules it doesn't list are on.
+// It reads from JSON such as {"rules": {"unused-variable": false}}.
+type LintConfig struct {
+	Rules map[LintRule]bool `json:"rules"`
+}
+
+func (c LintConfig) enabled(rule LintRule) bool {
+	on, found := c.Rules[rule]
+	return on || !found
+}
+
+// LintDiagnostic is a problem found by the linter, the message is in English until localized
+type LintDiagnostic struct {
+	Pos     Position
+	Rule    LintRule
+	Code    MessageCode
+	Message string
+	args    []any
+}
+
+func (d LintDiagnostic) String() string {
+	return fmt.Sprintf("%v: %s [%s]", d.Pos, d.Message, d.Rule)
+}
+
+// Localize translates the message of the diagnostic into the language of the locale
+func (d LintDiagnostic) Localize(locale *Locale) LintDiagnostic {
+	d.Message = Localize(locale, d.Code, d.args...)
+	return d
+}
+
+// Lint parses a source and reports the problems found by the rules the config turns on, sorted by
+// position. Names are resolved like the interpreter does: blocks of if and while have their own scope
+// and functions see every variable of the scopes around them since they may be called later.
+func Lint(source string, config LintConfig) (diagnostics []LintDiagnostic, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recoverError(r)
+		}
+	}()
+	parser := NewParser()
+	return Analyze(parser.CreateAST(source)).Lint(config), nil
+}
+
+// Lint returns the problems found by the rules the config turns on, sorted by position
+func (a *Analysis) Lint(config LintConfig) []LintDiagnostic {
+	var diagnostics []LintDiagnostic
+	directives := lintDirectives(a.program)
+	for _, diagnostic := range a.diagnostics {
+		if config.enabled(diagnostic.Rule) && !suppressed(diagnostic, directives) {
+			diagnostics = append(diagnostics, diagnostic)
+		}
+	}
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		a, b := diagnostics[i].Pos, diagnostics[j].Pos
+		return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+	})
+	return diagnostics
+}
+
+// linter resolves the names of a program while checking it
+type linter struct {
+	tokens   []Token
+	builtins map[string]bool
+	scope    *nameScope
+	// functions are checked once the whole program is walked, since a function sees
+	// the variables declared after it when it is called later
+	functions []lintFunction
+	// variables are reported when nothing reads them
+	variables   []*binding
+	diagnostics []LintDiagnostic
+	// declared are the names declared by the program in order, and refers maps the index
+	// of every token naming a variable to the binding it resolves to
+	declared []*binding
+	refers   map[int]*binding
+}
+
+type lintFunction struct {
+	declaration FuncDeclareExpression
+	scope       *nameScope
+}
+
+type nameScope struct {
+	parent *nameScope
+	names  map[string]*binding
+}
+
+// binding is a name declared by the program or a builtin
+type binding struct {
+	name string
+	kind bindingKind
+	pos  Position
+	// token is the index of the name in the declaration, statement is the declaration
+	token     int
+	statement Statement
+	used      bool
+}
+
+type bindingKind int
+
+const (
+	bindVariable bindingKind = iota
+	bindFunction
+	bindParameter
+	bindImport
+	bindBuiltin
+)
+
+func (s *nameScope) lookup(name string) *binding {
+	for scope := s; scope != nil; scope = scope.parent {
+		if binding, found := scope.names[name]; found {
+			return binding
+		}
+	}
+	return nil
+}
+
+func (l *linter) report(rule LintRule, pos Position, args ...any) {
+	code := LintRules[rule]
+	l.diagnostics = append(l.diagnostics, LintDiagnostic{
+		Pos: pos, Rule: rule, Code: code, Message: Localize(nil, code, args...), args: args,
+	})
+}
+
+func (l *linter) run(body []Statement) {
+	l.statements(body, true)
+	for len(l.functions) > 0 {
+		function := l.functions[0]
+		l.functions = l.functions[1:]
+		l.scope = &nameScope{parent: function.scope, names: make(map[string]*binding)}
+		for _, parameter := range function.declaration.arguments {
+			l.declare(parameter.name, bindParameter, parameter.token, function.declaration)
+		}
+		// the last value of a function is returned
+		l.statements(function.declaration.body, true)
+	}
+	for _, variable := range l.variables {
+		if !variable.used {
+			l.report(LintUnusedVariable, variable.pos, variable.name)
+		}
+	}
+}
+
+// declare adds a name to the current scope, token is the index of the name in the declaration
+func (l *linter) declare(name string, kind bindingKind, token int, statement Statement) *binding {
+	pos := statement.Pos()
+	if token < len(l.tokens) {
+		pos = l.tokens[token].pos
+	}
+	if l.builtins[name] {
+		l.report(LintShadowedBuiltin, pos, name)
+	}
+	binding := &binding{name: name, kind: kind, pos: pos, token: token, statement: statement}
+	l.scope.names[name] = binding
+	l.declared = append(l.declared, binding)
+	l.refers[token] = binding
+	return binding
+}
+
+// use marks the binding a name resolves to as read, token is the index of the name
+func (l *linter) use(name string, token int) {
+	if binding := l.scope.lookup(name); binding != nil {
+		binding.used = true
+		l.refers[token] = binding
+	}
+}
+
+// nameToken is the index of the name following the keyword a declaration starts with
+func nameToken(declaration Statement) int {
+	return startToken(declaration) + 1
+}
+
+// statements checks a body, valueUsed tells whether the value of its last statement is read
+func (l *linter) statements(body []Statement, valueUsed bool) {
+	unreachable := false
+	for i, statement := range body {
+		jump := statement.Kind() == StmtReturn || statement.Kind() == StmtBreak
+		if jump && i+1 < len(body) && !unreachable {
+			l.report(LintUnreachableCode, body[i+1].Pos(), l.tokens[startToken(statement)].value)
+			unreachable = true
+		}
+		// the value before a return or a break is the one returned
+		used := i == len(body)-1 && valueUsed ||
+			i+1 < len(body) && (body[i+1].Kind() == StmtReturn || body[i+1].Kind() == StmtBreak)
+		l.statement(statement, used)
+	}
+}
+
+// block checks a body of an if or a while, which has its own scope
+func (l *linter) block(body []Statement, valueUsed bool) {
+	l.scope = &nameScope{parent: l.scope, names: make(map[string]*binding)}
+	l.statements(body, valueUsed)
+	l.scope = l.scope.parent
+}
+
+func (l *linter) statement(statement Statement, valueUsed bool) {
+	switch statement := statement.(type) {
+	case VarDeclareExpression:
+		l.statement(statement.valueExpr, true)
+		l.variables = append(l.variables, l.declare(statement.name, bindVariable, nameToken(statement), statement))
+	case FuncDeclareExpression:
+		if statement.name != "" {
+			l.declare(statement.name, bindFunction, nameToken(statement), statement)
+		}
+		l.functions = append(l.functions, lintFunction{declaration: statement, scope: l.scope})
+	case ConditionalExpression:
+		l.conditional(statement, valueUsed, true)
+	case WhileLoopExpression:
+		l.condition(statement.condition)
+		l.block(statement.body, valueUsed)
+	case BinaryExpression:
+		if statement.operator == "=" {
+			l.assign(statement.left)
+		} else {
+			l.statement(statement.left, true)
+		}
+		l.statement(statement.right, true)
+	case Identifier:
+		l.use(statement.name, startToken(statement))
+	case FuncCallExpression:
+		l.use(statement.name, startToken(statement))
+		for _, argument := range statement.arguments {
+			l.statement(argument, true)
+		}
+	case ArrayAccessExpr:
+		l.use(statement.name, startToken(statement))
+		l.statement(statement.index, true)
+	case ArrayLiteral:
+		for _, value := range statement.values {
+			l.statement(value, true)
+		}
+	case ObjectDeclareExpr:
+		for _, value := range statement.values {
+			l.statement(value, true)
+		}
+	case ObjectAccessExpr:
+		l.use(statement.owner.name, startToken(statement))
+		l.property(statement.property)
+	case ImportExpr:
+		// without 'as' the import is named after the file
+		token := len(l.tokens)
+		if start := startToken(statement); start+3 < len(l.tokens) && l.tokens[start+2].name == TkAs {
+			token = start + 3
+		}
+		l.declare(statement.alias, bindImport, token, statement)
+	case ExportExpr:
+		l.statement(statement.declaration, true)
+		// importers read what is exported
+		name := ""
+		switch declaration := statement.declaration.(type) {
+		case VarDeclareExpression:
+			name = declaration.name
+		case FuncDeclareExpression:
+			name = declaration.name
+		}
+		if binding := l.scope.lookup(name); binding != nil {
+			binding.used = true
+		}
+	case TestBlock:
+		l.block(statement.body, false)
+	}
+}
+
+// property checks the arguments and indexes used in a property, which are evaluated in the scope of the caller
+func (l *linter) property(property Expression) {
+	switch property := property.(type) {
+	case FuncCallExpression:
+		for _, argument := range property.arguments {
+			l.statement(argument, true)
+		}
+	case ArrayAccessExpr:
+		l.statement(property.index, true)
+	case ObjectAccessExpr:
+		l.property(property.property)
+	}
+}
+
+func (l *linter) assign(target Expression) {
+	switch target := target.(type) {
+	case Identifier:
+		if binding := l.scope.lookup(target.name); binding != nil {
+			l.refers[startToken(target)] = binding
+		} else {
+			l.report(LintUndeclaredAssignment, target.Pos(), target.name)
+		}
+	default:
+		l.statement(target, true)
+	}
+}
+
+// conditional checks an if, the ifs chained with 'else if' are reported with the first one
+func (l *linter) conditional(conditional ConditionalExpression, valueUsed bool, first bool) {
+	if first && !valueUsed && pure(conditional) {
+		l.report(LintUnusedIfValue, conditional.Pos())
+	}
+	l.condition(conditional.condition)
+	l.block(conditional.trueBody, valueUsed)
+	if len(conditional.falseBody) == 1 && conditional.falseBody[0].Kind() == StmtConditionalExpr {
+		l.scope = &nameScope{parent: l.scope, names: make(map[string]*binding)}
+		l.conditional(conditional.falseBody[0].(ConditionalExpression), valueUsed, false)
+		l.scope = l.scope.parent
+		return
+	}
+	l.block(conditional.falseBody, valueUsed)
+}
+
+// condition checks the condition of an if or a while, where '=' assigns instead of comparing
+func (l *linter) condition(condition Expression) {
+	var find func(expr Expression)
+	find = func(expr Expression) {
+		if binary, ok := expr.(BinaryExpression); ok {
+			if binary.operator == "=" {
+				l.report(LintAssignmentInCondition, binary.Pos(), l.tokens[startToken(binary.left)].value)
+			}
+			find(binary.left)
+			find(binary.right)
+		}
+	}
+	find(condition)
+	l.statement(condition, true)
+}
+
+// pure reports whether a statement only computes a value, without calls, assignments,
+// declarations or jumps, so nothing is left of it when its value isn't read
+func pure(statement Statement) bool {
+	switch statement := statement.(type) {
+	case IntLiteral, FloatLiteral, StringLiteral, NullLiteral, BoolLiteral, Identifier:
+		return true
+	case BinaryExpression:
+		return statement.operator != "=" && pure(statement.left) && pure(statement.right)
+	case ArrayLiteral:
+		return allPure(statement.values)
+	case ObjectDeclareExpr:
+		return allPure(statement.values)
+	case ArrayAccessExpr:
+		return pure(statement.index)
+	case ObjectAccessExpr:
+		return pure(statement.property)
+	case ConditionalExpression:
+		return pure(statement.condition) && allPure(statement.trueBody) && allPure(statement.falseBody)
+	}
+	return false
+}
+
+func allPure[T Statement](statements []T) bool {
+	for _, statement := range statements {
+		if !pure(statement) {
+			return false
+		}
+	}
+	return true
+}
+
+var lintComment = regexp.MustCompile(`^;\s*lint-(disable-next-line|disable-line|disable|enable)(?:\s+(.*))?$`)
+
+// lintDirective is a comment turning rules off or on, no rules means every rule
+type lintDirective struct {
+	kind  string
+	line  int
+	rules []LintRule
+}
+
+func (d lintDirective) covers(rule LintRule) bool {
+	return len(d.rules) == 0 || slices.Contains(d.rules, rule)
+}
+
+func lintDirectives(program Program) []lintDirective {
+	var comments []Comment
+	for _, token := range program.tokens {
+		comments = append(comments, token.comments...)
+	}
+	comments = append(comments, program.comments...)
+	var directives []lintDirective
+	for _, comment := range comments {
+		match := lintComment.FindStringSubmatch(comment.Text)
+		if match == nil {
+			continue
+		}
+		directive := lintDirective{kind: match[1], line: comment.Pos.Line}
+		for _, name := range strings.FieldsFunc(match[2], func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+			directive.rules = append(directive.rules, LintRule(name))
+		}
+		directives = append(directives, directive)
+	}
+	return directives
+}
+
+// suppressed reports whether a comment turned off the rule of the diagnostic at its line
+func suppressed(diagnostic LintDiagnostic, directives []lintDirective) bool {
+	disabled := false
+	for _, directive := range directives {
+		if !directive.covers(diagnostic.Rule) {
+			continue
+		}
+		switch directive.kind {
+		case "disable-line":
+			if directive.line == diagnostic.Pos.Line {
+				return true
+			}
+		case "disable-next-line":
+			if directive.line+1 == diagnostic.Pos.Line {
+				return true
+			}
+		case "disable":
+			if directive.line <= diagnostic.Pos.Line {
+				disabled = true
+			}
+		case "enable":
+			if directive.line <= diagnostic.Pos.Line {
+				disabled = false
+			}
+		}
+	}
+	return disabled
+}
@@ -0,0 +1,94 @@
+package blulang_test
+
+import (
+	"blulang"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func lintMessages(t *testing.T, source string, config blulang.LintConfig) []string {
+	diagnostics, err := blulang.Lint(source, config)
+	assert.NoErrorf(t, err, source)
+	var messages []string
+	for _, diagnostic := range diagnostics {
+		messages = append(messages, diagnostic.String())
+	}
+	return messages
+}
+
+func TestLintRules(t *testing.T) {
+	sources := map[string][]string{
+		"let a = 1":                         {"1:5: 'a' is declared but never used [unused-variable]"},
+		"let a = 1 if true { let a = 2 a }": {"1:5: 'a' is declared but never used [unused-variable]"},
+		"export let a = 1":                  nil,
+		"fn show() { print(total) } let total = 1 show()": nil,
+		"let print = fn (x) { x } print(1)":               {"1:5: 'print' hides the builtin with the same name [shadowed-builtin]"},
+		"fn f(in) { in } f(1)":                            {"1:6: 'in' hides the builtin with the same name [shadowed-builtin]"},
+		"; lang: en\nfn f(in) { in } f(1)":                nil,
+		"import \"math\" as abs abs.pi":                   {"1:18: 'abs' hides the builtin with the same name [shadowed-builtin]"},
+		"count = 1":                                       nil,
+		"total = 1":                                       {"1:1: 'total' is assigned but never declared, the assignment has no effect [undeclared-assignment]"},
+		"let a = [1] a[0] = 2 fn f() { b = 1 } f()":       {"1:31: 'b' is assigned but never declared, the assignment has no effect [undeclared-assignment]"},
+		"fn f(a) { a return a + 1 } f(1)":                 {"1:20: unreachable code after 'return' [unreachable-code]"},
+		"khi đúng { nghỉ in(1) }":                         {"1:17: unreachable code after 'nghỉ' [unreachable-code]"},
+		"let a = 1 if a > 1 { 1 } else if a { 2 } a":      {"1:11: the value of this if is never used [unused-if-value]"},
+		"let a = 1 if a > 1 { print(1) } a":               nil,
+		"fn f(a) { if a { 1 } else { 2 } return } f(1)":   nil,
+		"let a = 1 if a == 1 || a = 2 { print(a) }":       {"1:24: 'a' is assigned in a condition, use '==' to compare [assignment-in-condition]"},
+		"let a = 1 while !a = 2 { a }":                    {"1:18: 'a' is assigned in a condition, use '==' to compare [assignment-in-condition]"},
+		"let a = 1 if a = 1 { print(a) }":                 {"1:14: 'a' is assigned in a condition, use '==' to compare [assignment-in-condition]"},
+		"hàm f(trỏ) { nếu trỏ = 1 { 1 } hay { 0 } } f(1)": {"1:18: 'trỏ' is assigned in a condition, use '==' to compare [assignment-in-condition]"},
+	}
+	for source, expected := range sources {
+		assert.Equalf(t, expected, lintMessages(t, source, blulang.LintConfig{}), source)
+	}
+}
+
+func TestLintSamples(t *testing.T) {
+	files, err := filepath.Glob("sample/*.blu")
+	assert.NoError(t, err)
+	for _, file := range files {
+		source, err := os.ReadFile(file)
+		assert.NoError(t, err)
+		assert.Emptyf(t, lintMessages(t, string(source), blulang.LintConfig{}), file)
+	}
+}
+
+func TestLintToggles(t *testing.T) {
+	source := `let a = 1
+let print = 2 ; lint-disable-line unused-variable
+; lint-disable-next-line
+let b = 1
+; lint-disable unused-variable, shadowed-builtin
+let c = 1
+let abs = 1
+; lint-enable
+let d = 1
+`
+	assert.Equal(t, []string{
+		"1:5: 'a' is declared but never used [unused-variable]",
+		"2:5: 'print' hides the builtin with the same name [shadowed-builtin]",
+		"9:5: 'd' is declared but never used [unused-variable]",
+	}, lintMessages(t, source, blulang.LintConfig{}))
+
+	config := blulang.LintConfig{Rules: map[blulang.LintRule]bool{blulang.LintUnusedVariable: false}}
+	assert.Equal(t, []string{
+		"2:5: 'print' hides the builtin with the same name [shadowed-builtin]",
+	}, lintMessages(t, source, config))
+
+	source = "let a = 1 if a = 1 { print(a) }"
+	config = blulang.LintConfig{Rules: map[blulang.LintRule]bool{blulang.LintAssignmentInCondition: false}}
+	assert.Empty(t, lintMessages(t, source, config))
+	assert.Empty(t, lintMessages(t, "; lint-disable assignment-in-condition\n"+source, blulang.LintConfig{}))
+}
+
+func TestLintErrors(t *testing.T) {
+	_, err := blulang.Lint("let a = (1", blulang.LintConfig{})
+	assert.EqualError(t, err, "syntax error at 1:10: unexpected end of input, expected ')'")
+
+	diagnostics, err := blulang.Lint("let a = 1", blulang.LintConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, "'a' được khai báo nhưng không được dùng", diagnostics[0].Localize(blulang.Vietnamese).Message)
+}
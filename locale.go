@@ -0,0 +1,94 @@
+package blulang
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Locale is a language pack giving the keywords, the builtin names and the error messages
+// of one human language. Packs are registered by name so files can select them with a header:
+//
+//	; lang: vi
+type Locale struct {
+	// Name is the code used in the header, such as "en" or "vi"
+	Name string
+	// Keywords maps every keyword of the language to its token,
+	// keywords of several words match any spacing between the words
+	Keywords map[string]TokenType
+	// Builtins maps the English name of a builtin to its name in the language,
+	// builtins without a translation keep their English name
+	Builtins map[string]string
+	// Messages translates the formats of the message catalogue, missing ones are shown in English
+	Messages map[MessageCode]string
+	// Explanations translates the explanations printed by blulang explain
+	Explanations map[MessageCode]string
+}
+
+// Locales are the registered language packs by name
+var Locales = map[string]*Locale{}
+
+// RegisterLocale makes a language pack available to the lang header, replacing a pack of the same name
+func RegisterLocale(locale *Locale) {
+	Locales[locale.Name] = locale
+}
+
+func init() {
+	RegisterLocale(English)
+	RegisterLocale(Vietnamese)
+}
+
+// DefaultLocales are used when none are configured, English and Vietnamese can be mixed in one file
+// and error messages are in English
+func DefaultLocales() []*Locale {
+	return []*Locale{English, Vietnamese}
+}
+
+func (l *Locale) builtinName(name string) string {
+	if translated, found := l.Builtins[name]; found {
+		return translated
+	}
+	return name
+}
+
+// mergeKeywords combines the keywords of several packs
+func mergeKeywords(locales []*Locale) map[string]TokenType {
+	if len(locales) == 1 {
+		return locales[0].Keywords
+	}
+	keywords := make(map[string]TokenType)
+	for _, locale := range locales {
+		for keyword, tokenType := range locale.Keywords {
+			keywords[keyword] = tokenType
+		}
+	}
+	return keywords
+}
+
+var localeHeader = regexp.MustCompile(`^;\s*lang:\s*(\S+)\s*$`)
+
+// headerLocale finds the lang header in the comments at the top of the source,
+// it returns the name of the language and the line of the header or 0 when there is none
+func headerLocale(source string) (string, int) {
+	for i, line := range strings.Split(source, "\n") {
+		line = strings.TrimSpace(line)
+		if i == 0 && strings.HasPrefix(line, "#!") || line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, ";") {
+			break
+		}
+		if match := localeHeader.FindStringSubmatch(line); match != nil {
+			return match[1], i + 1
+		}
+	}
+	return "", 0
+}
+
+// sourceLocales are the language packs a parsed source is read with by default,
+// the one of its header or the default ones
+func sourceLocales(source string) []*Locale {
+	if name, line := headerLocale(source); line > 0 {
+		return []*Locale{Locales[name]}
+	}
+	return DefaultLocales()
+}
@@ -0,0 +1,22 @@
+package blulang
+
+// English is the language pack of the original BluLang keywords and builtins,
+// its messages are the catalogue other packs translate
+var English = &Locale{
+	Name: "en",
+	Keywords: map[string]TokenType{
+		"let":    TkDeclareVar,
+		"fn":     TkDeclareFunc,
+		"if":     TkIf,
+		"else":   TkElse,
+		"while":  TkWhile,
+		"return": TkReturn,
+		"break":  TkBreak,
+		"import": TkImport,
+		"export": TkExport,
+		"as":     TkAs,
+		"test":   TkTest,
+	},
+	Messages:     englishMessages,
+	Explanations: englishExplanations,
+}
@@ -0,0 +1,68 @@
+package blulang_test
+
+import (
+	"blulang"
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLocaleHeader(t *testing.T) {
+	interpreter := blulang.New(blulang.Options{})
+	result, err := interpreter.Run(context.Background(), "; lang: vi\ncho a = 2\nnếu a == 2 { đúng } hay { sai }")
+	assert.NoError(t, err)
+	assert.Equal(t, true, result.Value())
+
+	// English keywords are plain names in a Vietnamese file
+	result, err = interpreter.Run(context.Background(), "; lang: vi\ncho let = 5\nlet")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, result.Value())
+
+	_, err = interpreter.Run(context.Background(), "; lang: vi\ncho a = 3")
+	assert.EqualError(t, err, "lỗi thực thi: biến đã được khai báo: a")
+	_, err = interpreter.Run(context.Background(), "cho a = 3")
+	assert.EqualError(t, err, "runtime error: variable already defined: a")
+
+	_, err = interpreter.Run(context.Background(), "; lang: vi\nkiểm thử 5 { }")
+	assert.EqualError(t, err, "lỗi cú pháp tại 2:10: không mong đợi '5', cần tên bài kiểm thử")
+
+	_, err = interpreter.Run(context.Background(), "#!/usr/bin/env blulang\n; lang: xx\n1")
+	assert.EqualError(t, err, "syntax error at 2:1: unknown language: xx")
+}
+
+func TestInterpreterLocales(t *testing.T) {
+	var stdout bytes.Buffer
+	interpreter := blulang.New(blulang.Options{
+		Stdout:       &stdout,
+		Capabilities: blulang.CapIO,
+		Locales:      []*blulang.Locale{blulang.Vietnamese},
+	})
+	_, err := interpreter.Run(context.Background(), `cho a = 1 in(a)`)
+	assert.NoError(t, err)
+	assert.Equal(t, "1\n", stdout.String())
+	assert.Equal(t, blulang.VaNullVal, interpreter.Get("print").Kind())
+
+	_, err = interpreter.Run(context.Background(), `in(`)
+	assert.EqualError(t, err, "lỗi cú pháp tại 1:3: chương trình kết thúc đột ngột, cần ')'")
+}
+
+func TestRegisterLocale(t *testing.T) {
+	blulang.RegisterLocale(&blulang.Locale{
+		Name: "id",
+		Keywords: map[string]blulang.TokenType{
+			"biar": blulang.TkDeclareVar,
+			"jika": blulang.TkIf,
+			"lain": blulang.TkElse,
+		},
+		Builtins: map[string]string{"print": "cetak", "true": "benar"},
+	})
+	defer delete(blulang.Locales, "id")
+
+	var stdout bytes.Buffer
+	interpreter := blulang.New(blulang.Options{Stdout: &stdout, Capabilities: blulang.CapIO})
+	result, err := interpreter.Run(context.Background(), "; lang: id\nbiar x = 2\njika x == 2 { cetak(x) benar } lain { 0 }")
+	assert.NoError(t, err)
+	assert.Equal(t, true, result.Value())
+	assert.Equal(t, "2\n", stdout.String())
+}
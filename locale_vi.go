@@ -0,0 +1,330 @@
+package blulang
+
+// Vietnamese is the language pack of the Vietnamese keywords, builtins and error messages
+var Vietnamese = &Locale{
+	Name: "vi",
+	Keywords: map[string]TokenType{
+		"cho":       TkDeclareVar,
+		"hàm":       TkDeclareFunc,
+		"nếu":       TkIf,
+		"hay":       TkElse,
+		"khi":       TkWhile,
+		"trả":       TkReturn,
+		"nghỉ":      TkBreak,
+		"nhập khẩu": TkImport,
+		"xuất khẩu": TkExport,
+		"là":        TkAs,
+		"kiểm thử":  TkTest,
+	},
+	Builtins: map[string]string{
+		"true":        "đúng",
+		"false":       "sai",
+		"args":        "thamsố",
+		"count":       "đếm",
+		"print":       "in",
+		"printErr":    "inLỗi",
+		"input":       "nhập",
+		"readFile":    "đọcTệp",
+		"writeFile":   "ghiTệp",
+		"exit":        "thoát",
+		"now":         "bâyGiờ",
+		"sleep":       "ngủ",
+		"random":      "ngẫuNhiên",
+		"assert":      "khẳngĐịnh",
+		"assertEqual": "khẳngĐịnhBằng",
+	},
+	Messages:     vietnameseMessages,
+	Explanations: vietnameseExplanations,
+}
+
+var vietnameseMessages = map[MessageCode]string{
+	msgSyntaxError:  "lỗi cú pháp tại %v: %s",
+	msgRuntimeError: "lỗi thực thi: %s",
+
+	// what the parser expected
+	"module path":    "đường dẫn mô-đun",
+	"module alias":   "tên gọi của mô-đun",
+	"test name":      "tên bài kiểm thử",
+	"variable name":  "tên biến",
+	"parameter name": "tên tham số",
+	"property name":  "tên thuộc tính",
+	"an expression":  "một biểu thức",
+	"a variable or named function declaration": "một khai báo biến hoặc hàm có tên",
+
+	CodeUnexpectedEnd:         "chương trình kết thúc đột ngột",
+	CodeUnexpectedEndExpected: "chương trình kết thúc đột ngột, cần %s",
+	CodeUnexpectedToken:       "không mong đợi '%s', cần %s",
+	CodeNumberOutOfRange:      "số vượt quá giới hạn: %s",
+	CodeUnknownLanguage:       "ngôn ngữ không xác định: %s",
+	CodeUnterminatedString:    "chuỗi chưa được đóng, thiếu dấu '\"' ở cuối",
+	CodeNotAnExpression:       "cần một biểu thức không khai báo, gán, nhập, xuất, trả hay nghỉ",
+
+	CodeInvalidStatement:      "câu lệnh không hợp lệ: %v",
+	CodeNotAnObject:           "%s không phải là đối tượng",
+	CodeVariableDefined:       "biến đã được khai báo: %s",
+	CodeInvalidProperty:       "truy cập thuộc tính không hợp lệ: %v",
+	CodeArrayOperator:         "toán tử không dùng được cho mảng: %s",
+	CodeUnsupportedOperator:   "toán tử không được hỗ trợ: %s",
+	CodeDivisionByZero:        "chia cho số không",
+	CodeNotAFunction:          "%s không phải là hàm",
+	CodeNotAnArray:            "%s không phải là mảng",
+	CodeInvalidIndex:          "chỉ số %s không hợp lệ cho %s, một mảng có %d phần tử",
+	CodeExpectsOneArgument:    "%s cần 1 đối số nhưng nhận được %d",
+	CodeExpectsArguments:      "%s cần %d đối số nhưng nhận được %d",
+	CodeExpectsArgumentRange:  "%s cần từ %d đến %d đối số nhưng nhận được %d",
+	CodeExpectsAtLeast:        "%s cần ít nhất %d đối số nhưng nhận được %d",
+	CodeArgumentNotNumber:     "%s: đối số %d phải là một số, nhận được %v",
+	CodeArgumentNotInteger:    "%s: đối số %d phải là một số nguyên, nhận được %v",
+	CodeArgumentNotString:     "%s: đối số %d phải là một chuỗi, nhận được %v",
+	CodeArgumentNotArray:      "%s: đối số %d phải là một mảng, nhận được %v",
+	CodeArrayContainsItself:   "%s không thể chứa chính nó",
+	CodeMissingArguments:      "%s nhận %d đối số nhưng chỉ được truyền %d",
+	CodeStepLimit:             "vượt quá số bước cho phép",
+	CodeCallDepthLimit:        "vượt quá độ sâu gọi hàm cho phép",
+	CodeCollectionLimit:       "vượt quá kích thước tập hợp cho phép",
+	CodeStopped:               "chương trình bị dừng: %v",
+	CodeHostError:             "lỗi từ hệ thống: %v",
+	CodeNotComparable:         "không so sánh được hàm bằng %s",
+	CodeModuleNotFound:        "không tìm thấy mô-đun: %s",
+	CodeImportCycle:           "nhập khẩu vòng tròn: %s",
+	CodeImportNotAvailable:    "không thể nhập khẩu trong phạm vi này",
+	CodeImportNeedsFilesystem: "nhập khẩu %s cần quyền truy cập tệp",
+	CodeExportNotTopLevel:     "xuất khẩu chỉ được dùng ở cấp cao nhất của mô-đun",
+	CodeModuleError:           "%s: %v",
+	CodeTestNotTopLevel:       "kiểm thử chỉ được dùng ở cấp cao nhất của tệp",
+	CodeUnsupportedMapKey:     "kiểu khóa của map không được hỗ trợ: %v",
+	CodeUnsupportedGoType:     "kiểu Go không được hỗ trợ: %v",
+	CodeGoArgumentCount:       "cần %d đối số nhưng nhận được %d",
+	CodeGoArgument:            "đối số %d: %v",
+	CodeAssertionFailed:       "khẳng định sai",
+	CodeAssertionMessage:      "khẳng định sai: %s",
+	CodeNotEqual:              "hai giá trị không bằng nhau:\n%s",
+
+	CodeRandomLimit:      "giới hạn của random phải là số dương, nhận được %d",
+	CodeSqrtNegative:     "căn bậc hai của số âm: %v",
+	CodeInvalidLogBase:   "log: cơ số không hợp lệ %v",
+	CodeInvalidRadix:     "parseInt: cơ số phải từ 2 đến 36, nhận được %d",
+	CodeInvalidNumber:    "parseInt: %q không phải là số trong cơ số %d",
+	CodeEmptyArray:       "%s của một mảng rỗng",
+	CodeNotPositive:      "%s của một số không dương: %v",
+	CodeNegativeRepeat:   "repeat: số lần lặp không được âm, nhận được %d",
+	CodeMissingFormatArg: "format: thiếu đối số cho {%s}",
+	CodeIntegerOverflow:  "%s: kết quả vượt quá giới hạn của số nguyên",
+
+	CodeNoTargetKeyword:     "%s không có từ khóa cho '%s'",
+	CodeKeywordClash:        "'%s' là từ khóa trong %s, hãy đổi tên trước khi dịch",
+	CodeUnknownLintRule:     "quy tắc lint không xác định %q",
+	CodeInvalidLintConfig:   "cấu hình lint không hợp lệ: %v",
+	CodeReadFile:            "lỗi đọc tệp: %v",
+	CodeReadStdin:           "lỗi đọc đầu vào chuẩn: %v",
+	CodeUnknownLanguageFlag: "ngôn ngữ không xác định %q, cần một trong %s",
+	CodeUnknownCode:         "mã lỗi không xác định %s, chạy blulang explain để xem danh sách mã",
+	CodeWriteFile:           "lỗi ghi tệp: %v",
+	CodeNoDebugScript:       "blulang debug cần một tệp chương trình hoặc mã nguồn -e",
+	CodeNoTestFiles:         "không tìm thấy tệp kiểm thử nào trong %s",
+	CodeSlowerThanBaseline:  "chậm hơn kết quả gốc quá %g%%: %s",
+
+	CodeUnusedVariable:        "'%s' được khai báo nhưng không được dùng",
+	CodeShadowedBuiltin:       "'%s' che mất hàm có sẵn cùng tên",
+	CodeUndeclaredAssignment:  "'%s' được gán nhưng chưa được khai báo, phép gán không có tác dụng",
+	CodeUnreachableCode:       "mã phía sau '%s' không bao giờ chạy",
+	CodeUnusedIfValue:         "giá trị của câu nếu này không được dùng",
+	CodeAssignmentInCondition: "'%s' bị gán trong điều kiện, hãy dùng '==' để so sánh",
+}
+
+var vietnameseExplanations = map[MessageCode]string{
+	CodeUnexpectedEnd: `Chương trình kết thúc giữa chừng một câu lệnh.
+Hãy kiểm tra câu lệnh cuối cùng đã được viết đầy đủ chưa.`,
+	CodeUnexpectedEndExpected: `Chương trình kết thúc trong khi vẫn còn thiếu một phần, thường là dấu ngoặc đóng.
+Mỗi '(' cần một ')', mỗi '[' cần một ']' và mỗi '{' cần một '}':
+
+    hàm cộng(a, b) { a + b }`,
+	CodeUnexpectedToken: `Trình phân tích gặp một ký hiệu không được phép ở vị trí này, thông báo cho biết
+cần gì thay vào đó. Lỗi thường gặp là dùng từ khóa làm tên:
+
+    cho nếu = 1    ; 'nếu' là từ khóa
+    cho khiNào = 1 ; hợp lệ`,
+	CodeNumberOutOfRange: `Số nguyên quá lớn để lưu trong 64 bit, số nguyên lớn nhất là 9223372036854775807.
+Hãy viết số thực như 10000000000000000000.0 cho các số lớn hơn.`,
+	CodeUnknownLanguage: `Dòng lang ở đầu tệp chọn một ngôn ngữ chưa được đăng ký.
+Các ngôn ngữ có sẵn là en và vi:
+
+    ; lang: vi`,
+	CodeUnterminatedString: `Một chuỗi bắt đầu bằng '"' và kéo dài đến dấu '"' tiếp theo, nhưng dấu này bị thiếu.
+Dấu '"' bên trong chuỗi được viết là '\"'.
+
+    in("xin chào)     ; sai
+    in("xin chào")    ; in ra xin chào`,
+	CodeNotAnExpression: `Biểu thức theo dõi và điều kiện điểm dừng của trình gỡ lỗi là một biểu thức duy nhất,
+chỉ đọc các biến của chương trình mà không thay đổi chúng.
+
+    a[0] + 1    ; đọc a
+    a[0] = 1    ; sai, gán cho a`,
+	CodeInvalidStatement: `Trình thông dịch nhận một câu lệnh mà nó không biết cách thực thi.
+Đây là lỗi của trình thông dịch hoặc của mã Go tạo cây cú pháp.`,
+	CodeNotAnObject: `Thuộc tính được đọc bằng '.' trên một giá trị không phải đối tượng hay mô-đun.
+
+    cho điểm = { x: 1 }
+    điểm.x         ; 1
+    cho n = 1
+    n.x            ; n không phải là đối tượng`,
+	CodeVariableDefined: `Một biến hoặc hàm được khai báo hai lần trong cùng một phạm vi.
+Hãy khai báo một lần với cho và gán giá trị mới bằng '=':
+
+    cho a = 1
+    a = 2`,
+	CodeInvalidProperty: `Sau '.' chỉ được viết tên, và giữa '[' và ']' chỉ được viết giá trị.
+
+    người.tên
+    người["tên"]`,
+	CodeArrayOperator: `Mảng chỉ hỗ trợ '+' để nối hai mảng và '==' hoặc '!=' để so sánh.
+
+    [1, 2] + [3]   ; [1, 2, 3]`,
+	CodeUnsupportedOperator: `Toán tử không dùng được với các giá trị này, ví dụ '-' giữa hai chuỗi.
+Hãy chuyển đổi giá trị trước hoặc dùng một hàm của mô-đun string.`,
+	CodeDivisionByZero: `Một số nguyên bị chia cho số không.
+Hãy kiểm tra số chia trước khi chia, hoặc chia số thực để nhận vô cực:
+
+    nếu b != 0 { a / b }`,
+	CodeNotAFunction: `Một giá trị không phải hàm được gọi bằng '(' và ')'.
+Hãy kiểm tra chính tả của tên và tên đó đã được khai báo bằng hàm chưa.`,
+	CodeNotAnArray: `Một giá trị không phải mảng được truy cập bằng '[' và ']'.
+Hãy kiểm tra biến có chứa mảng không, đối tượng được đọc bằng '.':
+
+    cho a = [1, 2]
+    a[0]   ; 1`,
+	CodeInvalidIndex: `Chỉ số của mảng phải là số nguyên từ 0 đến số phần tử trừ một.
+Hãy so sánh chỉ số với đếm trước khi đọc hoặc gán phần tử:
+
+    nếu i < đếm(a) { a[i] }`,
+	CodeExpectsOneArgument: `Hàm có sẵn này nhận đúng một đối số.`,
+	CodeExpectsArguments:   `Hàm có sẵn này nhận đúng số đối số được nêu.`,
+	CodeExpectsArgumentRange: `Hàm có sẵn này nhận số đối số trong khoảng được nêu,
+các đối số cuối có thể bỏ qua.`,
+	CodeExpectsAtLeast: `Hàm có sẵn này nhận bao nhiêu đối số cũng được, tối thiểu là số được nêu.`,
+	CodeArgumentNotNumber: `Đối số phải là số nguyên hoặc số thực.
+Chữ đọc bằng nhập có thể được đổi thành số bằng math.parseInt.`,
+	CodeArgumentNotInteger: `Đối số phải là số nguyên, số thực không được chấp nhận.
+Dùng math.floor, math.round hoặc math.trunc để đổi số thực thành số nguyên.`,
+	CodeArgumentNotString: `Đối số phải là một chuỗi viết trong dấu ngoặc kép.`,
+	CodeArgumentNotArray:  `Đối số phải là một mảng như [1, 2, 3].`,
+	CodeArrayContainsItself: `Một mảng được gán vào một phần tử của chính nó, trực tiếp hoặc qua
+các mảng và đối tượng mà giá trị chứa. Mảng như vậy không thể được in hay so sánh.
+Hãy gán một bản sao tạo bằng '+':
+
+    a[0] = [] + a`,
+	CodeMissingArguments: `Một hàm được gọi với ít đối số hơn số tham số của nó.
+Hãy truyền một giá trị cho mỗi tham số, các đối số thừa bị bỏ qua:
+
+    hàm diệnTích(r, c) { r * c }
+    diệnTích(2, 3)`,
+	CodeStepLimit: `Chương trình thực thi nhiều câu lệnh hơn mức cho phép, thường là do một vòng lặp
+không bao giờ kết thúc. Hãy kiểm tra điều kiện của mỗi vòng khi.`,
+	CodeCallDepthLimit: `Các hàm gọi nhau quá sâu, thường là do một hàm đệ quy không bao giờ
+đến được trường hợp dừng:
+
+    hàm đếmNgược(n) {
+        nếu n == 0 { 0 } hay { đếmNgược(n - 1) }
+    }`,
+	CodeCollectionLimit: `Một mảng, đối tượng hoặc chuỗi lớn hơn mức cho phép.`,
+	CodeStopped:         `Chương trình bị dừng vì chạy quá thời gian cho phép hoặc vì bị hủy.`,
+	CodeHostError: `Một hàm của hệ thống bị lỗi, ví dụ đọcTệp với một tệp không tồn tại.
+Thông báo đến từ hệ thống.`,
+	CodeNotComparable: `'==' và '!=' so sánh số, chuỗi, giá trị đúng sai, null, và mảng, đối tượng theo từng
+phần tử, nhưng không so sánh được hàm. Hãy so sánh giá trị mà các hàm trả về:
+
+    f(1) == g(1)`,
+	CodeModuleNotFound: `Không tìm thấy tệp được nhập khẩu cạnh tệp đang nhập khẩu hay trong các thư mục
+của BLU_PATH. Các mô-đun có sẵn như "math" và "string" không cần tệp.`,
+	CodeImportCycle: `Các mô-đun nhập khẩu lẫn nhau thành vòng tròn nên không thể thực thi.
+Hãy chuyển các khai báo dùng chung sang một mô-đun thứ ba.`,
+	CodeImportNotAvailable: `Lệnh nhập khẩu được dùng ở nơi không thể tải mô-đun, như trong một phạm vi
+do mã Go tạo ra mà không có phạm vi toàn cục.`,
+	CodeImportNeedsFilesystem: `Nhập khẩu một tệp cần đọc ổ đĩa, điều mà chương trình chủ không cho phép.
+Các mô-đun có sẵn như "math" vẫn nhập khẩu được. Trên dòng lệnh, hãy bỏ -sandbox.`,
+	CodeExportNotTopLevel: `xuất khẩu chỉ đánh dấu được các khai báo ở cấp cao nhất của tệp, không phải trong
+hàm, vòng lặp hay câu điều kiện.`,
+	CodeModuleError: `Một mô-đun được nhập khẩu bị lỗi, phần còn lại của thông báo là lỗi của mô-đun đó.`,
+	CodeTestNotTopLevel: `Các khối kiểm thử chỉ viết được ở cấp cao nhất của tệp, không phải trong
+hàm, vòng lặp hay câu điều kiện.`,
+	CodeUnsupportedMapKey: `Map của Go đưa vào trình thông dịch phải có khóa là chuỗi.`,
+	CodeUnsupportedGoType: `Một giá trị Go đưa vào trình thông dịch có kiểu không chuyển đổi được,
+như channel.`,
+	CodeGoArgumentCount: `Một hàm Go đăng ký bằng RegisterFunc được gọi với sai số đối số.`,
+	CodeGoArgument: `Một đối số của hàm Go đăng ký bằng RegisterFunc không chuyển đổi được
+sang kiểu của tham số.`,
+	CodeAssertionFailed: `Điều kiện đưa vào khẳngĐịnh là sai nên bài kiểm thử thất bại.
+Đối số thứ hai mô tả điều gì sai:
+
+    khẳngĐịnh(đếm(items) > 0, "items rỗng")`,
+	CodeAssertionMessage: `Điều kiện đưa vào khẳngĐịnh là sai, thông báo là thông báo đã đưa vào khẳngĐịnh.`,
+	CodeNotEqual: `Hai giá trị đưa vào khẳngĐịnhBằng khác nhau, giá trị thực tế trước và giá trị
+mong đợi sau. Mỗi dòng nêu chỉ số hoặc thuộc tính khác nhau:
+
+    khẳngĐịnhBằng([1, 2], [1, 3])   ; [1]: expected 3, got 2`,
+	CodeRandomLimit:    `ngẫuNhiên(n) trả về một số từ 0 đến trước n, nên n phải ít nhất là 1.`,
+	CodeSqrtNegative:   `Căn bậc hai của số âm không phải là số thực.`,
+	CodeInvalidLogBase: `Cơ số của lôgarit phải dương và khác 1.`,
+	CodeInvalidRadix:   `math.parseInt đọc số viết trong cơ số từ 2 đến 36.`,
+	CodeInvalidNumber: `Chuỗi không phải là số trong cơ số đã cho.
+
+    math.parseInt("ff", 16)   ; 255`,
+	CodeEmptyArray:     `min và max cần ít nhất một giá trị.`,
+	CodeNotPositive:    `Lôgarit chỉ được định nghĩa cho số dương.`,
+	CodeNegativeRepeat: `Một chuỗi có thể được lặp lại không hoặc nhiều lần, không thể lặp một số âm lần.`,
+	CodeMissingFormatArg: `string.format có nhiều chỗ trống hơn số đối số.
+'{}' lấy đối số tiếp theo và '{n}' lấy đối số ở vị trí n, bắt đầu từ 0:
+
+    string.format("{} + {} = {2}", 1, 2, 3)`,
+	CodeIntegerOverflow: `Kết quả nguyên nằm ngoài khoảng từ math.minInt đến math.maxInt.
+Hãy truyền một số thực để tính kết quả dưới dạng số thực:
+
+    math.pow(3.0, 50)`,
+	CodeNoTargetKeyword: `Ngôn ngữ đích không có từ khóa cho một cấu trúc mà chương trình dùng.`,
+	CodeUnknownLintRule: `Cấu hình lint dùng tên một quy tắc không tồn tại.
+blulang lint -rules liệt kê các quy tắc.`,
+	CodeInvalidLintConfig: `Cấu hình lint không phải JSON hợp lệ. Cấu hình bật hoặc tắt quy tắc theo tên:
+
+    { "rules": { "unused-variable": false } }`,
+
+	CodeUnusedVariable: `Một biến được khai báo bằng cho nhưng giá trị của nó không bao giờ được đọc.
+Hãy xóa nó, hoặc tắt quy tắc cho một dòng bằng chú thích:
+
+    cho thừa = 1 ; lint-disable-line unused-variable`,
+	CodeShadowedBuiltin: `Một khai báo che mất một hàm có sẵn.
+Một biến, hàm, tham số hoặc tên module trùng tên với một hàm có sẵn như in,
+nên không gọi được hàm có sẵn ở nơi tên này được nhìn thấy.`,
+	CodeUndeclaredAssignment: `Một giá trị được gán cho một biến chưa bao giờ được khai báo.
+Phép gán bị bỏ qua, hãy khai báo biến bằng cho trước:
+
+    đếmSố = 1       ; bị bỏ qua
+    cho tổng = 0
+    tổng = 1        ; đúng`,
+	CodeUnreachableCode: `Các câu lệnh sau trả hoặc nghỉ trong cùng một khối không bao giờ chạy.`,
+	CodeUnusedIfValue: `Giá trị do một câu nếu tính ra bị mất.
+Câu nếu chỉ tính ra một giá trị nhưng không được gán và không phải câu lệnh cuối của khối:
+
+    nếu a > 1 { 1 } hay { 2 }             ; bị mất
+    cho b = nếu a > 1 { 1 } hay { 2 }     ; được giữ`,
+	CodeAssignmentInCondition: `Một điều kiện gán giá trị bằng '=' thay vì so sánh bằng '=='.
+
+    nếu a = 1 { ... }              ; gán 1 cho a
+    khi a == 1 || b = 2 { ... }    ; gán 2 cho b`,
+	CodeKeywordClash: `Một tên do chương trình đặt là từ khóa trong ngôn ngữ đích nên chương trình
+sau khi dịch sẽ không đọc lại được. Hãy đổi tên trước khi dịch.`,
+	CodeReadFile:            `Không đọc được tệp chương trình, hãy kiểm tra đường dẫn và quyền truy cập.`,
+	CodeReadStdin:           `Không đọc được chương trình từ đầu vào chuẩn.`,
+	CodeUnknownLanguageFlag: `Ngôn ngữ được chọn bằng -lang, -to hoặc BLU_LANG chưa được đăng ký.`,
+	CodeUnknownCode:         `Mã được đưa cho blulang explain không có trong danh mục.`,
+	CodeWriteFile:           `Không ghi được chương trình đã định dạng vào tệp, hãy kiểm tra quyền ghi của tệp.`,
+	CodeNoDebugScript: `Trình gỡ lỗi đọc lệnh từ stdin, nên chương trình không thể đến từ stdin.
+Hãy đưa tệp cần gỡ lỗi, hoặc mã nguồn với -e:
+
+    blulang debug main.blu
+    blulang debug -e "cho a = 1"`,
+	CodeNoTestFiles: `blulang test chạy các tệp có tên kết thúc bằng _test.blu, trong các thư mục
+được đưa vào và thư mục con của chúng, hoặc thư mục hiện tại nếu không có.`,
+	CodeSlowerThanBaseline: `blulang bench -baseline so sánh thời gian chạy của từng chương trình với thời gian lưu
+trong tệp và báo lỗi khi nó tăng quá tỉ lệ phần trăm -threshold. Hãy chạy lại để loại trừ
+trường hợp máy đang bận, hoặc lưu kết quả gốc mới bằng -save khi thay đổi được chấp nhận.`,
+}
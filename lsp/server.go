@@ -0,0 +1,431 @@
+// Package lsp is a language server for BluLang speaking the language server protocol over
+// a pair of streams, so editors such as VS Code and Neovim can check and navigate .blu files.
+package lsp
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"blulang"
+	"blulang/internal/jsonrpc"
+)
+
+// Options configure the diagnostics of a server
+type Options struct {
+	// Language forces the language of diagnostics, by default they are in the language of each file
+	Language *blulang.Locale
+	// Lint turns the rules of the linter on and off
+	Lint blulang.LintConfig
+}
+
+// Server answers the requests of one editor about the files it has open
+type Server struct {
+	in        *bufio.Reader
+	out       io.Writer
+	options   Options
+	documents map[string]*document
+	shutdown  bool
+}
+
+// ErrNoShutdown is returned by Run when the editor exits without asking the server to shut down
+var ErrNoShutdown = errors.New("lsp: exit without shutdown")
+
+// NewServer creates a server reading messages from in and writing to out, usually stdin and stdout
+func NewServer(in io.Reader, out io.Writer, options Options) *Server {
+	return &Server{in: bufio.NewReader(in), out: out, options: options, documents: make(map[string]*document)}
+}
+
+// Run answers messages until the editor sends exit or closes the input
+func (s *Server) Run() error {
+	for {
+		content, err := jsonrpc.ReadMessage(s.in)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var message request
+		if err := json.Unmarshal(content, &message); err != nil {
+			return fmt.Errorf("lsp: invalid message: %w", err)
+		}
+		if message.Method == "exit" {
+			if !s.shutdown {
+				return ErrNoShutdown
+			}
+			return nil
+		}
+		if err := s.handle(message); err != nil {
+			return err
+		}
+	}
+}
+
+// handle answers a request, notifications get no answer
+func (s *Server) handle(message request) error {
+	result, err := s.dispatch(message)
+	if message.ID == nil {
+		return nil
+	}
+	if err != nil {
+		var failure responseError
+		if !errors.As(err, &failure) {
+			failure = responseError{Code: codeInternalError, Message: err.Error()}
+		}
+		return jsonrpc.WriteMessage(s.out, errorResponse{JSONRPC: "2.0", ID: message.ID, Error: failure})
+	}
+	return jsonrpc.WriteMessage(s.out, response{JSONRPC: "2.0", ID: message.ID, Result: result})
+}
+
+func (s *Server) dispatch(message request) (result any, err error) {
+	defer func() {
+		// a failing request must not stop the editor from using the others
+		if r := recover(); r != nil {
+			err = responseError{Code: codeInternalError, Message: fmt.Sprint(r)}
+		}
+	}()
+	if s.shutdown && message.ID != nil {
+		return nil, responseError{Code: codeInvalidRequest, Message: "the server is shut down"}
+	}
+	switch message.Method {
+	case "initialize":
+		return s.initialize(), nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		return nil, withParams(message, s.didOpen)
+	case "textDocument/didChange":
+		return nil, withParams(message, s.didChange)
+	case "textDocument/didClose":
+		return nil, withParams(message, s.didClose)
+	case "textDocument/definition":
+		return withResult(message, s.definition)
+	case "textDocument/references":
+		return withResult(message, s.references)
+	case "textDocument/hover":
+		return withResult(message, s.hover)
+	case "textDocument/documentSymbol":
+		return withResult(message, s.documentSymbol)
+	case "textDocument/completion":
+		return withResult(message, s.completion)
+	case "textDocument/formatting":
+		return withResult(message, s.formatting)
+	}
+	if message.ID != nil {
+		return nil, responseError{Code: codeMethodNotFound, Message: "method not supported: " + message.Method}
+	}
+	// other notifications such as initialized and $/cancelRequest need nothing
+	return nil, nil
+}
+
+func decodeParams[P any](message request) (P, error) {
+	var params P
+	if err := json.Unmarshal(message.Params, &params); err != nil {
+		return params, responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return params, nil
+}
+
+func withParams[P any](message request, handler func(P) error) error {
+	params, err := decodeParams[P](message)
+	if err != nil {
+		return err
+	}
+	return handler(params)
+}
+
+func withResult[P any](message request, handler func(P) any) (any, error) {
+	params, err := decodeParams[P](message)
+	if err != nil {
+		return nil, err
+	}
+	return handler(params), nil
+}
+
+func (s *Server) initialize() any {
+	return map[string]any{
+		"capabilities": map[string]any{
+			// changes are sent as edits of ranges
+			"textDocumentSync":           map[string]any{"openClose": true, "change": 2},
+			"definitionProvider":         true,
+			"referencesProvider":         true,
+			"hoverProvider":              true,
+			"documentSymbolProvider":     true,
+			"completionProvider":         map[string]any{},
+			"documentFormattingProvider": true,
+		},
+		"serverInfo": map[string]any{"name": "blulang"},
+	}
+}
+
+func (s *Server) didOpen(params didOpenParams) error {
+	document := &document{uri: params.TextDocument.URI}
+	document.update(params.TextDocument.Text, 0)
+	s.documents[document.uri] = document
+	return s.publishDiagnostics(document)
+}
+
+func (s *Server) didChange(params didChangeParams) error {
+	document, found := s.documents[params.TextDocument.URI]
+	if !found {
+		return nil
+	}
+	text := document.text
+	edit := len(text)
+	for _, change := range params.ContentChanges {
+		if change.Range == nil {
+			text, edit = change.Text, 0
+			continue
+		}
+		lines := strings.Split(text, "\n")
+		start, end := byteOffset(lines, change.Range.Start), byteOffset(lines, change.Range.End)
+		text = text[:start] + change.Text + text[max(start, end):]
+		// every change only alters the text from its start on
+		edit = min(edit, start)
+	}
+	document.update(text, edit)
+	return s.publishDiagnostics(document)
+}
+
+func (s *Server) didClose(params textDocumentParams) error {
+	delete(s.documents, params.TextDocument.URI)
+	return jsonrpc.WriteMessage(s.out, notification{
+		JSONRPC: "2.0",
+		Method:  "textDocument/publishDiagnostics",
+		Params:  publishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []diagnostic{}},
+	})
+}
+
+// publishDiagnostics sends the syntax errors of a document, or what the linter
+// reports once it parses
+func (s *Server) publishDiagnostics(document *document) error {
+	locale := cmp.Or(s.options.Language, document.locale)
+	diagnostics := []diagnostic{}
+	for _, err := range document.errs {
+		err := blulang.LocalizeError(err, locale).(blulang.SyntaxError)
+		diagnostics = append(diagnostics, diagnostic{
+			Range:    document.wordRange(err.Pos),
+			Severity: severityError,
+			Code:     string(err.Code),
+			Source:   "blulang",
+			Message:  err.Message,
+		})
+	}
+	// names used in the statements that don't parse would look unused
+	if len(document.errs) == 0 {
+		for _, problem := range document.analysis.Lint(s.options.Lint) {
+			problem = problem.Localize(locale)
+			diagnostics = append(diagnostics, diagnostic{
+				Range:    document.wordRange(problem.Pos),
+				Severity: severityWarning,
+				Code:     string(problem.Rule),
+				Source:   "blulang lint",
+				Message:  problem.Message,
+			})
+		}
+	}
+	return jsonrpc.WriteMessage(s.out, notification{
+		JSONRPC: "2.0",
+		Method:  "textDocument/publishDiagnostics",
+		Params:  publishDiagnosticsParams{URI: document.uri, Diagnostics: diagnostics},
+	})
+}
+
+func (s *Server) definition(params positionParams) any {
+	document, found := s.documents[params.TextDocument.URI]
+	if !found {
+		return nil
+	}
+	pos, found := document.analysis.Definition(document.position(params.Position))
+	if !found {
+		return nil
+	}
+	return location{URI: document.uri, Range: document.wordRange(pos)}
+}
+
+func (s *Server) references(params referenceParams) any {
+	document, found := s.documents[params.TextDocument.URI]
+	if !found {
+		return nil
+	}
+	pos := document.position(params.Position)
+	declaration, declared := document.analysis.Definition(pos)
+	locations := []location{}
+	for _, reference := range document.analysis.References(pos) {
+		if declared && reference == declaration && !params.Context.IncludeDeclaration {
+			continue
+		}
+		locations = append(locations, location{URI: document.uri, Range: document.wordRange(reference)})
+	}
+	return locations
+}
+
+func (s *Server) hover(params positionParams) any {
+	document, found := s.documents[params.TextDocument.URI]
+	if !found {
+		return nil
+	}
+	pos := document.position(params.Position)
+	description, found := document.analysis.Hover(pos)
+	if !found {
+		return nil
+	}
+	return hover{
+		Contents: markupContent{Kind: "plaintext", Value: description},
+		Range:    document.wordRange(document.wordStart(pos)),
+	}
+}
+
+func (s *Server) documentSymbol(params textDocumentParams) any {
+	document, found := s.documents[params.TextDocument.URI]
+	if !found {
+		return nil
+	}
+	symbols := []documentSymbol{}
+	for _, symbol := range document.analysis.Symbols() {
+		symbols = append(symbols, documentSymbol{
+			Name:           symbol.Name,
+			Detail:         symbol.Detail,
+			Kind:           symbolFunction,
+			Range:          textRange{Start: document.lspPosition(symbol.Start), End: document.lspPosition(symbol.End)},
+			SelectionRange: document.wordRange(symbol.Pos),
+		})
+	}
+	return symbols
+}
+
+func (s *Server) completion(params positionParams) any {
+	document, found := s.documents[params.TextDocument.URI]
+	if !found {
+		return nil
+	}
+	items := []completionItem{}
+	for _, completion := range document.analysis.Completions() {
+		kind := completionVariable
+		switch {
+		case completion.Kind == blulang.CompleteKeyword:
+			kind = completionKeyword
+		case completion.Kind == blulang.CompleteFunction:
+			kind = completionFunction
+		case completion.Kind == blulang.CompleteBuiltin && completion.Detail == string(blulang.VaNativeFuncVal):
+			kind = completionFunction
+		case completion.Kind == blulang.CompleteBuiltin:
+			kind = completionConstant
+		}
+		items = append(items, completionItem{Label: completion.Label, Kind: kind, Detail: completion.Detail})
+	}
+	return items
+}
+
+func (s *Server) formatting(params textDocumentParams) any {
+	document, found := s.documents[params.TextDocument.URI]
+	if !found {
+		return nil
+	}
+	formatted, err := blulang.Format(document.text)
+	if err != nil || formatted == document.text {
+		// a file with syntax errors is left as it is, the diagnostics tell why
+		return []textEdit{}
+	}
+	last := len(document.lines) - 1
+	return []textEdit{{
+		Range:   textRange{End: position{Line: last, Character: utf16Length(document.lines[last])}},
+		NewText: formatted,
+	}}
+}
+
+// document is a file open in the editor, parsed again on every change
+type document struct {
+	uri   string
+	text  string
+	lines []string
+	// program holds the statements that parse, errs are the syntax errors of the others
+	program  blulang.Program
+	errs     []blulang.SyntaxError
+	analysis *blulang.Analysis
+	// locale is the language of the lang header or the default one
+	locale *blulang.Locale
+}
+
+// update parses a new text of the document, which is the same as the previous one before
+// the byte offset edit so the statements before it are kept
+func (d *document) update(text string, edit int) {
+	parser := blulang.NewParser()
+	edit = len([]rune(text[:min(edit, len(text))]))
+	d.program, d.errs = parser.Reparse(d.program, text, edit)
+	d.text, d.lines, d.locale = text, strings.Split(text, "\n"), parser.Locale()
+	d.analysis = blulang.Analyze(d.program)
+}
+
+// position converts a position of the editor to one of the source
+func (d *document) position(pos position) blulang.Position {
+	column := 0
+	if pos.Line < len(d.lines) {
+		column = runeCount(d.lines[pos.Line], pos.Character)
+	}
+	return blulang.Position{Line: pos.Line + 1, Column: column + 1}
+}
+
+// lspPosition converts a position of the source to one of the editor
+func (d *document) lspPosition(pos blulang.Position) position {
+	line := pos.Line - 1
+	if line < 0 || line >= len(d.lines) {
+		return position{Line: max(line, 0)}
+	}
+	runes := []rune(d.lines[line])
+	return position{Line: line, Character: utf16Length(string(runes[:min(pos.Column-1, len(runes))]))}
+}
+
+// wordStart is the position of the first letter of the name at a position
+func (d *document) wordStart(pos blulang.Position) blulang.Position {
+	if pos.Line-1 >= len(d.lines) {
+		return pos
+	}
+	runes := []rune(d.lines[pos.Line-1])
+	column := min(pos.Column-1, len(runes))
+	for column > 0 && isWordPart(runes[column-1]) {
+		column--
+	}
+	return blulang.Position{Line: pos.Line, Column: column + 1}
+}
+
+// wordRange spans the name starting at a position, or the letter there when it isn't a name
+func (d *document) wordRange(pos blulang.Position) textRange {
+	end := blulang.Position{Line: pos.Line, Column: pos.Column + 1}
+	if pos.Line-1 < len(d.lines) {
+		runes := []rune(d.lines[pos.Line-1])
+		column := pos.Column - 1
+		for column < len(runes) && isWordPart(runes[column]) {
+			column++
+		}
+		if column > pos.Column-1 {
+			end.Column = column + 1
+		}
+	}
+	return textRange{Start: d.lspPosition(pos), End: d.lspPosition(end)}
+}
+
+func isWordPart(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) || r == '_'
+}
+
+// byteOffset is the offset in the text of a position of the editor
+func byteOffset(lines []string, pos position) int {
+	offset := 0
+	for line := 0; line < pos.Line && line < len(lines); line++ {
+		offset += len(lines[line]) + 1
+	}
+	if pos.Line >= len(lines) {
+		return max(offset-1, 0)
+	}
+	line := lines[pos.Line]
+	runes := runeCount(line, pos.Character)
+	return offset + len(string([]rune(line)[:min(runes, len([]rune(line)))]))
+}
@@ -0,0 +1,212 @@
+package lsp_test
+
+import (
+	"blulang"
+	"blulang/lsp"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/textproto"
+	"strconv"
+	"testing"
+)
+
+const uri = "file:///tmp/main.blu"
+
+// session runs a server over the given messages and returns what it wrote
+func session(t *testing.T, options lsp.Options, messages ...map[string]any) []map[string]any {
+	var in bytes.Buffer
+	for _, message := range messages {
+		message["jsonrpc"] = "2.0"
+		content, err := json.Marshal(message)
+		assert.NoError(t, err)
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(content), content)
+	}
+	var out bytes.Buffer
+	assert.NoError(t, lsp.NewServer(&in, &out, options).Run())
+
+	var written []map[string]any
+	reader := bufio.NewReader(&out)
+	for {
+		header, err := textproto.NewReader(reader).ReadMIMEHeader()
+		if err == io.EOF {
+			return written
+		}
+		assert.NoError(t, err)
+		length, _ := strconv.Atoi(header.Get("Content-Length"))
+		content := make([]byte, length)
+		_, err = io.ReadFull(reader, content)
+		assert.NoError(t, err)
+		var message map[string]any
+		assert.NoError(t, json.Unmarshal(content, &message))
+		written = append(written, message)
+	}
+}
+
+func open(text string) map[string]any {
+	return map[string]any{"method": "textDocument/didOpen", "params": map[string]any{
+		"textDocument": map[string]any{"uri": uri, "languageId": "blulang", "version": 1, "text": text},
+	}}
+}
+
+func change(line, character, endLine, endCharacter int, text string) map[string]any {
+	return map[string]any{"method": "textDocument/didChange", "params": map[string]any{
+		"textDocument": map[string]any{"uri": uri, "version": 2},
+		"contentChanges": []any{map[string]any{
+			"range": map[string]any{
+				"start": map[string]any{"line": line, "character": character},
+				"end":   map[string]any{"line": endLine, "character": endCharacter},
+			},
+			"text": text,
+		}},
+	}}
+}
+
+func at(id int, method string, line, character int) map[string]any {
+	return map[string]any{"id": id, "method": method, "params": map[string]any{
+		"textDocument": map[string]any{"uri": uri},
+		"position":     map[string]any{"line": line, "character": character},
+		"context":      map[string]any{"includeDeclaration": true},
+	}}
+}
+
+// toJSON gives the JSON form of an expected value, the one of decoded messages
+func toJSON(t *testing.T, value any) any {
+	content, err := json.Marshal(value)
+	assert.NoError(t, err)
+	var decoded any
+	assert.NoError(t, json.Unmarshal(content, &decoded))
+	return decoded
+}
+
+func span(line, start, end int) map[string]any {
+	return map[string]any{
+		"start": map[string]any{"line": line, "character": start},
+		"end":   map[string]any{"line": line, "character": end},
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	written := session(t, lsp.Options{},
+		map[string]any{"id": 1, "method": "initialize", "params": map[string]any{}},
+		map[string]any{"method": "initialized", "params": map[string]any{}},
+		map[string]any{"id": 2, "method": "workspace/symbol", "params": map[string]any{}},
+		map[string]any{"id": 3, "method": "shutdown"},
+		map[string]any{"method": "exit"},
+	)
+	assert.Len(t, written, 3)
+	capabilities := written[0]["result"].(map[string]any)["capabilities"].(map[string]any)
+	assert.Equal(t, true, capabilities["definitionProvider"])
+	assert.Equal(t, float64(2), capabilities["textDocumentSync"].(map[string]any)["change"])
+	assert.Equal(t, float64(-32601), written[1]["error"].(map[string]any)["code"])
+	assert.Equal(t, map[string]any{"jsonrpc": "2.0", "id": float64(3), "result": nil}, written[2])
+
+	var out bytes.Buffer
+	exit := `{"jsonrpc":"2.0","method":"exit"}`
+	in := bytes.NewBufferString(fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(exit), exit))
+	assert.ErrorIs(t, lsp.NewServer(in, &out, lsp.Options{}).Run(), lsp.ErrNoShutdown)
+}
+
+func TestDiagnostics(t *testing.T) {
+	written := session(t, lsp.Options{},
+		open("let a = 1\nlet = 2\nprint(a)"),
+		// the error is fixed by typing the name
+		change(1, 4, 1, 4, "b "),
+		map[string]any{"method": "textDocument/didClose", "params": map[string]any{"textDocument": map[string]any{"uri": uri}}},
+		open("; lang: vi\ncho a = 1"),
+	)
+	assert.Len(t, written, 4)
+	assert.Equal(t, toJSON(t, []any{map[string]any{
+		"range": span(1, 4, 5), "severity": 1, "code": "E0003", "source": "blulang",
+		"message": "unexpected '=', expected variable name",
+	}}), written[0]["params"].(map[string]any)["diagnostics"])
+	assert.Equal(t, toJSON(t, []any{map[string]any{
+		"range": span(1, 4, 5), "severity": 2, "code": "unused-variable", "source": "blulang lint",
+		"message": "'b' is declared but never used",
+	}}), written[1]["params"].(map[string]any)["diagnostics"])
+	assert.Equal(t, []any{}, written[2]["params"].(map[string]any)["diagnostics"])
+	// a Vietnamese file gets Vietnamese messages
+	diagnostics := written[3]["params"].(map[string]any)["diagnostics"].([]any)
+	assert.Len(t, diagnostics, 1)
+	assert.Equal(t, blulang.Localize(blulang.Vietnamese, blulang.CodeUnusedVariable, "a"), diagnostics[0].(map[string]any)["message"])
+}
+
+func TestNavigation(t *testing.T) {
+	source := "; cộng hai số\nfn cộng(a, b) {\n    a + b\n}\nlet tổng = cộng(1, 2)\nin(tổng)\n"
+	written := session(t, lsp.Options{},
+		open(source),
+		at(1, "textDocument/definition", 4, 13),
+		at(2, "textDocument/references", 1, 3),
+		at(3, "textDocument/hover", 5, 1),
+		at(4, "textDocument/hover", 1, 5),
+		map[string]any{"id": 5, "method": "textDocument/documentSymbol", "params": map[string]any{"textDocument": map[string]any{"uri": uri}}},
+		at(6, "textDocument/definition", 0, 3),
+	)
+	assert.Len(t, written, 7)
+	assert.Equal(t, toJSON(t, map[string]any{"uri": uri, "range": span(1, 3, 7)}), written[1]["result"])
+	assert.Equal(t, toJSON(t, []any{
+		map[string]any{"uri": uri, "range": span(1, 3, 7)},
+		map[string]any{"uri": uri, "range": span(4, 11, 15)},
+	}), written[2]["result"])
+	assert.Equal(t, toJSON(t, map[string]any{
+		"contents": map[string]any{"kind": "plaintext", "value": "in (print) :: NativeFuncVal"},
+		"range":    span(5, 0, 2),
+	}), written[3]["result"])
+	assert.Equal(t, "fn cộng(a, b)\n\ncộng hai số", written[4]["result"].(map[string]any)["contents"].(map[string]any)["value"])
+	assert.Equal(t, toJSON(t, []any{map[string]any{
+		"name": "cộng", "detail": "fn cộng(a, b)", "kind": 12,
+		"range":          map[string]any{"start": map[string]any{"line": 1, "character": 0}, "end": map[string]any{"line": 3, "character": 1}},
+		"selectionRange": span(1, 3, 7),
+	}}), written[5]["result"])
+	assert.Nil(t, written[6]["result"])
+}
+
+func TestCompletionAndFormatting(t *testing.T) {
+	written := session(t, lsp.Options{},
+		open("let   a=[1,2]\nhàm f(x){x}"),
+		at(1, "textDocument/completion", 1, 0),
+		map[string]any{"id": 2, "method": "textDocument/formatting", "params": map[string]any{
+			"textDocument": map[string]any{"uri": uri}, "options": map[string]any{"tabSize": 4, "insertSpaces": true},
+		}},
+		// unbalanced braces can't be formatted
+		change(1, 11, 1, 11, "{"),
+		map[string]any{"id": 3, "method": "textDocument/formatting", "params": map[string]any{"textDocument": map[string]any{"uri": uri}}},
+	)
+	assert.Len(t, written, 5)
+	kinds := make(map[string]float64)
+	for _, item := range written[1]["result"].([]any) {
+		item := item.(map[string]any)
+		kinds[item["label"].(string)] = item["kind"].(float64)
+	}
+	assert.Equal(t, float64(14), kinds["while"])
+	assert.Equal(t, float64(14), kinds["khi"])
+	assert.Equal(t, float64(3), kinds["print"])
+	assert.Equal(t, float64(21), kinds["true"])
+	assert.Equal(t, float64(3), kinds["f"])
+	assert.Equal(t, float64(6), kinds["a"])
+
+	assert.Equal(t, toJSON(t, []any{map[string]any{
+		"range":   map[string]any{"start": map[string]any{"line": 0, "character": 0}, "end": map[string]any{"line": 1, "character": 11}},
+		"newText": "let a = [1, 2]\nhàm f(x) {\n    x\n}\n",
+	}}), written[2]["result"])
+	assert.Equal(t, []any{}, written[4]["result"])
+}
+
+func TestIncrementalChanges(t *testing.T) {
+	written := session(t, lsp.Options{},
+		open("let a = 1\nlet b = 2\nprint(a + b)"),
+		// typing an unfinished statement keeps the others resolved
+		change(1, 9, 1, 9, "\nlet c = ("),
+		at(1, "textDocument/definition", 3, 10),
+		change(2, 9, 2, 9, "a)"),
+		at(2, "textDocument/references", 0, 4),
+	)
+	assert.Len(t, written, 5)
+	assert.Len(t, written[1]["params"].(map[string]any)["diagnostics"], 1)
+	assert.Equal(t, toJSON(t, map[string]any{"uri": uri, "range": span(1, 4, 5)}), written[2]["result"])
+	assert.Len(t, written[3]["params"].(map[string]any)["diagnostics"], 1, "only c is unused")
+	assert.Len(t, written[4]["result"], 3)
+}
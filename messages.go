@@ -0,0 +1,447 @@
+package blulang
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MessageCode identifies a diagnostic of the message catalogue. A code never changes meaning
+// so it can be searched for and explained offline with blulang explain.
+type MessageCode string
+
+// syntax errors
+const (
+	CodeUnexpectedEnd         MessageCode = "E0001"
+	CodeUnexpectedEndExpected MessageCode = "E0002"
+	CodeUnexpectedToken       MessageCode = "E0003"
+	CodeNumberOutOfRange      MessageCode = "E0004"
+	CodeUnknownLanguage       MessageCode = "E0005"
+	CodeUnterminatedString    MessageCode = "E0007"
+	CodeNotAnExpression       MessageCode = "E0008"
+)
+
+// runtime errors of the language
+const (
+	CodeInvalidStatement      MessageCode = "E0010"
+	CodeNotAnObject           MessageCode = "E0011"
+	CodeVariableDefined       MessageCode = "E0012"
+	CodeInvalidProperty       MessageCode = "E0013"
+	CodeArrayOperator         MessageCode = "E0014"
+	CodeUnsupportedOperator   MessageCode = "E0015"
+	CodeDivisionByZero        MessageCode = "E0016"
+	CodeNotAFunction          MessageCode = "E0017"
+	CodeNotAnArray            MessageCode = "E0018"
+	CodeInvalidIndex          MessageCode = "E0019"
+	CodeExpectsOneArgument    MessageCode = "E0020"
+	CodeExpectsArguments      MessageCode = "E0021"
+	CodeExpectsArgumentRange  MessageCode = "E0022"
+	CodeExpectsAtLeast        MessageCode = "E0023"
+	CodeArgumentNotNumber     MessageCode = "E0024"
+	CodeArgumentNotInteger    MessageCode = "E0025"
+	CodeArgumentNotString     MessageCode = "E0026"
+	CodeArgumentNotArray      MessageCode = "E0027"
+	CodeArrayContainsItself   MessageCode = "E0028"
+	CodeMissingArguments      MessageCode = "E0029"
+	CodeStepLimit             MessageCode = "E0030"
+	CodeCallDepthLimit        MessageCode = "E0031"
+	CodeCollectionLimit       MessageCode = "E0032"
+	CodeStopped               MessageCode = "E0033"
+	CodeHostError             MessageCode = "E0034"
+	CodeNotComparable         MessageCode = "E0035"
+	CodeModuleNotFound        MessageCode = "E0040"
+	CodeImportCycle           MessageCode = "E0041"
+	CodeImportNotAvailable    MessageCode = "E0042"
+	CodeImportNeedsFilesystem MessageCode = "E0043"
+	CodeExportNotTopLevel     MessageCode = "E0044"
+	CodeModuleError           MessageCode = "E0045"
+	CodeTestNotTopLevel       MessageCode = "E0046"
+	CodeUnsupportedMapKey     MessageCode = "E0050"
+	CodeUnsupportedGoType     MessageCode = "E0051"
+	CodeGoArgumentCount       MessageCode = "E0052"
+	CodeGoArgument            MessageCode = "E0053"
+	CodeAssertionFailed       MessageCode = "E0054"
+	CodeAssertionMessage      MessageCode = "E0055"
+	CodeNotEqual              MessageCode = "E0056"
+)
+
+// errors of the standard library
+const (
+	CodeRandomLimit      MessageCode = "E0060"
+	CodeSqrtNegative     MessageCode = "E0061"
+	CodeInvalidLogBase   MessageCode = "E0062"
+	CodeInvalidRadix     MessageCode = "E0063"
+	CodeInvalidNumber    MessageCode = "E0064"
+	CodeEmptyArray       MessageCode = "E0065"
+	CodeNotPositive      MessageCode = "E0066"
+	CodeNegativeRepeat   MessageCode = "E0067"
+	CodeMissingFormatArg MessageCode = "E0068"
+	CodeIntegerOverflow  MessageCode = "E0069"
+)
+
+// warnings of the linter, see LintRule
+const (
+	CodeUnusedVariable        MessageCode = "E0080"
+	CodeShadowedBuiltin       MessageCode = "E0081"
+	CodeUndeclaredAssignment  MessageCode = "E0082"
+	CodeUnreachableCode       MessageCode = "E0083"
+	CodeUnusedIfValue         MessageCode = "E0084"
+	CodeAssignmentInCondition MessageCode = "E0085"
+)
+
+// errors of the tools
+const (
+	CodeNoTargetKeyword     MessageCode = "E0070"
+	CodeKeywordClash        MessageCode = "E0071"
+	CodeUnknownLintRule     MessageCode = "E0072"
+	CodeInvalidLintConfig   MessageCode = "E0073"
+	CodeReadFile            MessageCode = "E0090"
+	CodeReadStdin           MessageCode = "E0091"
+	CodeUnknownLanguageFlag MessageCode = "E0092"
+	CodeUnknownCode         MessageCode = "E0093"
+	CodeWriteFile           MessageCode = "E0094"
+	CodeNoDebugScript       MessageCode = "E0095"
+	CodeNoTestFiles         MessageCode = "E0096"
+	CodeSlowerThanBaseline  MessageCode = "E0097"
+)
+
+// the frames every syntax and runtime error message is shown in, they are translated like messages
+const (
+	msgSyntaxError  MessageCode = "syntax error"
+	msgRuntimeError MessageCode = "runtime error"
+)
+
+// englishMessages is the message catalogue, every diagnostic has an English format here
+var englishMessages = map[MessageCode]string{
+	msgSyntaxError:  "syntax error at %v: %s",
+	msgRuntimeError: "runtime error: %s",
+
+	CodeUnexpectedEnd:         "unexpected end of input",
+	CodeUnexpectedEndExpected: "unexpected end of input, expected %s",
+	CodeUnexpectedToken:       "unexpected '%s', expected %s",
+	CodeNumberOutOfRange:      "number out of range: %s",
+	CodeUnknownLanguage:       "unknown language: %s",
+	CodeUnterminatedString:    "unterminated string, expected a closing '\"'",
+	CodeNotAnExpression:       "expected an expression that doesn't declare, assign, import, export, return or break",
+
+	CodeInvalidStatement:      "invalid statement: %v",
+	CodeNotAnObject:           "%s is not an object",
+	CodeVariableDefined:       "variable already defined: %s",
+	CodeInvalidProperty:       "invalid property access: %v",
+	CodeArrayOperator:         "unsupported operator for array: %s",
+	CodeUnsupportedOperator:   "unsupported operator: %s",
+	CodeDivisionByZero:        "division by zero",
+	CodeNotAFunction:          "%s is not a function",
+	CodeNotAnArray:            "%s is not an array",
+	CodeInvalidIndex:          "invalid index %s of %s, an array of %d elements",
+	CodeExpectsOneArgument:    "%s expects 1 argument but got %d",
+	CodeExpectsArguments:      "%s expects %d arguments but got %d",
+	CodeExpectsArgumentRange:  "%s expects %d to %d arguments but got %d",
+	CodeExpectsAtLeast:        "%s expects at least %d arguments but got %d",
+	CodeArgumentNotNumber:     "%s: argument %d must be a number, got %v",
+	CodeArgumentNotInteger:    "%s: argument %d must be an integer, got %v",
+	CodeArgumentNotString:     "%s: argument %d must be a string, got %v",
+	CodeArgumentNotArray:      "%s: argument %d must be an array, got %v",
+	CodeArrayContainsItself:   "%s can't contain itself",
+	CodeMissingArguments:      "%s takes %d arguments but got %d",
+	CodeStepLimit:             "step limit exceeded",
+	CodeCallDepthLimit:        "call depth limit exceeded",
+	CodeCollectionLimit:       "collection size limit exceeded",
+	CodeStopped:               "%v",
+	CodeHostError:             "%v",
+	CodeNotComparable:         "functions can't be compared with %s",
+	CodeModuleNotFound:        "module not found: %s",
+	CodeImportCycle:           "import cycle: %s",
+	CodeImportNotAvailable:    "import is not available in this scope",
+	CodeImportNeedsFilesystem: "importing %s requires the filesystem capability",
+	CodeExportNotTopLevel:     "export is only allowed at the top level of a module",
+	CodeModuleError:           "%s: %v",
+	CodeTestNotTopLevel:       "test is only allowed at the top level of a file",
+	CodeUnsupportedMapKey:     "unsupported map key type %v",
+	CodeUnsupportedGoType:     "unsupported Go type %v",
+	CodeGoArgumentCount:       "expected %d arguments but got %d",
+	CodeGoArgument:            "argument %d: %v",
+	CodeAssertionFailed:       "assertion failed",
+	CodeAssertionMessage:      "assertion failed: %s",
+	CodeNotEqual:              "values are not equal:\n%s",
+
+	CodeRandomLimit:      "random limit must be positive, got %d",
+	CodeSqrtNegative:     "sqrt of a negative number: %v",
+	CodeInvalidLogBase:   "log: invalid base %v",
+	CodeInvalidRadix:     "parseInt: radix must be between 2 and 36, got %d",
+	CodeInvalidNumber:    "parseInt: invalid number %q in base %d",
+	CodeEmptyArray:       "%s of an empty array",
+	CodeNotPositive:      "%s of a non positive number: %v",
+	CodeNegativeRepeat:   "repeat: count must not be negative, got %d",
+	CodeMissingFormatArg: "format: no argument for {%s}",
+	CodeIntegerOverflow:  "%s: the result doesn't fit in an integer",
+
+	CodeNoTargetKeyword:     "%s has no keyword for '%s'",
+	CodeKeywordClash:        "'%s' is a keyword in %s, rename it before translating",
+	CodeUnknownLintRule:     "unknown lint rule %q",
+	CodeInvalidLintConfig:   "invalid lint config: %v",
+	CodeReadFile:            "error reading file: %v",
+	CodeReadStdin:           "error reading stdin: %v",
+	CodeUnknownLanguageFlag: "unknown language %q, expected one of %s",
+	CodeUnknownCode:         "unknown error code %s, run blulang explain to list the codes",
+	CodeWriteFile:           "error writing file: %v",
+	CodeNoDebugScript:       "blulang debug needs a script file or -e source",
+	CodeNoTestFiles:         "no test files found in %s",
+	CodeSlowerThanBaseline:  "slower than the baseline by more than %g%%: %s",
+
+	CodeUnusedVariable:        "'%s' is declared but never used",
+	CodeShadowedBuiltin:       "'%s' hides the builtin with the same name",
+	CodeUndeclaredAssignment:  "'%s' is assigned but never declared, the assignment has no effect",
+	CodeUnreachableCode:       "unreachable code after '%s'",
+	CodeUnusedIfValue:         "the value of this if is never used",
+	CodeAssignmentInCondition: "'%s' is assigned in a condition, use '==' to compare",
+}
+
+// englishExplanations are printed by blulang explain
+var englishExplanations = map[MessageCode]string{
+	CodeUnexpectedEnd: `The program ended in the middle of a statement.
+Check that the last statement is complete.`,
+	CodeUnexpectedEndExpected: `The program ended while something was still expected, usually a closing brace.
+Every '(' needs a ')', every '[' a ']' and every '{' a '}':
+
+    fn add(a, b) { a + b }`,
+	CodeUnexpectedToken: `The parser found a token that can't appear at this place, the message says what
+was expected instead. A common cause is a keyword used as a name:
+
+    let if = 1     ; 'if' is a keyword
+    let when = 1   ; fine`,
+	CodeNumberOutOfRange: `An integer literal doesn't fit in 64 bits, integers go up to 9223372036854775807.
+Write a float such as 10000000000000000000.0 for larger numbers.`,
+	CodeUnknownLanguage: `The lang header at the top of the file names a language that isn't registered.
+The built in languages are en and vi:
+
+    ; lang: vi`,
+	CodeUnterminatedString: `A string starts with '"' and goes on to the next '"', which is missing.
+A '"' inside the string is written '\"'.
+
+    print("hello)     ; wrong
+    print("hello")    ; prints hello`,
+	CodeNotAnExpression: `The watches and breakpoint conditions of the debugger are a single expression, which reads
+the variables of the program without changing them.
+
+    a[0] + 1    ; reads a
+    a[0] = 1    ; wrong, assigns a`,
+	CodeInvalidStatement: `The interpreter was given a statement it doesn't know how to evaluate.
+This is a bug in the interpreter or in the Go code building the syntax tree.`,
+	CodeNotAnObject: `A property was read with '.' on a value that isn't an object or module.
+
+    let point = { x: 1 }
+    point.x        ; 1
+    let n = 1
+    n.x            ; n is not an object`,
+	CodeVariableDefined: `A variable or function was declared twice in the same scope.
+Declare it once with let and assign new values with '=':
+
+    let a = 1
+    a = 2`,
+	CodeInvalidProperty: `Only names can follow '.' and only values can be written between '[' and ']'.
+
+    person.name
+    person["name"]`,
+	CodeArrayOperator: `Arrays only support '+' to join two arrays and '==' or '!=' to compare them.
+
+    [1, 2] + [3]   ; [1, 2, 3]`,
+	CodeUnsupportedOperator: `The operator can't be used with these values, for example '-' between two strings.
+Convert the values first or use a function of the string module.`,
+	CodeDivisionByZero: `An integer was divided by zero.
+Check the divisor before dividing, or divide floats to get an infinity:
+
+    if b != 0 { a / b }`,
+	CodeNotAFunction: `A value that isn't a function was called with '(' and ')'.
+Check the spelling of the name and that it was declared with fn.`,
+	CodeNotAnArray: `A value that isn't an array was indexed with '[' and ']'.
+Check that the variable holds an array, objects are read with '.':
+
+    let a = [1, 2]
+    a[0]   ; 1`,
+	CodeInvalidIndex: `An array index must be an integer from 0 to the number of elements minus one.
+Check the index against count before reading or assigning the element:
+
+    if i < count(a) { a[i] }`,
+	CodeExpectsOneArgument: `The builtin function takes exactly one argument.`,
+	CodeExpectsArguments:   `The builtin function takes exactly the given number of arguments.`,
+	CodeExpectsArgumentRange: `The builtin function takes between the given numbers of arguments,
+the last ones are optional.`,
+	CodeExpectsAtLeast: `The builtin function takes any number of arguments from the given minimum.`,
+	CodeArgumentNotNumber: `The argument must be an integer or a float.
+Text read with input can be turned into a number with math.parseInt.`,
+	CodeArgumentNotInteger: `The argument must be an integer, floats are rejected.
+Use math.floor, math.round or math.trunc to turn a float into an integer.`,
+	CodeArgumentNotString: `The argument must be a string written between double quotes.`,
+	CodeArgumentNotArray:  `The argument must be an array such as [1, 2, 3].`,
+	CodeArrayContainsItself: `An array was assigned into one of its own elements, directly or through
+the arrays and objects the value holds. Such an array could never be printed or compared.
+Assign a copy built with '+' instead:
+
+    a[0] = [] + a`,
+	CodeMissingArguments: `A function was called with fewer arguments than it has parameters.
+Pass a value for each parameter, extra arguments are ignored:
+
+    fn area(w, h) { w * h }
+    area(2, 3)`,
+	CodeStepLimit: `The program evaluated more statements than the host allows, usually
+because of a loop that never ends. Check the condition of every while loop.`,
+	CodeCallDepthLimit: `Functions called each other too deeply, usually because a recursive function
+never reaches its base case:
+
+    fn countdown(n) {
+        if n == 0 { 0 } else { countdown(n - 1) }
+    }`,
+	CodeCollectionLimit: `An array, object or string grew larger than the host allows.`,
+	CodeStopped: `The host stopped the program, because it ran longer than its time limit or
+because it was cancelled.`,
+	CodeHostError: `A function provided by the host or by the operating system failed, for example
+readFile with a file that doesn't exist. The message comes from the host.`,
+	CodeNotComparable: `'==' and '!=' compare numbers, strings, booleans, null, and arrays and objects
+by their elements, but not functions. Compare what the functions return instead:
+
+    f(1) == g(1)`,
+	CodeModuleNotFound: `The file given to import wasn't found next to the importing file nor in the
+directories listed in BLU_PATH. Module names such as "math" and "string" need no file.`,
+	CodeImportCycle: `Modules imported each other in a circle, which can't be evaluated.
+Move the shared declarations to a third module imported by both.`,
+	CodeImportNotAvailable: `import was used where modules can't be loaded, such as in a scope created by Go code
+without a global scope.`,
+	CodeImportNeedsFilesystem: `Importing a file reads the disk, which the host didn't allow.
+Native modules such as "math" can still be imported. On the command line, drop -sandbox.`,
+	CodeExportNotTopLevel: `export can only mark declarations at the top level of a file, not inside a
+function, loop or condition.`,
+	CodeModuleError: `An imported module failed, the rest of the message is the error of the module.`,
+	CodeTestNotTopLevel: `test blocks can only be written at the top level of a file, not inside a
+function, loop or condition.`,
+	CodeUnsupportedMapKey: `A Go map given to the interpreter must have string keys.`,
+	CodeUnsupportedGoType: `A Go value given to the interpreter has a type that can't be converted,
+such as a channel.`,
+	CodeGoArgumentCount: `A Go function registered with RegisterFunc was called with the wrong number of arguments.`,
+	CodeGoArgument: `An argument of a Go function registered with RegisterFunc couldn't be converted
+to the type of its parameter.`,
+	CodeAssertionFailed: `The condition given to assert was false, so the test fails.
+A second argument describes what went wrong:
+
+    assert(count(items) > 0, "items is empty")`,
+	CodeAssertionMessage: `The condition given to assert was false, the message is the one given to assert.`,
+	CodeNotEqual: `The two values given to assertEqual differ, the actual value first and the
+expected one second. Each line names the index or property that differs:
+
+    assertEqual([1, 2], [1, 3])   ; [1]: expected 3, got 2`,
+	CodeRandomLimit:    `random(n) returns a number from 0 up to n, so n must be at least 1.`,
+	CodeSqrtNegative:   `The square root of a negative number isn't a real number.`,
+	CodeInvalidLogBase: `The base of a logarithm must be positive and not 1.`,
+	CodeInvalidRadix:   `math.parseInt reads numbers written in a radix from 2 to 36.`,
+	CodeInvalidNumber: `The text isn't a number in the given radix.
+
+    math.parseInt("ff", 16)   ; 255`,
+	CodeEmptyArray:     `min and max need at least one value.`,
+	CodeNotPositive:    `Logarithms are only defined for positive numbers.`,
+	CodeNegativeRepeat: `A text can be repeated zero or more times, not a negative number of times.`,
+	CodeMissingFormatArg: `string.format has more placeholders than arguments.
+'{}' takes the next argument and '{n}' the argument at index n, starting from 0:
+
+    string.format("{} + {} = {2}", 1, 2, 3)`,
+	CodeIntegerOverflow: `The integer result is outside the range from math.minInt to math.maxInt.
+Pass a float to compute it as a float instead:
+
+    math.pow(3.0, 50)`,
+	CodeNoTargetKeyword: `The language translated to has no keyword for a construct used by the program.`,
+	CodeKeywordClash: `A name chosen by the program is a keyword of the language translated to,
+so the translated program couldn't be read back. Rename it before translating.`,
+	CodeUnknownLintRule: `The lint config names a rule that doesn't exist.
+blulang lint -rules lists the rules.`,
+	CodeInvalidLintConfig: `The lint config isn't valid JSON. It turns rules on or off by name:
+
+    { "rules": { "unused-variable": false } }`,
+
+	CodeUnusedVariable: `A variable is declared with let but its value is never read.
+Remove it, or disable the rule for one line with a comment:
+
+    let unused = 1 ; lint-disable-line unused-variable`,
+	CodeShadowedBuiltin: `A declaration hides a builtin.
+A variable, function, parameter or import alias has the name of a builtin such as print,
+so the builtin can't be called where the name is visible.`,
+	CodeUndeclaredAssignment: `A value is assigned to a variable that was never declared.
+The assignment is ignored, declare the variable with let first:
+
+    count = 1       ; ignored
+    let total = 0
+    total = 1       ; fine`,
+	CodeUnreachableCode: `Statements after return or break in the same block never run.`,
+	CodeUnusedIfValue: `The value computed by an if is lost.
+The if only computes a value but it is neither assigned nor the last statement of a block:
+
+    if a > 1 { 1 } else { 2 }             ; lost
+    let b = if a > 1 { 1 } else { 2 }     ; kept`,
+	CodeAssignmentInCondition: `A condition assigns a value with '=' instead of comparing with '=='.
+
+    if a = 1 { ... }                 ; assigns 1 to a
+    while a == 1 || b = 2 { ... }    ; assigns 2 to b`,
+	CodeReadFile:            `The script file couldn't be read, check its path and permissions.`,
+	CodeReadStdin:           `The script couldn't be read from the standard input.`,
+	CodeUnknownLanguageFlag: `The language given with -lang, -to or BLU_LANG isn't registered.`,
+	CodeUnknownCode:         `The code given to blulang explain isn't in the catalogue.`,
+	CodeWriteFile:           `The formatted script couldn't be written back, check the permissions of the file.`,
+	CodeNoDebugScript: `The debugger reads its commands from stdin, so the script can't come from stdin too.
+Give the file to debug, or the source with -e:
+
+    blulang debug main.blu
+    blulang debug -e "let a = 1"`,
+	CodeNoTestFiles: `blulang test runs the files whose name ends with _test.blu, in the directories
+it is given and their subdirectories, or the current directory by default.`,
+	CodeSlowerThanBaseline: `blulang bench -baseline compares the time of each program to the one saved in the
+file and fails when it grew by more than the -threshold percentage. Benchmark again to
+rule out a busy machine, or save a new baseline with -save once the change is accepted.`,
+}
+
+// message gives the format of a diagnostic in the language of the locale, falling back to English
+// for messages the pack doesn't translate. Phrases outside of the catalogue, like the
+// descriptions of what the parser expected, are their own English text.
+func (l *Locale) message(code MessageCode) string {
+	if l != nil {
+		if translated, found := l.Messages[code]; found {
+			return translated
+		}
+	}
+	if format, found := englishMessages[code]; found {
+		return format
+	}
+	return string(code)
+}
+
+// Localize formats a diagnostic of the catalogue in the language of the locale, English when nil,
+// arguments that are phrases are translated too
+func Localize(locale *Locale, code MessageCode, args ...any) string {
+	localized := make([]any, len(args))
+	for i, arg := range args {
+		if phrase, ok := arg.(MessageCode); ok {
+			arg = locale.message(phrase)
+		}
+		localized[i] = arg
+	}
+	return fmt.Sprintf(locale.message(code), localized...)
+}
+
+// Explain gives the explanation of an error code in the language of the locale
+func Explain(code MessageCode, locale *Locale) (string, bool) {
+	code = MessageCode(strings.ToUpper(string(code)))
+	explanation, found := englishExplanations[code]
+	if locale != nil {
+		if translated, translatedFound := locale.Explanations[code]; translatedFound {
+			explanation = translated
+		}
+	}
+	return explanation, found
+}
+
+// MessageCodes are the codes of the catalogue in order
+func MessageCodes() []MessageCode {
+	var codes []MessageCode
+	for code := range englishExplanations {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	return codes
+}
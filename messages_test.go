@@ -0,0 +1,53 @@
+package blulang_test
+
+import (
+	"blulang"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"sort"
+	"testing"
+)
+
+var formatVerb = regexp.MustCompile(`%[a-z]`)
+
+func TestCatalogueIsTranslated(t *testing.T) {
+	for _, code := range blulang.MessageCodes() {
+		english := blulang.English.Messages[code]
+		translated, found := blulang.Vietnamese.Messages[code]
+		assert.Truef(t, found, "%s has no Vietnamese message", code)
+		// translations must take the same arguments
+		assert.Equalf(t, sortedVerbs(english), sortedVerbs(translated), "%s", code)
+		_, found = blulang.Vietnamese.Explanations[code]
+		assert.Truef(t, found, "%s has no Vietnamese explanation", code)
+	}
+}
+
+func sortedVerbs(format string) []string {
+	verbs := formatVerb.FindAllString(format, -1)
+	sort.Strings(verbs)
+	return verbs
+}
+
+func TestErrorCodes(t *testing.T) {
+	interpreter := blulang.New(blulang.Options{})
+	_, err := interpreter.Run(context.Background(), "let a = 1 let a = 2")
+	var runtimeError blulang.RuntimeError
+	assert.True(t, errors.As(err, &runtimeError))
+	assert.Equal(t, blulang.CodeVariableDefined, runtimeError.Code)
+
+	_, err = interpreter.Run(context.Background(), "let = 1")
+	var syntaxError blulang.SyntaxError
+	assert.True(t, errors.As(err, &syntaxError))
+	assert.Equal(t, blulang.CodeUnexpectedToken, syntaxError.Code)
+	assert.EqualError(t, blulang.LocalizeError(err, blulang.Vietnamese), "lỗi cú pháp tại 1:5: không mong đợi '=', cần tên biến")
+}
+
+func TestLanguageOption(t *testing.T) {
+	interpreter := blulang.New(blulang.Options{Language: blulang.Vietnamese})
+	_, err := interpreter.Run(context.Background(), "1 / 0")
+	assert.EqualError(t, err, "lỗi thực thi: chia cho số không")
+	_, err = interpreter.Run(context.Background(), `import "math" math.sqrt("4")`)
+	assert.EqualError(t, err, "lỗi thực thi: sqrt: đối số 1 phải là một số, nhận được StringVal")
+}
@@ -0,0 +1,157 @@
+package blulang
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// NativeModules are the modules implemented in Go, they are imported by name like import "math"
+// and don't need the filesystem capability
+var NativeModules = map[string]func() ObjectVal{
+	"math":   NewMathModule,
+	"string": NewStringModule,
+}
+
+func newNativeModule(members map[string]RuntimeVal) ObjectVal {
+	props := NewScope(nil)
+	for name, member := range members {
+		props.DeclareVar(name, member)
+	}
+	return NewObjectVal(props)
+}
+
+// moduleRegistry is shared by every module of an interpreter so each file is evaluated once
+type moduleRegistry struct {
+	env     Environment
+	cache   map[string]ObjectVal
+	loading []string
+}
+
+// moduleContext belongs to the top level scope of one module and every scope nested in it
+type moduleContext struct {
+	registry *moduleRegistry
+	// path is the absolute path of the module file, empty when the source didn't come from a file
+	path    string
+	scope   *Scope
+	exports []string
+	// builtins are the builtins of the module by their English name,
+	// they are declared under their names in each of the language packs in use
+	builtins map[string]RuntimeVal
+	locales  []*Locale
+	// builtinScope encloses the module scope and holds the builtins under their localized
+	// names, so that a script may declare a variable named like one of them
+	builtinScope *Scope
+	// test is the name of the test block the run evaluates, tests are skipped when it is empty
+	test string
+}
+
+func newModuleRegistry(env Environment) *moduleRegistry {
+	return &moduleRegistry{env: env, cache: make(map[string]ObjectVal)}
+}
+
+// dir is the directory imports of the module are relative to
+func (m *moduleContext) dir() string {
+	if m.path == "" {
+		dir, _ := os.Getwd()
+		return dir
+	}
+	return filepath.Dir(m.path)
+}
+
+// resolve finds the file of an import relative to the importing module, then in the search paths
+func (m *moduleContext) resolve(importPath string) string {
+	if filepath.IsAbs(importPath) {
+		return filepath.Clean(importPath)
+	}
+	candidates := []string{filepath.Join(m.dir(), importPath)}
+	for _, searchPath := range m.registry.env.SearchPaths {
+		candidates = append(candidates, filepath.Join(searchPath, importPath))
+	}
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			path, _ := filepath.Abs(candidate)
+			return path
+		}
+	}
+	panic(codedError(CodeModuleNotFound, importPath))
+}
+
+// load evaluates a module file once, with the limits of the importing run
+func (r *moduleRegistry) load(path string, exec *execution) ObjectVal {
+	if exports, found := r.cache[path]; found {
+		return exports
+	}
+	for i, loading := range r.loading {
+		if loading == path {
+			var cycle []string
+			for _, module := range append(r.loading[i:len(r.loading):len(r.loading)], path) {
+				cycle = append(cycle, filepath.Base(module))
+			}
+			panic(codedError(CodeImportCycle, strings.Join(cycle, " -> ")))
+		}
+	}
+	r.loading = append(r.loading, path)
+	defer func() {
+		r.loading = r.loading[:len(r.loading)-1]
+	}()
+
+	source, err := os.ReadFile(path)
+	if err != nil {
+		panic(WrapRuntimeError(err))
+	}
+	parser := NewLocaleParser(r.env.locales()...)
+	program, err := parser.Parse(string(source))
+	if err != nil {
+		panic(causedError(err, CodeModuleError, filepath.Base(path), err))
+	}
+	moduleScope := newGlobalScope(r.env, r)
+	moduleScope.module.useLocale(parser.Locale())
+	moduleScope.module.path = path
+	moduleScope.exec = exec
+	Eval(program, moduleScope)
+
+	exports := NewScope(nil)
+	for _, name := range moduleScope.module.exports {
+		exports.DeclareVar(name, moduleScope.variables[name])
+	}
+	r.cache[path] = NewObjectVal(exports)
+	return r.cache[path]
+}
+
+func EvalImportExpression(expr ImportExpr, scope *Scope) RuntimeVal {
+	if scope.module == nil {
+		panic(codedError(CodeImportNotAvailable))
+	}
+	if newModule, found := NativeModules[expr.path]; found {
+		registry := scope.module.registry
+		if _, loaded := registry.cache[expr.path]; !loaded {
+			registry.cache[expr.path] = newModule()
+		}
+		scope.DeclareVar(expr.alias, registry.cache[expr.path])
+		scope.exec.variable(expr.alias, registry.cache[expr.path], true)
+		return registry.cache[expr.path]
+	}
+	if !scope.module.registry.env.Capabilities.Has(CapFilesystem) {
+		panic(codedError(CodeImportNeedsFilesystem, expr.path))
+	}
+	path := scope.module.resolve(expr.path)
+	exports := scope.module.registry.load(path, scope.exec)
+	scope.DeclareVar(expr.alias, exports)
+	scope.exec.variable(expr.alias, exports, true)
+	return exports
+}
+
+func EvalExportExpression(expr ExportExpr, scope *Scope) RuntimeVal {
+	if scope.module == nil || scope.module.scope != scope {
+		panic(codedError(CodeExportNotTopLevel))
+	}
+	value := Eval(expr.declaration, scope)
+	switch declaration := expr.declaration.(type) {
+	case VarDeclareExpression:
+		scope.module.exports = append(scope.module.exports, declaration.name)
+	case FuncDeclareExpression:
+		scope.module.exports = append(scope.module.exports, declaration.name)
+	}
+	return value
+}
@@ -0,0 +1,79 @@
+package blulang_test
+
+import (
+	"blulang"
+	"blulang/internal/testfiles"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+)
+
+func TestImportSample(t *testing.T) {
+	interpreter := blulang.New(blulang.Options{Capabilities: blulang.CapFilesystem})
+	result, err := interpreter.RunFile(context.Background(), "./sample/modules.blu")
+	assert.NoError(t, err)
+	assert.Equal(t, 25, result.Value())
+	// private functions of a module are not exported
+	result, err = interpreter.Run(context.Background(), `geometry.square(2)`)
+	assert.NoError(t, err)
+	assert.Equal(t, blulang.VaNullVal, result.Kind())
+}
+
+func TestImportIsEvaluatedOnce(t *testing.T) {
+	dir := t.TempDir()
+	testfiles.WriteFiles(t, dir, map[string]string{
+		"main.blu":  `import "lib/a.blu" import "lib/b.blu" a.loaded + b.loaded`,
+		"lib/a.blu": `import "counter.blu" export let loaded = counter.next()`,
+		"lib/b.blu": `import "counter.blu" as c export let loaded = c.next()`,
+		"lib/counter.blu": `
+		let calls = 0
+		export fn next() {
+			calls = calls + 1
+		}`,
+	})
+	interpreter := blulang.New(blulang.Options{Capabilities: blulang.CapFilesystem})
+	result, err := interpreter.RunFile(context.Background(), filepath.Join(dir, "main.blu"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, result.Value())
+}
+
+func TestImportSearchPath(t *testing.T) {
+	dir := t.TempDir()
+	testfiles.WriteFiles(t, dir, map[string]string{
+		"shared/strings.blu": `xuất khẩu cho greeting = "xin chào"`,
+	})
+	interpreter := blulang.New(blulang.Options{
+		Capabilities: blulang.CapFilesystem,
+		SearchPaths:  []string{filepath.Join(dir, "shared")},
+	})
+	result, err := interpreter.Run(context.Background(), `nhập khẩu "strings.blu" strings.greeting`)
+	assert.NoError(t, err)
+	assert.Equal(t, "xin chào", result.Value())
+}
+
+func TestImportErrors(t *testing.T) {
+	dir := t.TempDir()
+	testfiles.WriteFiles(t, dir, map[string]string{
+		"main.blu":   `import "a.blu"`,
+		"a.blu":      `import "b.blu"`,
+		"b.blu":      `import "main.blu"`,
+		"broken.blu": `let = 1`,
+		"nested.blu": `if true { export let a = 1 }`,
+	})
+	interpreter := blulang.New(blulang.Options{Capabilities: blulang.CapFilesystem})
+	_, err := interpreter.RunFile(context.Background(), filepath.Join(dir, "main.blu"))
+	assert.EqualError(t, err, "runtime error: import cycle: main.blu -> a.blu -> b.blu -> main.blu")
+
+	interpreter = blulang.New(blulang.Options{Capabilities: blulang.CapFilesystem})
+	_, err = interpreter.Run(context.Background(), `import "missing.blu"`)
+	assert.EqualError(t, err, "runtime error: module not found: missing.blu")
+	_, err = interpreter.Run(context.Background(), `import "`+filepath.Join(dir, "broken.blu")+`"`)
+	assert.EqualError(t, err, "runtime error: broken.blu: syntax error at 1:5: unexpected '=', expected variable name")
+	assert.ErrorAs(t, err, &blulang.SyntaxError{})
+	_, err = interpreter.Run(context.Background(), `import "`+filepath.Join(dir, "nested.blu")+`"`)
+	assert.EqualError(t, err, "runtime error: export is only allowed at the top level of a module")
+
+	_, err = blulang.New(blulang.Options{}).Run(context.Background(), `import "geometry.blu"`)
+	assert.EqualError(t, err, "runtime error: importing geometry.blu requires the filesystem capability")
+}
@@ -1,35 +1,171 @@
-package main
+package blulang
 
 import (
 	"bufio"
 	"fmt"
+	"io"
+	"math"
 	"os"
 	"strconv"
 	"strings"
 )
 
-var PrintFunc = NewNativeFuncVal(func(scope *Scope, args ...RuntimeVal) RuntimeVal {
-	var values []interface{}
-	for _, v := range args {
-		values = append(values, v.Value())
+// Streams are the standard streams that builtins of a global scope read from and write to
+type Streams struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// stdinReader is shared so input buffered by one read isn't lost to the next
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// DefaultStreams are the standard streams of the process
+func DefaultStreams() Streams {
+	return Streams{Stdin: stdinReader, Stdout: os.Stdout, Stderr: os.Stderr}
+}
+
+// withDefaults fills the missing streams with the ones of the process
+func (s Streams) withDefaults() Streams {
+	defaults := DefaultStreams()
+	if s.Stdin == nil {
+		s.Stdin = defaults.Stdin
 	}
-	fmt.Println(values...)
-	return NewArrayVal(args)
-})
-var InputFunc = NewNativeFuncVal(func(scope *Scope, args ...RuntimeVal) RuntimeVal {
-	reader := bufio.NewReader(os.Stdin)
-	text, _ := reader.ReadString('\n')
-	text = strings.TrimSpace(text)
-	intVal, err := strconv.Atoi(text)
-	if err == nil {
-		return NewIntVal(intVal)
-	}
-	return NewStringVal(text)
-})
+	if s.Stdout == nil {
+		s.Stdout = defaults.Stdout
+	}
+	if s.Stderr == nil {
+		s.Stderr = defaults.Stderr
+	}
+	return s
+}
+
+// NewPrintFunc creates a print builtin writing its arguments as a line to w
+func NewPrintFunc(w io.Writer) NativeFuncVal {
+	return NewNativeFuncVal(func(scope *Scope, args ...RuntimeVal) RuntimeVal {
+		var values []interface{}
+		for _, v := range args {
+			values = append(values, v.Value())
+		}
+		fmt.Fprintln(w, values...)
+		return NewArrayVal(args)
+	})
+}
 
-var CountFunc = NewNativeFuncVal(func(scope *Scope, args ...RuntimeVal) RuntimeVal {
-	if args[0].Kind() == VaArrayVal {
-		return NewIntVal(len(args[0].(ArrayVal).values))
+// NewInputFunc creates an input builtin reading one line from r, numbers are returned as IntVal
+func NewInputFunc(r io.Reader) NativeFuncVal {
+	reader, ok := r.(*bufio.Reader)
+	if !ok {
+		reader = bufio.NewReader(r)
+	}
+	return NewNativeFuncVal(func(scope *Scope, args ...RuntimeVal) RuntimeVal {
+		text, _ := reader.ReadString('\n')
+		text = strings.TrimSpace(text)
+		intVal, err := strconv.Atoi(text)
+		if err == nil {
+			return NewIntVal(intVal)
+		}
+		return NewStringVal(text)
+	})
+}
+
+var PrintFunc = NewPrintFunc(os.Stdout)
+var InputFunc = NewInputFunc(stdinReader)
+
+var CountFunc = NewCheckedFuncVal("count", 1, 1, func(scope *Scope, args NativeArgs) RuntimeVal {
+	if args.Get(0).Kind() == VaArrayVal {
+		return NewIntVal(len(args.Array(0)))
 	}
 	return NewIntVal(0)
 })
+
+var AbsFunc = NewCheckedFuncVal("abs", 1, 1, func(scope *Scope, args NativeArgs) RuntimeVal {
+	if args.Get(0).Kind() == VaFloatVal {
+		return NewFloatVal(math.Abs(args.Float(0)))
+	}
+	if args.Int(0) == math.MinInt {
+		panic(codedError(CodeIntegerOverflow, "abs"))
+	}
+	if args.Int(0) < 0 {
+		return NewIntVal(-args.Int(0))
+	}
+	return args.Get(0)
+})
+
+// Variadic is the maximum argument count of native functions accepting any number of arguments
+const Variadic = -1
+
+// NewCheckedFuncVal creates a native function that fails with a runtime error naming
+// the function unless it is called with minArgs up to maxArgs arguments
+func NewCheckedFuncVal(name string, minArgs int, maxArgs int, call func(scope *Scope, args NativeArgs) RuntimeVal) NativeFuncVal {
+	return NewNativeFuncVal(func(scope *Scope, args ...RuntimeVal) RuntimeVal {
+		if len(args) < minArgs || (maxArgs != Variadic && len(args) > maxArgs) {
+			switch {
+			case minArgs == 1 && maxArgs == 1:
+				panic(codedError(CodeExpectsOneArgument, name, len(args)))
+			case maxArgs == Variadic:
+				panic(codedError(CodeExpectsAtLeast, name, minArgs, len(args)))
+			case maxArgs != minArgs:
+				panic(codedError(CodeExpectsArgumentRange, name, minArgs, maxArgs, len(args)))
+			}
+			panic(codedError(CodeExpectsArguments, name, minArgs, len(args)))
+		}
+		return call(scope, NativeArgs{fnName: name, values: args})
+	})
+}
+
+// NativeArgs gives typed access to the arguments of a native function,
+// a value of the wrong type fails with a runtime error naming the function
+type NativeArgs struct {
+	fnName string
+	values []RuntimeVal
+}
+
+func (a NativeArgs) Len() int {
+	return len(a.values)
+}
+
+func (a NativeArgs) Values() []RuntimeVal {
+	return a.values
+}
+
+func (a NativeArgs) Get(i int) RuntimeVal {
+	return a.values[i]
+}
+
+func (a NativeArgs) Int(i int) int {
+	intVal, ok := a.values[i].(IntVal)
+	if !ok {
+		a.mismatch(i, CodeArgumentNotInteger)
+	}
+	return intVal.value
+}
+
+// Float accepts both IntVal and FloatVal
+func (a NativeArgs) Float(i int) float64 {
+	floatVal, ok := toFloat(a.values[i])
+	if !ok {
+		a.mismatch(i, CodeArgumentNotNumber)
+	}
+	return floatVal
+}
+
+func (a NativeArgs) String(i int) string {
+	stringVal, ok := a.values[i].(StringVal)
+	if !ok {
+		a.mismatch(i, CodeArgumentNotString)
+	}
+	return stringVal.value
+}
+
+func (a NativeArgs) Array(i int) []RuntimeVal {
+	arrayVal, ok := a.values[i].(ArrayVal)
+	if !ok {
+		a.mismatch(i, CodeArgumentNotArray)
+	}
+	return arrayVal.values
+}
+
+func (a NativeArgs) mismatch(i int, code MessageCode) {
+	panic(codedError(code, a.fnName, i+1, a.values[i].Kind()))
+}
@@ -0,0 +1,49 @@
+package blulang
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeNFD decomposes precomposed letters into a base letter followed by combining marks
+// in canonical order, so "ệ" becomes "e" + dot below + circumflex
+func NormalizeNFD(text string) string {
+	return norm.NFD.String(text)
+}
+
+// NormalizeNFC composes base letters and combining marks into precomposed letters
+func NormalizeNFC(text string) string {
+	if isASCII(text) {
+		return text
+	}
+	return norm.NFC.String(text)
+}
+
+// RemoveDiacritics strips accents and tone marks, "Việt Nam" becomes "Viet Nam" and "Đà" becomes "Da"
+func RemoveDiacritics(text string) string {
+	var builder strings.Builder
+	for _, ch := range NormalizeNFD(text) {
+		switch {
+		case unicode.Is(unicode.Mn, ch):
+			continue
+		case ch == 'đ':
+			builder.WriteRune('d')
+		case ch == 'Đ':
+			builder.WriteRune('D')
+		default:
+			builder.WriteRune(ch)
+		}
+	}
+	return builder.String()
+}
+
+func isASCII(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if text[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
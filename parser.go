@@ -1,12 +1,19 @@
-package main
+package blulang
 
 import (
-	"log"
+	"path/filepath"
 	"strconv"
+	"strings"
 )
 
 type Parser struct {
-	tokens []Token
+	tokens  []Token
+	last    Token
+	locales []*Locale
+	locale  *Locale
+	// program holds every token of the source, index is the one of the next token
+	program []Token
+	index   int
 }
 
 func NewParser() Parser {
@@ -14,17 +21,260 @@ func NewParser() Parser {
 		tokens: make([]Token, 0),
 	}
 }
-func (p *Parser) CreateAST(source string) Program {
-	tokens := Tokenize(source)
-	p.tokens = tokens
-	program := NewProgram()
 
+// NewLocaleParser creates a parser for sources using the keywords of the given language packs,
+// a source with a lang header uses the keywords of its header instead
+func NewLocaleParser(locales ...*Locale) Parser {
+	parser := NewParser()
+	parser.locales = locales
+	return parser
+}
+
+func (p *Parser) CreateAST(source string) Program {
+	program := p.start(source, p.sourceLocales(source))
 	for len(p.tokens) > 0 {
 		program.body = append(program.body, p.parseStatement())
+		program.ends = append(program.ends, p.index)
+	}
+	program.clean = len(program.body)
+	return program
+}
+
+// sourceLocales are the language packs a source is read with, failing on an unknown lang header
+func (p *Parser) sourceLocales(source string) []*Locale {
+	locales := p.locales
+	if len(locales) == 0 {
+		locales = DefaultLocales()
+	}
+	p.locale = locales[0]
+	if name, line := headerLocale(source); line > 0 {
+		locale, found := Locales[name]
+		if !found {
+			panic(NewSyntaxError(Position{Line: line, Column: 1}, CodeUnknownLanguage, name))
+		}
+		locales = []*Locale{locale}
+		p.locale = locale
 	}
+	return locales
+}
+
+// start tokenizes a source and prepares the parser to read it from its first token
+func (p *Parser) start(source string, locales []*Locale) Program {
+	tokens, comments := tokenize(source, mergeKeywords(locales))
+	p.tokens, p.program, p.index = tokens, tokens, 0
+	program := NewProgram()
+	program.tokens, program.comments, program.locales = tokens, comments, locales
+	program.pos = Position{Line: 1, Column: 1}
 	return program
 }
 
+// ParseTolerant parses as much of a source as it can, for editors reading a file while it is typed.
+// A top level statement with a syntax error is skipped up to the next line starting a statement,
+// the program holds every statement that could be parsed and the errors are returned in order.
+func (p *Parser) ParseTolerant(source string) (Program, []SyntaxError) {
+	return p.Reparse(Program{}, source, 0)
+}
+
+// Reparse parses a new version of a source that was edited from the rune offset edit on. The top
+// level statements of the previous program followed by a token ending before the edit are kept
+// and parsing resumes after them, so only the statements from the edited one on are parsed again. Syntax errors are
+// tolerated like ParseTolerant does.
+func (p *Parser) Reparse(previous Program, source string, edit int) (program Program, errs []SyntaxError) {
+	locales := DefaultLocales()
+	if err := p.tolerate(func() { locales = p.sourceLocales(source) }); err != nil {
+		errs = append(errs, *err)
+	}
+	program = NewProgram()
+	if err := p.tolerate(func() { program = p.start(source, locales) }); err != nil {
+		// nothing can be parsed without the tokens
+		return program, append(errs, *err)
+	}
+
+	// the tokens before the edit are the same in both versions. Statements have no terminator,
+	// so a statement is only kept when the token following it, which ended it, is before the
+	// edit too, an edit right after a statement may continue it.
+	kept := 0
+	for len(errs) == 0 && kept < previous.clean && previous.ends[kept] < len(program.tokens) &&
+		program.tokens[previous.ends[kept]].end < edit {
+		kept++
+	}
+	program.body = append(program.body, previous.body[:kept]...)
+	program.ends = append(program.ends, previous.ends[:kept]...)
+	program.clean = kept
+	if kept > 0 {
+		p.seek(previous.ends[kept-1])
+	}
+
+	// errors found before reported follow from the last reported one
+	reported := 0
+	for len(p.tokens) > 0 {
+		start := p.index
+		var statement Statement
+		err := p.tolerate(func() { statement = p.parseStatement() })
+		if err == nil {
+			program.body = append(program.body, statement)
+			program.ends = append(program.ends, p.index)
+			if len(errs) == 0 {
+				program.clean = len(program.body)
+			}
+			continue
+		}
+		if p.index >= reported {
+			errs = append(errs, *err)
+			reported = p.index + 1
+		}
+		p.resync(start)
+	}
+	return program, errs
+}
+
+// tolerate runs a parse, returning the syntax error it raises
+func (p *Parser) tolerate(parse func()) (err *SyntaxError) {
+	defer func() {
+		if r := recover(); r != nil {
+			syntaxError, ok := r.(SyntaxError)
+			if !ok {
+				panic(r)
+			}
+			err = &syntaxError
+		}
+	}()
+	parse()
+	return nil
+}
+
+// seek moves the parser to the token at the given index
+func (p *Parser) seek(index int) {
+	p.tokens, p.index = p.program[index:], index
+	if index > 0 {
+		p.last = p.program[index-1]
+	}
+}
+
+// resync moves the parser past a statement that failed to parse, which starts at the given
+// token, to the first token of a later line that can start a statement outside of the braces
+// opened by the statement. When the braces are never closed it resumes inside them instead.
+func (p *Parser) resync(start int) {
+	inside := len(p.program)
+	depth := 0
+	for index := start + 1; index < len(p.program); index++ {
+		token := p.program[index]
+		if token.pos.Line > p.program[index-1].pos.Line && statementStart[token.name] {
+			if depth <= 0 {
+				p.seek(index)
+				return
+			}
+			inside = min(inside, index)
+		}
+		switch token.name {
+		case TkOpenCurly:
+			depth++
+		case TkCloseCurly:
+			depth--
+		}
+	}
+	p.seek(inside)
+}
+
+// statementStart are the tokens a line starting a statement usually begins with
+var statementStart = map[TokenType]bool{
+	TkDeclareVar: true, TkDeclareFunc: true, TkIf: true, TkWhile: true,
+	TkImport: true, TkExport: true, TkTest: true, TkIdentifier: true,
+}
+
+// Locale is the language pack of the last parsed source, the one of its header or
+// the first one the parser was created with
+func (p *Parser) Locale() *Locale {
+	return p.locale
+}
+
+// Parse is like CreateAST but reports syntax errors instead of panicking
+func (p *Parser) Parse(source string) (program Program, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recoverError(r)
+		}
+	}()
+	return p.CreateAST(source), nil
+}
+
+// ParseExpression parses a single expression that reads values without changing them, such
+// as the watch of a debugger. It fails on declarations, assignments, imports, exports and on
+// return and break, the body of a function written in the expression isn't checked.
+func (p *Parser) ParseExpression(source string) (program Program, err error) {
+	program, err = p.Parse(source)
+	if err != nil {
+		return program, err
+	}
+	if len(program.body) != 1 {
+		pos := Position{Line: 1, Column: 1}
+		if len(program.body) > 1 {
+			pos = program.body[1].Pos()
+		}
+		return program, NewSyntaxError(pos, CodeNotAnExpression)
+	}
+	if statement := changing(program.body[0]); statement != nil {
+		return program, NewSyntaxError(statement.Pos(), CodeNotAnExpression)
+	}
+	return program, nil
+}
+
+// changing finds a statement of an expression that declares, assigns or jumps
+func changing(expression Statement) Statement {
+	var found Statement
+	var find func(expression Statement)
+	body := func(statements []Statement) {
+		for _, statement := range statements {
+			find(statement)
+		}
+	}
+	find = func(expression Statement) {
+		if found != nil {
+			return
+		}
+		switch expression := expression.(type) {
+		case VarDeclareExpression, ImportExpr, ExportExpr, TestBlock, ReturnStatement, BreakStatement:
+			found = expression
+		case FuncDeclareExpression:
+			if expression.name != "" {
+				found = expression
+			}
+		case BinaryExpression:
+			if expression.operator == "=" {
+				found = expression
+				return
+			}
+			find(expression.left)
+			find(expression.right)
+		case ConditionalExpression:
+			find(expression.condition)
+			body(expression.trueBody)
+			body(expression.falseBody)
+		case WhileLoopExpression:
+			find(expression.condition)
+			body(expression.body)
+		case FuncCallExpression:
+			for _, value := range expression.arguments {
+				find(value)
+			}
+		case ArrayAccessExpr:
+			find(expression.index)
+		case ArrayLiteral:
+			for _, value := range expression.values {
+				find(value)
+			}
+		case ObjectDeclareExpr:
+			for _, value := range expression.values {
+				find(value)
+			}
+		case ObjectAccessExpr:
+			find(expression.property)
+		}
+	}
+	find(expression)
+	return found
+}
+
 func (p *Parser) peek() Token {
 	if len(p.tokens) > 0 {
 		return p.tokens[0]
@@ -39,7 +289,42 @@ func (p *Parser) peekNext() Token {
 }
 
 func (p *Parser) pop() {
+	if len(p.tokens) == 0 {
+		panic(NewSyntaxError(p.last.pos, CodeUnexpectedEnd))
+	}
+	p.last = p.tokens[0]
 	p.tokens = p.tokens[1:]
+	p.index++
+}
+
+// nodeAt records the token at the given index as the first one of a statement, which
+// ends with the last token popped, or with the first one when none was popped yet
+func (p *Parser) nodeAt(index int) node {
+	token := p.program[index]
+	last := p.program[max(p.index, index+1)-1]
+	return node{pos: token.pos, token: index, end: last.endPos, comments: token.comments}
+}
+
+// expect pops the next token, failing when it is not of the given type
+func (p *Parser) expect(name TokenType, description MessageCode) Token {
+	token := p.peek()
+	if token.name != name {
+		p.unexpected(description)
+	}
+	p.pop()
+	return token
+}
+
+func (p *Parser) unexpected(description MessageCode) {
+	token := p.peek()
+	if len(p.tokens) == 0 {
+		panic(NewSyntaxError(p.last.pos, CodeUnexpectedEndExpected, description))
+	}
+	panic(NewSyntaxError(token.pos, CodeUnexpectedToken, token.value, description))
+}
+
+func (p *Parser) atEnd() bool {
+	return len(p.tokens) == 0
 }
 
 // Order of precedence;
@@ -74,21 +359,75 @@ func (p *Parser) parseExpression() Expression {
 	if p.peek().name == TkWhile {
 		return p.parseWhileLoopExpression()
 	}
+	if p.peek().name == TkImport {
+		return p.parseImportExpression()
+	}
+	if p.peek().name == TkExport {
+		return p.parseExportExpression()
+	}
+	if p.peek().name == TkTest {
+		return p.parseTestBlock()
+	}
 	return p.parseAssignmentExpression()
 }
 
+func (p *Parser) parseImportExpression() Expression {
+	start := p.index
+	p.pop() // pop 'import'
+	path := p.expect(TkString, "module path").value
+	// the alias defaults to the file name without its extension
+	alias := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if p.peek().name == TkAs {
+		p.pop() // pop 'as'
+		alias = p.expect(TkIdentifier, "module alias").value
+	}
+	expr := NewImportExpr(path, alias)
+	expr.node = p.nodeAt(start)
+	return expr
+}
+
+func (p *Parser) parseExportExpression() Expression {
+	start := p.index
+	p.pop() // pop 'export'
+	if p.peek().name != TkDeclareVar && !(p.peek().name == TkDeclareFunc && p.peekNext().name == TkIdentifier) {
+		p.unexpected("a variable or named function declaration")
+	}
+	expr := NewExportExpr(p.parseExpression())
+	expr.node = p.nodeAt(start)
+	return expr
+}
+
+func (p *Parser) parseTestBlock() Expression {
+	start := p.index
+	p.pop() // pop 'test'
+	name := p.peek()
+	if name.value == "" {
+		p.unexpected("test name")
+	}
+	p.expect(TkString, "test name")
+	var statements []Statement
+	statements = p.parseCodeBlock(statements)
+	expr := NewTestBlock(name.value, statements)
+	expr.node = p.nodeAt(start)
+	return expr
+}
+
 func (p *Parser) parseWhileLoopExpression() Expression {
+	start := p.index
 	p.pop() // pop 'if'
-	conditionExpr := p.parseLogicalExpression()
+	conditionExpr := p.parseAssignmentExpression()
 
 	var statements []Statement
 	statements = p.parseCodeBlock(statements)
-	return NewWhileLoopExpression(conditionExpr, statements)
+	expr := NewWhileLoopExpression(conditionExpr, statements)
+	expr.node = p.nodeAt(start)
+	return expr
 }
 
 func (p *Parser) parseConditionalExpression() Expression {
+	start := p.index
 	p.pop() // pop 'if'
-	conditionExpr := p.parseLogicalExpression()
+	conditionExpr := p.parseAssignmentExpression()
 
 	var trueBodyStatements []Statement
 	trueBodyStatements = p.parseCodeBlock(trueBodyStatements)
@@ -103,13 +442,18 @@ func (p *Parser) parseConditionalExpression() Expression {
 		}
 	}
 
-	return NewConditionalExpression(conditionExpr, trueBodyStatements, falseBodyStatements)
+	expr := NewConditionalExpression(conditionExpr, trueBodyStatements, falseBodyStatements)
+	expr.node = p.nodeAt(start)
+	return expr
 }
 
 func (p *Parser) parseCodeBlock(statements []Statement) []Statement {
 	// pop open curly bracket
-	p.pop()
+	p.expect(TkOpenCurly, "'{'")
 	for p.peek().name != TkCloseCurly {
+		if p.atEnd() {
+			p.unexpected("'}'")
+		}
 		statements = append(statements, p.parseStatement())
 	}
 	// pop close curly bracket
@@ -118,29 +462,36 @@ func (p *Parser) parseCodeBlock(statements []Statement) []Statement {
 }
 
 func (p *Parser) parseAssignmentExpression() Expression {
+	start := p.index
 	expr := p.parseLogicalExpression()
 	if p.peek().value == "=" {
 		p.pop() // pop equal sign
-		return NewBinaryExpression(expr, p.parseExpression(), "=")
+		return p.binaryExpression(start, expr, p.parseExpression(), "=")
 	}
 	return expr
 }
 
 func (p *Parser) parseVariableDeclarationExpression() Expression {
+	start := p.index
 	// pop the declaration keyword
 	p.pop()
 
-	variableName := p.peek().value
-	p.pop()
+	variableName := p.expect(TkIdentifier, "variable name").value
 
 	// pop the equal sign
+	if p.peek().value != "=" {
+		p.unexpected("'='")
+	}
 	p.pop()
 
 	value := p.parseExpression()
-	return NewVarDeclareExpression(variableName, value)
+	expr := NewVarDeclareExpression(variableName, value)
+	expr.node = p.nodeAt(start)
+	return expr
 }
 
 func (p *Parser) parseFunctionDeclarationExpression() Expression {
+	start := p.index
 	// pop the declaration keyword
 	p.pop()
 
@@ -151,11 +502,13 @@ func (p *Parser) parseFunctionDeclarationExpression() Expression {
 	}
 
 	// pop open round bracket
-	p.pop()
+	p.expect(TkOpenRound, "'('")
 	var arguments []Identifier
 	for p.peek().name != TkCloseRound {
-		arguments = append(arguments, NewIdentifier(p.peek().value))
-		p.pop()
+		index := p.index
+		argument := NewIdentifier(p.expect(TkIdentifier, "parameter name").value)
+		argument.node = p.nodeAt(index)
+		arguments = append(arguments, argument)
 		if p.peek().name == TkComma {
 			p.pop()
 		}
@@ -166,10 +519,13 @@ func (p *Parser) parseFunctionDeclarationExpression() Expression {
 	var statements []Statement
 	statements = p.parseCodeBlock(statements)
 
-	return NewFuncDeclareExpression(functionName, arguments, statements)
+	expr := NewFuncDeclareExpression(functionName, arguments, statements)
+	expr.node = p.nodeAt(start)
+	return expr
 }
 
 func (p *Parser) parseIdentifierBasedExpression() Expression {
+	start := p.index
 	// parse function call
 	if p.peekNext().name == TkOpenRound {
 		funcName := p.peek().value
@@ -179,6 +535,9 @@ func (p *Parser) parseIdentifierBasedExpression() Expression {
 		p.pop()
 		var args []Expression
 		for p.peek().name != TkCloseRound {
+			if p.atEnd() {
+				p.unexpected("')'")
+			}
 			args = append(args, p.parseExpression())
 			if p.peek().name == TkComma {
 				p.pop()
@@ -186,7 +545,9 @@ func (p *Parser) parseIdentifierBasedExpression() Expression {
 		}
 		// pop close round bracket
 		p.pop()
-		return NewFuncCallExpression(funcName, args)
+		expr := NewFuncCallExpression(funcName, args)
+		expr.node = p.nodeAt(start)
+		return expr
 	}
 	// parse array index access
 	if p.peekNext().name == TKOpenSquare {
@@ -194,49 +555,55 @@ func (p *Parser) parseIdentifierBasedExpression() Expression {
 		p.pop() // pop name
 		p.pop() // pop [
 		indexExpr := p.parseExpression()
-		p.pop() // pop ]
-		return NewArrayAccessExpr(identifierName, indexExpr)
+		p.expect(TkCloseSquare, "']'")
+		expr := NewArrayAccessExpr(identifierName, indexExpr)
+		expr.node = p.nodeAt(start)
+		return expr
 	}
 	// parse property access
 	if p.peekNext().name == TkDot {
 		return p.parseObjectAccessExpression()
 	}
-	identifierName := p.peek().value
+	identifier := NewIdentifier(p.peek().value)
+	identifier.node = p.nodeAt(start)
 	p.pop()
-	return NewIdentifier(identifierName)
+	return identifier
 }
 
 func (p *Parser) parseLogicalExpression() Expression {
+	start := p.index
 	leftExp := p.parseComparisonExpression()
 	operator := p.peek().value
 	for operator == "&&" || operator == "||" {
 		p.pop()
 		rightExp := p.parseExpression()
-		leftExp = NewBinaryExpression(leftExp, rightExp, operator)
+		leftExp = p.binaryExpression(start, leftExp, rightExp, operator)
 		operator = p.peek().value
 	}
 	return leftExp
 }
 
 func (p *Parser) parseComparisonExpression() Expression {
+	start := p.index
 	leftExp := p.parseAdditiveExpression()
 	operator := p.peek().value
 	for operator == "==" || operator == "!=" || operator == "<" || operator == ">" || operator == "<=" || operator == ">=" {
 		p.pop()
 		rightExp := p.parseExpression()
-		leftExp = NewBinaryExpression(leftExp, rightExp, operator)
+		leftExp = p.binaryExpression(start, leftExp, rightExp, operator)
 		operator = p.peek().value
 	}
 	return leftExp
 }
 
 func (p *Parser) parseAdditiveExpression() Expression {
+	start := p.index
 	leftExp := p.parseMultiplicativeExpression()
 	operator := p.peek().value
 	for operator == "+" || operator == "-" {
 		p.pop()
 		rightExp := p.parseMultiplicativeExpression()
-		leftExp = NewBinaryExpression(leftExp, rightExp, operator)
+		leftExp = p.binaryExpression(start, leftExp, rightExp, operator)
 		operator = p.peek().value
 	}
 
@@ -244,86 +611,128 @@ func (p *Parser) parseAdditiveExpression() Expression {
 }
 
 func (p *Parser) parseMultiplicativeExpression() Expression {
+	start := p.index
 	leftExp := p.parsePrimaryExpression()
 	operator := p.peek().value
 	for operator == "*" || operator == "/" {
 		p.pop()
 		rightExp := p.parseMultiplicativeExpression()
-		leftExp = NewBinaryExpression(leftExp, rightExp, operator)
+		leftExp = p.binaryExpression(start, leftExp, rightExp, operator)
 		operator = p.peek().value
 	}
 
 	return leftExp
 }
 
+// binaryExpression creates an operation whose left operand starts at the given token
+func (p *Parser) binaryExpression(start int, left Expression, right Expression, operator string) BinaryExpression {
+	expr := NewBinaryExpression(left, right, operator)
+	expr.node = p.nodeAt(start)
+	return expr
+}
+
 func (p *Parser) parseArrayExpression() Expression {
+	start := p.index
 	var values []Expression
 	p.pop() // pop [
 	for p.peek().name != TkCloseSquare {
+		if p.atEnd() {
+			p.unexpected("']'")
+		}
 		values = append(values, p.parseExpression())
 		if p.peek().name == TkComma {
 			p.pop() // pop comma after an element
 		}
 	}
 	p.pop() // pop ]
-	return NewArrayLiteral(values)
+	expr := NewArrayLiteral(values)
+	expr.node = p.nodeAt(start)
+	return expr
 }
 
 func (p *Parser) parseGroupedExpression() Expression {
 	p.pop()
 	expr := p.parseLogicalExpression()
-	p.pop()
+	p.expect(TkCloseRound, "')'")
 	return expr
 }
 
 func (p *Parser) parseNotExpression() Expression {
+	start := p.index
 	p.pop() // pop !
 	expression := p.parseExpression()
-	return NewBinaryExpression(expression, NewIdentifier("true"), "!=")
+	return p.binaryExpression(start, expression, NewBoolLiteral(true), "!=")
 }
 
 func (p *Parser) parseObjectDeclarationExpression() Expression {
+	start := p.index
 	p.pop() // pop {
-	props := make(map[string]Expression)
+	var names []string
+	var values []Expression
 	// parse { key1: val1, key2: val2}
 	for p.peek().name != TkCloseCurly {
-		name := p.peek().value
-		p.pop()
-		p.pop() // pop :
-		expr := p.parseExpression()
-		props[name] = expr
+		name := p.expect(TkIdentifier, "property name").value
+		p.expect(TKColon, "':'") // pop :
+		names = append(names, name)
+		values = append(values, p.parseExpression())
 		if p.peek().name == TkComma {
 			p.pop()
 		}
 	}
 	p.pop() // pop }
-	return NewObjectDeclareExpr(props)
+	expr := NewObjectDeclareExpr(names, values)
+	expr.node = p.nodeAt(start)
+	return expr
 }
 
 func (p *Parser) parseObjectAccessExpression() Expression {
-	identifier := p.peek().value
+	start := p.index
+	owner := NewIdentifier(p.peek().value)
+	owner.node = p.nodeAt(start)
 	p.pop() // pop owner
 	p.pop() // pop .
-	expr := p.parseIdentifierBasedExpression()
-	return NewObjectAccessExpr(NewIdentifier(identifier), expr)
+	if p.peek().name != TkIdentifier {
+		p.unexpected("property name")
+	}
+	access := NewObjectAccessExpr(owner, p.parseIdentifierBasedExpression())
+	access.node = p.nodeAt(start)
+	return access
 }
 
 func (p *Parser) parsePrimaryExpression() Expression {
+	start := p.index
 	token := p.peek()
 	switch token.name {
 	case TkNumber:
 		p.pop()
-		intVal, _ := strconv.Atoi(token.value)
-		return NewIntLiteral(intVal)
+		if strings.Contains(token.value, ".") {
+			floatVal, _ := strconv.ParseFloat(token.value, 64)
+			literal := NewFloatLiteral(floatVal)
+			literal.node = p.nodeAt(start)
+			return literal
+		}
+		intVal, err := strconv.Atoi(token.value)
+		if err != nil {
+			panic(NewSyntaxError(token.pos, CodeNumberOutOfRange, token.value))
+		}
+		literal := NewIntLiteral(intVal)
+		literal.node = p.nodeAt(start)
+		return literal
 	case TkString:
 		p.pop()
-		return NewStringLiteral(token.value)
+		literal := NewStringLiteral(token.value)
+		literal.node = p.nodeAt(start)
+		return literal
 	case TkBreak:
 		p.pop()
-		return NewBreakStatement()
+		statement := NewBreakStatement()
+		statement.node = p.nodeAt(start)
+		return statement
 	case TkReturn:
 		p.pop()
-		return NewReturnStatement()
+		statement := NewReturnStatement()
+		statement.node = p.nodeAt(start)
+		return statement
 	case TkNot:
 		return p.parseNotExpression()
 	case TkIdentifier:
@@ -333,7 +742,7 @@ func (p *Parser) parsePrimaryExpression() Expression {
 	case TkOpenRound:
 		return p.parseGroupedExpression()
 	default:
-		log.Panicf("Unknown token %v", token)
+		p.unexpected("an expression")
 	}
 	return NullLiteral{}
 }
@@ -0,0 +1,248 @@
+// Package profile measures where BluLang runs spend their time, to find why a script such as
+// a naive recursive Fibonacci is slow. A Profiler is a blulang.AllocationHook: the time between
+// two statements, calls or returns is charged to the stack of frames the interpreter tracks and
+// to the line being evaluated, so every step of the run is a sample. Functions are reported with
+// their calls, time and the arrays and objects they create, and the stacks are written in the
+// folded format of flame graph tools.
+package profile
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"blulang"
+)
+
+// Function is what a user function, or the top level of the run, cost
+type Function struct {
+	// Name is the name of the function, the one of the profiled file for the top level,
+	// prefixed by the name of its file when it comes from an imported module
+	Name  string
+	Calls int
+	// Total is the time spent in the function and in what it called, once for recursive calls,
+	// Self the time spent in its own statements and in builtins
+	Total time.Duration
+	Self  time.Duration
+	// Arrays and Objects count what its statements created
+	Arrays  int
+	Objects int
+}
+
+// Line is what a line of a file cost
+type Line struct {
+	File string
+	Line int
+	// Hits counts the statements starting on the line that were evaluated
+	Hits int
+	Self time.Duration
+}
+
+// Profiler records a run, the same one may record several runs one after the other
+type Profiler struct {
+	main string
+	// last is when the sample being taken started, at the event of the frames, running
+	// the functions of stack, and of the line
+	last   time.Time
+	frames []*blulang.Frame
+	stack  []*Function
+	line   *Line
+	// folded is the stack as written in folded stacks
+	folded    string
+	functions map[string]*Function
+	lines     map[lineKey]*Line
+	stacks    map[string]time.Duration
+}
+
+type lineKey struct {
+	file string
+	line int
+}
+
+// New creates a profiler for a program, file is the path of the program and
+// names its top level, empty when the source doesn't come from a file
+func New(file string) *Profiler {
+	return &Profiler{
+		main:      file,
+		functions: make(map[string]*Function),
+		lines:     make(map[lineKey]*Line),
+		stacks:    make(map[string]time.Duration),
+	}
+}
+
+func (p *Profiler) Statement(_ blulang.Statement, stack []*blulang.Frame) {
+	p.sample(stack)
+	p.line.Hits++
+}
+
+func (p *Profiler) Call(stack []*blulang.Frame) {
+	p.sample(stack)
+	p.stack[len(p.stack)-1].Calls++
+}
+
+func (p *Profiler) Return(stack []*blulang.Frame, _ blulang.RuntimeVal) {
+	p.sample(stack[:len(stack)-1])
+}
+
+func (p *Profiler) Allocate(value blulang.RuntimeVal, stack []*blulang.Frame) {
+	function := p.function(stack[len(stack)-1])
+	switch value.(type) {
+	case blulang.ArrayVal:
+		function.Arrays++
+	case blulang.ObjectVal:
+		function.Objects++
+	}
+}
+
+// Stop charges the time since the last statement of the run to it, it is called once the run ended
+func (p *Profiler) Stop() {
+	p.sample(nil)
+}
+
+// sample charges the time since the last event to the stack and the line it happened at,
+// and starts the next sample at the given stack. Functions stopped by an error don't return,
+// the stack of the next event has left them.
+func (p *Profiler) sample(stack []*blulang.Frame) {
+	now := time.Now()
+	if len(p.stack) > 0 {
+		elapsed := now.Sub(p.last)
+		p.stacks[p.folded] += elapsed
+		p.stack[len(p.stack)-1].Self += elapsed
+		for i, function := range p.stack {
+			// a recursive function is charged once
+			if !slices.Contains(p.stack[:i], function) {
+				function.Total += elapsed
+			}
+		}
+		if p.line != nil {
+			p.line.Self += elapsed
+		}
+	}
+	p.last = now
+	if len(p.stack) == 0 && len(stack) > 0 {
+		// a run starts
+		p.function(stack[0]).Calls++
+	}
+	if !slices.Equal(stack, p.frames) {
+		p.frames = append(p.frames[:0], stack...)
+		p.stack = p.stack[:0]
+		names := make([]string, len(stack))
+		for i, frame := range stack {
+			p.stack = append(p.stack, p.function(frame))
+			names[i] = p.stack[i].Name
+		}
+		p.folded = strings.Join(names, ";")
+	}
+	p.line = nil
+	if len(stack) > 0 && stack[len(stack)-1].Pos.Line > 0 {
+		frame := stack[len(stack)-1]
+		key := lineKey{frame.File, frame.Pos.Line}
+		if p.lines[key] == nil {
+			p.lines[key] = &Line{File: frame.File, Line: key.line}
+		}
+		p.line = p.lines[key]
+	}
+}
+
+// function is the function a frame runs
+func (p *Profiler) function(frame *blulang.Frame) *Function {
+	name := frame.Function
+	switch {
+	case name == "" && p.main == "":
+		name = "main"
+	case name == "":
+		name = filepath.Base(p.main)
+	case frame.File != p.main && frame.File != "":
+		name = filepath.Base(frame.File) + ":" + name
+	}
+	// folded stacks separate frames with ';' and counts with a space
+	name = strings.NewReplacer(";", ":", " ", "_").Replace(name)
+	if p.functions[name] == nil {
+		p.functions[name] = &Function{Name: name}
+	}
+	return p.functions[name]
+}
+
+// Functions are the functions that ran, the most expensive first
+func (p *Profiler) Functions() []Function {
+	var functions []Function
+	for _, function := range p.functions {
+		functions = append(functions, *function)
+	}
+	slices.SortFunc(functions, func(a, b Function) int {
+		return cmp.Or(cmp.Compare(b.Total, a.Total), cmp.Compare(b.Self, a.Self), strings.Compare(a.Name, b.Name))
+	})
+	return functions
+}
+
+// Lines are the lines that ran, the most expensive first
+func (p *Profiler) Lines() []Line {
+	var lines []Line
+	for _, line := range p.lines {
+		lines = append(lines, *line)
+	}
+	slices.SortFunc(lines, func(a, b Line) int {
+		return cmp.Or(cmp.Compare(b.Self, a.Self), cmp.Compare(b.Hits, a.Hits), strings.Compare(a.File, b.File), cmp.Compare(a.Line, b.Line))
+	})
+	return lines
+}
+
+// WriteFolded writes the stacks in the folded format read by flamegraph.pl, speedscope and
+// other flame graph tools: a line per stack, its frames from the top level separated by ';'
+// and the nanoseconds spent in it
+func (p *Profiler) WriteFolded(w io.Writer) error {
+	stacks := make([]string, 0, len(p.stacks))
+	for stack := range p.stacks {
+		stacks = append(stacks, stack)
+	}
+	slices.Sort(stacks)
+	var out strings.Builder
+	for _, stack := range stacks {
+		fmt.Fprintf(&out, "%s %d\n", stack, p.stacks[stack].Nanoseconds())
+	}
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// WriteReport writes a table of the functions and one of the lines costing the most time,
+// at most hotLines of them
+func (p *Profiler) WriteReport(w io.Writer, hotLines int) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "calls\ttotal\tself\tarrays\tobjects\t  function")
+	for _, function := range p.Functions() {
+		fmt.Fprintf(table, "%d\t%v\t%v\t%d\t%d\t  %s\n", function.Calls, round(function.Total), round(function.Self), function.Arrays, function.Objects, function.Name)
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(table, "hits\tself\t  line")
+	for i, line := range p.Lines() {
+		if i == hotLines {
+			break
+		}
+		fmt.Fprintf(table, "%d\t%v\t  %s:%d\n", line.Hits, round(line.Self), p.fileName(line.File), line.Line)
+	}
+	return table.Flush()
+}
+
+// fileName shows the path of a file relative to the profiled one
+func (p *Profiler) fileName(file string) string {
+	if file == "" {
+		return "main"
+	}
+	if relative, err := filepath.Rel(filepath.Dir(p.main), file); err == nil && p.main != "" {
+		return relative
+	}
+	return file
+}
+
+// round shows durations to the microsecond
+func round(duration time.Duration) time.Duration {
+	return duration.Round(time.Microsecond)
+}
@@ -0,0 +1,132 @@
+package profile_test
+
+import (
+	"blulang"
+	"blulang/profile"
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const fibonacci = `fn fib(n) {
+    if n < 2 {
+        n
+    } else {
+        fib(n - 1) + fib(n - 2)
+    }
+}
+fn pairs(n) {
+    let point = { x: n, y: [n] }
+    [point] + [point]
+}
+pairs(1)
+fib(6)
+`
+
+func profiled(t *testing.T, source string) *profile.Profiler {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "fib.blu")
+	require.NoError(t, os.WriteFile(path, []byte(source), 0o644))
+	profiler := profile.New(path)
+	_, err := blulang.New(blulang.Options{Hook: profiler}).RunFile(context.Background(), path)
+	profiler.Stop()
+	require.NoError(t, err)
+	return profiler
+}
+
+func TestFunctions(t *testing.T) {
+	functions := map[string]profile.Function{}
+	for _, function := range profiled(t, fibonacci).Functions() {
+		functions[function.Name] = function
+		assert.LessOrEqual(t, function.Self, function.Total, function.Name)
+	}
+	assert.Len(t, functions, 3)
+	assert.Equal(t, 1, functions["fib.blu"].Calls)
+	assert.Equal(t, 25, functions["fib"].Calls)
+	assert.Equal(t, 1, functions["pairs"].Calls)
+	// two array literals, their concatenation and the one in the object
+	assert.Equal(t, 4, functions["pairs"].Arrays)
+	assert.Equal(t, 1, functions["pairs"].Objects)
+	assert.Zero(t, functions["fib"].Arrays)
+	// the top level runs the whole program
+	assert.GreaterOrEqual(t, functions["fib.blu"].Total, functions["fib"].Total+functions["pairs"].Total)
+}
+
+func TestBuiltinAllocations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "show.blu")
+	require.NoError(t, os.WriteFile(path, []byte(`import "string"
+fn show(a) {
+    print(a)
+    print(a, [a])
+}
+fn words(text) {
+    string.split(text, " ")
+}
+show(1)
+words("a b")
+`), 0o644))
+	profiler := profile.New(path)
+	_, err := blulang.New(blulang.Options{Hook: profiler, Stdout: io.Discard, Capabilities: blulang.CapIO}).RunFile(context.Background(), path)
+	profiler.Stop()
+	require.NoError(t, err)
+	functions := map[string]profile.Function{}
+	for _, function := range profiler.Functions() {
+		functions[function.Name] = function
+	}
+	// print gives back its arguments in an array it doesn't count
+	assert.Equal(t, 1, functions["show"].Arrays)
+	assert.Zero(t, functions["show"].Objects)
+	assert.Equal(t, 1, functions["words"].Arrays)
+}
+
+func TestLines(t *testing.T) {
+	hits := map[int]int{}
+	for _, line := range profiled(t, fibonacci).Lines() {
+		hits[line.Line] = line.Hits
+	}
+	assert.Equal(t, map[int]int{1: 1, 2: 25, 3: 13, 5: 12, 8: 1, 9: 1, 10: 1, 12: 1, 13: 1}, hits)
+}
+
+func TestWriteFolded(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, profiled(t, fibonacci).WriteFolded(&out))
+	var stacks []string
+	for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n") {
+		stack, nanoseconds, found := strings.Cut(line, " ")
+		require.True(t, found, line)
+		_, err := strconv.Atoi(nanoseconds)
+		assert.NoError(t, err, line)
+		stacks = append(stacks, stack)
+	}
+	assert.Equal(t, []string{
+		"fib.blu", "fib.blu;fib", "fib.blu;fib;fib", "fib.blu;fib;fib;fib", "fib.blu;fib;fib;fib;fib",
+		"fib.blu;fib;fib;fib;fib;fib", "fib.blu;fib;fib;fib;fib;fib;fib", "fib.blu;pairs",
+	}, stacks)
+}
+
+func TestProfileModulesAndErrors(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lib.blu"), []byte("export fn fail(n) { n / 0 }\n"), 0o644))
+	path := filepath.Join(dir, "main.blu")
+	require.NoError(t, os.WriteFile(path, []byte("import \"lib.blu\"\nlib.fail(1)\n"), 0o644))
+	profiler := profile.New(path)
+	_, err := blulang.New(blulang.Options{Hook: profiler, Capabilities: blulang.CapFilesystem}).RunFile(context.Background(), path)
+	profiler.Stop()
+	assert.Error(t, err)
+
+	var names []string
+	for _, function := range profiler.Functions() {
+		names = append(names, function.Name)
+	}
+	assert.ElementsMatch(t, []string{"main.blu", "lib.blu:fail"}, names)
+	var out bytes.Buffer
+	require.NoError(t, profiler.WriteReport(&out, 1))
+	assert.Regexp(t, `^ +calls +total +self +arrays +objects +function\n +1 .* main\.blu\n +1 .* lib\.blu:fail\n\n +hits +self +line\n +1 .* [a-z]+\.blu:[12]\n$`, out.String())
+}
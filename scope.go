@@ -1,53 +1,118 @@
-package main
+package blulang
 
 import (
 	"errors"
-	"fmt"
-	"log"
-	"os"
 )
 
 type Scope struct {
 	parent    *Scope
 	variables map[string]RuntimeVal
+	exec      *execution
+	module    *moduleContext
 }
 
 func NewScope(parent *Scope) *Scope {
-	return &Scope{
+	scope := &Scope{
 		parent:    parent,
 		variables: make(map[string]RuntimeVal),
 	}
+	if parent != nil {
+		scope.exec = parent.exec
+		scope.module = parent.module
+	}
+	return scope
 }
 
+// NewGlobalScope creates a global scope with every capability using the streams of the process
 func NewGlobalScope() *Scope {
-	globalScope := NewScope(nil)
-	globalScope.DeclareVar("true", NewBoolVal(true))
-	globalScope.DeclareVar("false", NewBoolVal(false))
-	globalScope.DeclareVar("đúng", NewBoolVal(true))
-	globalScope.DeclareVar("sai", NewBoolVal(false))
-	globalScope.DeclareVar("print", PrintFunc)
-	globalScope.DeclareVar("in", PrintFunc)
-	globalScope.DeclareVar("count", CountFunc)
-	globalScope.DeclareVar("đếm", CountFunc)
-	globalScope.DeclareVar("input", InputFunc)
-	globalScope.DeclareVar("nhập", InputFunc)
-	globalScope.DeclareVar("abs", NewNativeFuncVal(func(scope *Scope, args ...RuntimeVal) RuntimeVal {
-		if args[0].Value().(int) < 0 {
-			return NewIntVal(-args[0].Value().(int))
-		}
-		return args[0]
-	}))
-	globalScope.DeclareVar("exit", NewNativeFuncVal(func(scope *Scope, args ...RuntimeVal) RuntimeVal {
-		fmt.Println("Good bye")
-		os.Exit(0)
-		return NullVal{}
-	}))
+	return NewGlobalScopeWith(Environment{Streams: DefaultStreams(), Capabilities: AllCapabilities})
+}
+
+// NewGlobalScopeWith creates a global scope that only declares the builtins allowed by
+// the capabilities of the environment, missing streams default to the ones of the process
+func NewGlobalScopeWith(env Environment) *Scope {
+	return newGlobalScope(env, newModuleRegistry(env))
+}
+
+// newGlobalScope creates the top level scope of a module sharing the registry of the other modules
+func newGlobalScope(env Environment, registry *moduleRegistry) *Scope {
+	builtinScope := NewScope(nil)
+	globalScope := NewScope(builtinScope)
+	globalScope.module = &moduleContext{registry: registry, scope: globalScope, builtins: newBuiltins(env), builtinScope: builtinScope}
+	builtinScope.module = globalScope.module
+	for _, locale := range env.locales() {
+		globalScope.module.useLocale(locale)
+	}
 	return globalScope
 }
 
+// newBuiltins creates the builtins allowed by the capabilities of the environment by their English name
+func newBuiltins(env Environment) map[string]RuntimeVal {
+	streams := env.Streams.withDefaults()
+	builtins := map[string]RuntimeVal{
+		"true":        NewBoolVal(true),
+		"false":       NewBoolVal(false),
+		"count":       CountFunc,
+		"abs":         AbsFunc,
+		"assert":      AssertFunc,
+		"assertEqual": AssertEqualFunc,
+	}
+	if env.Capabilities.Has(CapIO) {
+		builtins["print"] = NewPrintFunc(streams.Stdout)
+		builtins["printErr"] = NewPrintFunc(streams.Stderr)
+	}
+	if env.Capabilities.Has(CapInput) {
+		builtins["input"] = NewInputFunc(streams.Stdin)
+	}
+	if env.Capabilities.Has(CapFilesystem) {
+		builtins["readFile"] = ReadFileFunc
+		builtins["writeFile"] = WriteFileFunc
+	}
+	if env.Capabilities.Has(CapProcess) {
+		builtins["exit"] = ExitFunc
+	}
+	if env.Capabilities.Has(CapTime) {
+		builtins["now"] = NowFunc
+		builtins["sleep"] = SleepFunc
+	}
+	if env.Capabilities.Has(CapRandom) {
+		builtins["random"] = RandomFunc
+	}
+	return builtins
+}
+
+// useLocale declares the builtins of the module under their names in the language pack
+func (m *moduleContext) useLocale(locale *Locale) {
+	for _, used := range m.locales {
+		if used == locale {
+			return
+		}
+	}
+	m.locales = append(m.locales, locale)
+	for name, value := range m.builtins {
+		m.declareLocalized(locale, name, value)
+	}
+}
+
+// declareBuiltin adds a builtin declared under its name in every language pack used by the module
+func (m *moduleContext) declareBuiltin(name string, value RuntimeVal) {
+	m.builtins[name] = value
+	for _, locale := range m.locales {
+		m.declareLocalized(locale, name, value)
+	}
+}
+
+func (m *moduleContext) declareLocalized(locale *Locale, name string, value RuntimeVal) {
+	localized := locale.builtinName(name)
+	// packs may share a name, like the English abs that Vietnamese doesn't translate
+	if m.builtinScope.variables[localized] == nil {
+		m.builtinScope.DeclareVar(localized, value)
+	}
+}
+
 func (s *Scope) DeclareVar(name string, value RuntimeVal) RuntimeVal {
 	if s.variables[name] != nil {
-		log.Panic("variable already defined")
+		panic(codedError(CodeVariableDefined, name))
 	}
 
 	s.variables[name] = value
@@ -0,0 +1,206 @@
+package blulang
+
+import (
+	"math"
+	"strconv"
+)
+
+// NewMathModule creates the members of the math module, imported with import "math"
+func NewMathModule() ObjectVal {
+	return newNativeModule(map[string]RuntimeVal{
+		"pi":     NewFloatVal(math.Pi),
+		"e":      NewFloatVal(math.E),
+		"maxInt": NewIntVal(math.MaxInt),
+		"minInt": NewIntVal(math.MinInt),
+		"abs":    AbsFunc,
+		"min": NewCheckedFuncVal("min", 1, Variadic, func(scope *Scope, args NativeArgs) RuntimeVal {
+			return extremum(args, "min", func(a, b float64) bool { return a < b })
+		}),
+		"max": NewCheckedFuncVal("max", 1, Variadic, func(scope *Scope, args NativeArgs) RuntimeVal {
+			return extremum(args, "max", func(a, b float64) bool { return a > b })
+		}),
+		"pow": NewCheckedFuncVal("pow", 2, 2, func(scope *Scope, args NativeArgs) RuntimeVal {
+			// integer powers stay integers
+			if args.Get(0).Kind() == VaIntVal && args.Get(1).Kind() == VaIntVal && args.Int(1) >= 0 {
+				scope.exec.checkContext()
+				result, ok := intPow(args.Int(0), args.Int(1))
+				if !ok {
+					panic(codedError(CodeIntegerOverflow, "pow"))
+				}
+				return NewIntVal(result)
+			}
+			return NewFloatVal(math.Pow(args.Float(0), args.Float(1)))
+		}),
+		"sqrt": NewCheckedFuncVal("sqrt", 1, 1, func(scope *Scope, args NativeArgs) RuntimeVal {
+			if args.Float(0) < 0 {
+				panic(codedError(CodeSqrtNegative, args.Get(0).Value()))
+			}
+			return NewFloatVal(math.Sqrt(args.Float(0)))
+		}),
+		"floor": roundingFunc("floor", math.Floor),
+		"ceil":  roundingFunc("ceil", math.Ceil),
+		"round": roundingFunc("round", math.Round),
+		"trunc": roundingFunc("trunc", math.Trunc),
+		"toFloat": NewCheckedFuncVal("toFloat", 1, 1, func(scope *Scope, args NativeArgs) RuntimeVal {
+			return NewFloatVal(args.Float(0))
+		}),
+		"gcd": NewCheckedFuncVal("gcd", 1, Variadic, func(scope *Scope, args NativeArgs) RuntimeVal {
+			result := 0
+			for i := range args.Values() {
+				result = gcd(result, args.Int(i))
+			}
+			// only math.minInt has no positive counterpart
+			if result < 0 {
+				panic(codedError(CodeIntegerOverflow, "gcd"))
+			}
+			return NewIntVal(result)
+		}),
+		"lcm": NewCheckedFuncVal("lcm", 1, Variadic, func(scope *Scope, args NativeArgs) RuntimeVal {
+			result := 1
+			for i := range args.Values() {
+				if args.Int(i) == 0 {
+					return NewIntVal(0)
+				}
+				product, ok := mulInt(result/gcd(result, args.Int(i)), args.Int(i))
+				if !ok || product == math.MinInt {
+					panic(codedError(CodeIntegerOverflow, "lcm"))
+				}
+				result = abs(product)
+			}
+			return NewIntVal(result)
+		}),
+		"sin":   floatFunc("sin", math.Sin),
+		"cos":   floatFunc("cos", math.Cos),
+		"tan":   floatFunc("tan", math.Tan),
+		"asin":  floatFunc("asin", math.Asin),
+		"acos":  floatFunc("acos", math.Acos),
+		"atan":  floatFunc("atan", math.Atan),
+		"exp":   floatFunc("exp", math.Exp),
+		"log2":  logFunc("log2", math.Log2),
+		"log10": logFunc("log10", math.Log10),
+		"atan2": NewCheckedFuncVal("atan2", 2, 2, func(scope *Scope, args NativeArgs) RuntimeVal {
+			return NewFloatVal(math.Atan2(args.Float(0), args.Float(1)))
+		}),
+		// log is the natural logarithm, or the logarithm in the base given as second argument
+		"log": NewCheckedFuncVal("log", 1, 2, func(scope *Scope, args NativeArgs) RuntimeVal {
+			result := logFunc("log", math.Log).Invoke(scope, args.Get(0)).(FloatVal).value
+			if args.Len() == 2 {
+				base := args.Float(1)
+				if base <= 0 || base == 1 {
+					panic(codedError(CodeInvalidLogBase, args.Get(1).Value()))
+				}
+				result = result / math.Log(base)
+			}
+			return NewFloatVal(result)
+		}),
+		// parseInt reads an integer written in the given radix, 10 by default
+		"parseInt": NewCheckedFuncVal("parseInt", 1, 2, func(scope *Scope, args NativeArgs) RuntimeVal {
+			radix := 10
+			if args.Len() == 2 {
+				radix = args.Int(1)
+			}
+			if radix < 2 || radix > 36 {
+				panic(codedError(CodeInvalidRadix, radix))
+			}
+			result, err := strconv.ParseInt(args.String(0), radix, 0)
+			if err != nil {
+				panic(codedError(CodeInvalidNumber, args.String(0), radix))
+			}
+			return NewIntVal(int(result))
+		}),
+	})
+}
+
+// extremum picks the smallest or largest argument, a single array argument is searched instead
+func extremum(args NativeArgs, name string, better func(a, b float64) bool) RuntimeVal {
+	values := args.Values()
+	if args.Len() == 1 && args.Get(0).Kind() == VaArrayVal {
+		values = args.Array(0)
+		if len(values) == 0 {
+			panic(codedError(CodeEmptyArray, name))
+		}
+	}
+	elements := NativeArgs{fnName: name, values: values}
+	best := 0
+	for i := range values {
+		if better(elements.Float(i), elements.Float(best)) {
+			best = i
+		}
+	}
+	return values[best]
+}
+
+func roundingFunc(name string, round func(float64) float64) NativeFuncVal {
+	return NewCheckedFuncVal(name, 1, 1, func(scope *Scope, args NativeArgs) RuntimeVal {
+		if args.Get(0).Kind() == VaIntVal {
+			return args.Get(0)
+		}
+		rounded := round(args.Float(0))
+		// NaN fails both comparisons, and float64(math.MaxInt) is already one past the largest int
+		if !(rounded >= math.MinInt && rounded < math.MaxInt) {
+			panic(codedError(CodeIntegerOverflow, name))
+		}
+		return NewIntVal(int(rounded))
+	})
+}
+
+func floatFunc(name string, fn func(float64) float64) NativeFuncVal {
+	return NewCheckedFuncVal(name, 1, 1, func(scope *Scope, args NativeArgs) RuntimeVal {
+		return NewFloatVal(fn(args.Float(0)))
+	})
+}
+
+func logFunc(name string, log func(float64) float64) NativeFuncVal {
+	return NewCheckedFuncVal(name, 1, 1, func(scope *Scope, args NativeArgs) RuntimeVal {
+		if args.Float(0) <= 0 {
+			panic(codedError(CodeNotPositive, name, args.Get(0).Value()))
+		}
+		return NewFloatVal(log(args.Float(0)))
+	})
+}
+
+// intPow raises base to a positive exponent by squaring, ok is false when the result overflows
+func intPow(base int, exponent int) (result int, ok bool) {
+	result = 1
+	for exponent > 0 {
+		if exponent&1 == 1 {
+			if result, ok = mulInt(result, base); !ok {
+				return 0, false
+			}
+		}
+		exponent >>= 1
+		// the square is only a factor of the result when a higher bit of the exponent is set
+		if exponent > 0 {
+			if base, ok = mulInt(base, base); !ok {
+				return 0, false
+			}
+		}
+	}
+	return result, true
+}
+
+// mulInt multiplies two integers, ok is false when the product overflows
+func mulInt(a int, b int) (int, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	product := a * b
+	if product/b != a || (a == -1 && b == math.MinInt) || (b == -1 && a == math.MinInt) {
+		return 0, false
+	}
+	return product, true
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return abs(a)
+}
+
+func abs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
@@ -0,0 +1,85 @@
+package blulang_test
+
+import (
+	"blulang"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestMathModule(t *testing.T) {
+	interpreter := blulang.New(blulang.Options{})
+	_, err := interpreter.Run(context.Background(), `import "math"`)
+	assert.NoError(t, err)
+	sources := map[string]any{
+		"math.min(3, 1, 2)":                 1,
+		"math.max([4, 9, 2])":               9,
+		"math.max(1, 2.5)":                  2.5,
+		"math.pow(3, 4)":                    81,
+		"math.pow(4, 0.5)":                  2.0,
+		"math.pow(0 - 2, 63)":               math.MinInt,
+		"math.pow(1, 9000000000000)":        1,
+		"math.pow(0 - 1, 9000000000001)":    -1,
+		"math.pow(3.0, 50)":                 math.Pow(3, 50),
+		"math.sqrt(2)":                      math.Sqrt2,
+		"math.floor(2.7)":                   2,
+		"math.ceil(2.1)":                    3,
+		"math.round(2.5)":                   3,
+		"math.gcd(12, 18, 27)":              3,
+		"math.lcm(4, 6)":                    12,
+		"math.cos(0)":                       1.0,
+		"math.log(math.e)":                  1.0,
+		"math.log10(1000)":                  3.0,
+		"math.parseInt(\"101\", 2)":         5,
+		"math.parseInt(\"-42\")":            -42,
+		"math.toFloat(3) / 2":               1.5,
+		"math.abs(0 - 3)":                   3,
+		"math.gcd(math.minInt, 6)":          2,
+		"math.lcm(math.maxInt, 1)":          math.MaxInt,
+		"math.floor(0 - math.pow(2.0, 63))": math.MinInt,
+		"math.maxInt > math.minInt":         true,
+	}
+	for code, expected := range sources {
+		result, err := interpreter.Run(context.Background(), code)
+		assert.NoErrorf(t, err, code)
+		if expectedFloat, ok := expected.(float64); ok {
+			assert.InDeltaf(t, expectedFloat, result.Value(), 1e-9, code)
+		} else {
+			assert.Equalf(t, expected, result.Value(), code)
+		}
+	}
+}
+
+func TestMathModuleErrors(t *testing.T) {
+	interpreter := blulang.New(blulang.Options{})
+	_, err := interpreter.Run(context.Background(), `import "math" as m`)
+	assert.NoError(t, err)
+	errors := map[string]string{
+		`m.sqrt()`:                     "runtime error: sqrt expects 1 argument but got 0",
+		`m.sqrt("4")`:                  "runtime error: sqrt: argument 1 must be a number, got StringVal",
+		`m.sqrt(0 - 4)`:                "runtime error: sqrt of a negative number: -4",
+		`m.pow(3, 50)`:                 "runtime error: pow: the result doesn't fit in an integer",
+		`m.pow(2, 64)`:                 "runtime error: pow: the result doesn't fit in an integer",
+		`m.max([])`:                    "runtime error: max of an empty array",
+		`m.round(m.pow(10.0, 300))`:    "runtime error: round: the result doesn't fit in an integer",
+		`m.floor(m.pow(10.0, 400))`:    "runtime error: floor: the result doesn't fit in an integer",
+		`m.ceil(m.pow(0 - 1.0, 0.5))`:  "runtime error: ceil: the result doesn't fit in an integer",
+		`m.trunc(m.toFloat(m.maxInt))`: "runtime error: trunc: the result doesn't fit in an integer",
+		`m.abs(m.minInt)`:              "runtime error: abs: the result doesn't fit in an integer",
+		`m.gcd(m.minInt, 0)`:           "runtime error: gcd: the result doesn't fit in an integer",
+		`m.lcm(m.maxInt, 2)`:           "runtime error: lcm: the result doesn't fit in an integer",
+		`m.lcm(m.minInt, 1)`:           "runtime error: lcm: the result doesn't fit in an integer",
+		`m.gcd(1.5)`:                   "runtime error: gcd: argument 1 must be an integer, got FloatVal",
+		`m.log(0)`:                     "runtime error: log of a non positive number: 0",
+		`m.parseInt("12", 1)`:          "runtime error: parseInt: radix must be between 2 and 36, got 1",
+		`m.parseInt("zz", 10)`:         "runtime error: parseInt: invalid number \"zz\" in base 10",
+		`m.atan2(1)`:                   "runtime error: atan2 expects 2 arguments but got 1",
+		`m.log(1, 2, 3)`:               "runtime error: log expects 1 to 2 arguments but got 3",
+		`abs("a")`:                     "runtime error: abs: argument 1 must be an integer, got StringVal",
+	}
+	for code, message := range errors {
+		_, err := interpreter.Run(context.Background(), code)
+		assert.EqualErrorf(t, err, message, code)
+	}
+}
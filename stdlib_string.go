@@ -0,0 +1,159 @@
+package blulang
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// NewStringModule creates the members of the string module, imported with import "string".
+// Searching functions compare the NFC form of their arguments so text typed with
+// decomposed accents matches text typed with precomposed letters.
+func NewStringModule() ObjectVal {
+	return newNativeModule(map[string]RuntimeVal{
+		"length": NewCheckedFuncVal("length", 1, 1, func(scope *Scope, args NativeArgs) RuntimeVal {
+			return NewIntVal(utf8.RuneCountInString(NormalizeNFC(args.String(0))))
+		}),
+		// split with an empty separator splits into letters
+		"split": NewCheckedFuncVal("split", 2, 2, func(scope *Scope, args NativeArgs) RuntimeVal {
+			var parts []RuntimeVal
+			for _, part := range strings.Split(NormalizeNFC(args.String(0)), NormalizeNFC(args.String(1))) {
+				parts = append(parts, NewStringVal(part))
+			}
+			scope.exec.checkCollectionSize(len(parts))
+			return scope.exec.allocated(NewArrayVal(parts))
+		}),
+		"join": NewCheckedFuncVal("join", 1, 2, func(scope *Scope, args NativeArgs) RuntimeVal {
+			separator := ""
+			if args.Len() == 2 {
+				separator = args.String(1)
+			}
+			var parts []string
+			for _, element := range args.Array(0) {
+				parts = append(parts, displayString(element))
+			}
+			return NewStringVal(strings.Join(parts, separator))
+		}),
+		// trim removes surrounding whitespace, or the characters given as second argument
+		"trim": NewCheckedFuncVal("trim", 1, 2, func(scope *Scope, args NativeArgs) RuntimeVal {
+			if args.Len() == 2 {
+				return NewStringVal(strings.Trim(args.String(0), args.String(1)))
+			}
+			return NewStringVal(strings.TrimSpace(args.String(0)))
+		}),
+		"upper": NewCheckedFuncVal("upper", 1, 1, func(scope *Scope, args NativeArgs) RuntimeVal {
+			return NewStringVal(strings.ToUpper(args.String(0)))
+		}),
+		"lower": NewCheckedFuncVal("lower", 1, 1, func(scope *Scope, args NativeArgs) RuntimeVal {
+			return NewStringVal(strings.ToLower(args.String(0)))
+		}),
+		"contains": NewCheckedFuncVal("contains", 2, 2, func(scope *Scope, args NativeArgs) RuntimeVal {
+			return NewBoolVal(strings.Contains(NormalizeNFC(args.String(0)), NormalizeNFC(args.String(1))))
+		}),
+		"startsWith": NewCheckedFuncVal("startsWith", 2, 2, func(scope *Scope, args NativeArgs) RuntimeVal {
+			return NewBoolVal(strings.HasPrefix(NormalizeNFC(args.String(0)), NormalizeNFC(args.String(1))))
+		}),
+		"endsWith": NewCheckedFuncVal("endsWith", 2, 2, func(scope *Scope, args NativeArgs) RuntimeVal {
+			return NewBoolVal(strings.HasSuffix(NormalizeNFC(args.String(0)), NormalizeNFC(args.String(1))))
+		}),
+		// indexOf counts in letters and gives -1 when the text isn't found
+		"indexOf": NewCheckedFuncVal("indexOf", 2, 2, func(scope *Scope, args NativeArgs) RuntimeVal {
+			text := NormalizeNFC(args.String(0))
+			index := strings.Index(text, NormalizeNFC(args.String(1)))
+			if index < 0 {
+				return NewIntVal(-1)
+			}
+			return NewIntVal(utf8.RuneCountInString(text[:index]))
+		}),
+		"replace": NewCheckedFuncVal("replace", 3, 3, func(scope *Scope, args NativeArgs) RuntimeVal {
+			return NewStringVal(strings.ReplaceAll(NormalizeNFC(args.String(0)), NormalizeNFC(args.String(1)), args.String(2)))
+		}),
+		"repeat": NewCheckedFuncVal("repeat", 2, 2, func(scope *Scope, args NativeArgs) RuntimeVal {
+			if args.Int(1) < 0 {
+				panic(codedError(CodeNegativeRepeat, args.Int(1)))
+			}
+			scope.exec.checkRepeatedSize(len(args.String(0)), args.Int(1))
+			return NewStringVal(strings.Repeat(args.String(0), args.Int(1)))
+		}),
+		"padLeft": padFunc("padLeft", func(text string, padding string) string {
+			return padding + text
+		}),
+		"padRight": padFunc("padRight", func(text string, padding string) string {
+			return text + padding
+		}),
+		// format replaces {} with the next argument and {n} with the argument at index n
+		"format": NewCheckedFuncVal("format", 1, Variadic, func(scope *Scope, args NativeArgs) RuntimeVal {
+			return NewStringVal(formatString(args.String(0), args.Values()[1:]))
+		}),
+		"removeDiacritics": NewCheckedFuncVal("removeDiacritics", 1, 1, func(scope *Scope, args NativeArgs) RuntimeVal {
+			return NewStringVal(RemoveDiacritics(args.String(0)))
+		}),
+		"nfc": NewCheckedFuncVal("nfc", 1, 1, func(scope *Scope, args NativeArgs) RuntimeVal {
+			return NewStringVal(NormalizeNFC(args.String(0)))
+		}),
+		"nfd": NewCheckedFuncVal("nfd", 1, 1, func(scope *Scope, args NativeArgs) RuntimeVal {
+			return NewStringVal(NormalizeNFD(args.String(0)))
+		}),
+	})
+}
+
+// padFunc pads a text to a width counted in letters, with spaces or the text given as third argument
+func padFunc(name string, pad func(text string, padding string) string) NativeFuncVal {
+	return NewCheckedFuncVal(name, 2, 3, func(scope *Scope, args NativeArgs) RuntimeVal {
+		text := NormalizeNFC(args.String(0))
+		fill := " "
+		if args.Len() == 3 {
+			fill = args.String(2)
+		}
+		missing := args.Int(1) - utf8.RuneCountInString(text)
+		if missing <= 0 || fill == "" {
+			return NewStringVal(text)
+		}
+		scope.exec.checkCollectionSize(missing)
+		padding := []rune(strings.Repeat(fill, missing))[:missing]
+		return NewStringVal(pad(text, string(padding)))
+	})
+}
+
+func formatString(template string, args []RuntimeVal) string {
+	var builder strings.Builder
+	next := 0
+	for len(template) > 0 {
+		start := strings.IndexByte(template, '{')
+		end := strings.IndexByte(template[max(start, 0):], '}') + max(start, 0)
+		if start < 0 || end < start {
+			builder.WriteString(template)
+			break
+		}
+		builder.WriteString(template[:start])
+		placeholder := template[start+1 : end]
+		index := next
+		if placeholder != "" {
+			parsed, err := strconv.Atoi(placeholder)
+			if err != nil {
+				// not a placeholder, keep the brace
+				builder.WriteByte('{')
+				template = template[start+1:]
+				continue
+			}
+			index = parsed
+		} else {
+			next++
+		}
+		if index < 0 || index >= len(args) {
+			panic(codedError(CodeMissingFormatArg, placeholder))
+		}
+		builder.WriteString(displayString(args[index]))
+		template = template[end+1:]
+	}
+	return builder.String()
+}
+
+// displayString is how a value is shown inside text, strings are shown without quotes
+func displayString(val RuntimeVal) string {
+	if stringVal, ok := val.(StringVal); ok {
+		return stringVal.value
+	}
+	return fmt.Sprint(val.Value())
+}
@@ -0,0 +1,81 @@
+package blulang_test
+
+import (
+	"blulang"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestStringModule(t *testing.T) {
+	interpreter := blulang.New(blulang.Options{})
+	_, err := interpreter.Run(context.Background(), `import "string"`)
+	assert.NoError(t, err)
+	sources := map[string]any{
+		`string.length("Việt Nam")`:                                         8,
+		"string.length(\"Vie\u0323\u0302t\")":                               4,
+		`string.split("a,b,c", ",")`:                                        []any{"a", "b", "c"},
+		`string.split("đẹp", "")`:                                           []any{"đ", "ẹ", "p"},
+		`string.join([1, "b", true], "-")`:                                  "1-b-true",
+		`string.trim("  xin chào ")`:                                        "xin chào",
+		`string.trim("--a--", "-")`:                                         "a",
+		`string.upper("đà nẵng")`:                                           "ĐÀ NẴNG",
+		`string.lower("ĐẠI HỌC")`:                                           "đại học",
+		"string.contains(\"tie\u0302\u0301ng Vie\u0323\u0302t\", \"Việt\")": true,
+		`string.startsWith("Hà Nội", "Hà")`:                                 true,
+		`string.endsWith("Hà Nội", "Hà")`:                                   false,
+		`string.indexOf("Hà Nội", "Nội")`:                                   3,
+		`string.indexOf("Hà Nội", "Huế")`:                                   -1,
+		`string.replace("a.b.c", ".", "/")`:                                 "a/b/c",
+		`string.repeat("ab", 3)`:                                            "ababab",
+		`string.padLeft("7", 3, "0")`:                                       "007",
+		`string.padRight("ô", 3)`:                                           "ô  ",
+		`string.format("{} + {} = {2}", 1, 2, 3)`:                           "1 + 2 = 3",
+		`string.format("{x}")`:                                              "{x}",
+		`string.removeDiacritics("Tiếng Việt Đẹp")`:                         "Tieng Viet Dep",
+		"string.nfc(\"Vie\u0323\u0302t\") == \"Việt\"":                      true,
+		`string.nfd("ệ") == "e` + "\u0323\u0302" + `"`:                      true,
+		`string.nfd("ệ") == "ệ"`:                                            false,
+	}
+	for code, expected := range sources {
+		result, err := interpreter.Run(context.Background(), code)
+		assert.NoErrorf(t, err, code)
+		assert.Equalf(t, expected, blulang.ToGo(result), code)
+	}
+}
+
+func TestStringModuleErrors(t *testing.T) {
+	interpreter := blulang.New(blulang.Options{})
+	_, err := interpreter.Run(context.Background(), `import "string" as s`)
+	assert.NoError(t, err)
+	errors := map[string]string{
+		`s.upper(1)`:           "runtime error: upper: argument 1 must be a string, got IntVal",
+		`s.split("a")`:         "runtime error: split expects 2 arguments but got 1",
+		`s.join("a")`:          "runtime error: join: argument 1 must be an array, got StringVal",
+		`s.repeat("a", 0 - 1)`: "runtime error: repeat: count must not be negative, got -1",
+		// the size would overflow to a negative number
+		`s.repeat("ab", 4611686018427387904)`: "runtime error: collection size limit exceeded",
+		`s.format("{} {}", 1)`:                "runtime error: format: no argument for {}",
+		`s.format("{3}", 1)`:                  "runtime error: format: no argument for {3}",
+	}
+	for code, message := range errors {
+		_, err := interpreter.Run(context.Background(), code)
+		assert.EqualErrorf(t, err, message, code)
+	}
+
+	limited := blulang.New(blulang.Options{Limits: blulang.Limits{MaxCollectionSize: 1000}})
+	_, err = limited.Run(context.Background(), `import "string" string.repeat("a", 1099511627776)`)
+	assert.ErrorIs(t, err, blulang.ErrCollectionLimit)
+}
+
+func TestNormalize(t *testing.T) {
+	decomposed := "Tie\u0302\u0301ng Vie\u0323\u0302t"
+	assert.Equal(t, "Tiếng Việt", blulang.NormalizeNFC(decomposed))
+	assert.Equal(t, decomposed, blulang.NormalizeNFD("Tiếng Việt"))
+	// marks given in a non canonical order are reordered before composing
+	assert.Equal(t, "ệ", blulang.NormalizeNFC("e\u0302\u0323"))
+	assert.Equal(t, "Duong pho", blulang.RemoveDiacritics("Đường phố"))
+	// scripts other than Latin are normalised too
+	assert.Equal(t, "\u1F04", blulang.NormalizeNFC("\u03B1\u0313\u0301"))
+	assert.Equal(t, "\u1100\u1161", blulang.NormalizeNFD("\uAC00"))
+}
@@ -1,40 +1,43 @@
-package main_test
+package blulang_test
 
 import (
 	"blulang"
+	"context"
 	"github.com/stretchr/testify/assert"
 	"os"
+	"strings"
 	"testing"
+	"unicode"
 )
 
 func TestMath(t *testing.T) {
-	scope := main.NewScope(nil)
-	parser := main.NewParser()
+	scope := blulang.NewScope(nil)
+	parser := blulang.NewParser()
 	code := `
 	((10+4) * 2 - 3) / ((9-7)*(3-2))
 	`
 	program := parser.CreateAST(code)
-	result := main.Eval(program, scope)
+	result := blulang.Eval(program, scope)
 	assert.Equal(t, 12, result.Value())
-	assert.Equal(t, main.VaIntVal, result.Kind())
+	assert.Equal(t, blulang.VaIntVal, result.Kind())
 }
 
 func TestVariableDeclaration(t *testing.T) {
-	scope := main.NewScope(nil)
-	parser := main.NewParser()
+	scope := blulang.NewScope(nil)
+	parser := blulang.NewParser()
 	code := `
 	let a = 10
 	a
 	`
 	program := parser.CreateAST(code)
-	result := main.Eval(program, scope)
+	result := blulang.Eval(program, scope)
 	assert.Equal(t, 10, result.Value())
-	assert.Equal(t, main.VaIntVal, result.Kind())
+	assert.Equal(t, blulang.VaIntVal, result.Kind())
 }
 
 func TestVariableAssignment(t *testing.T) {
-	scope := main.NewScope(nil)
-	parser := main.NewParser()
+	scope := blulang.NewScope(nil)
+	parser := blulang.NewParser()
 	code := `
 	let a = 10
 	let b1 = 20
@@ -42,14 +45,14 @@ func TestVariableAssignment(t *testing.T) {
 	a
 	`
 	program := parser.CreateAST(code)
-	result := main.Eval(program, scope)
+	result := blulang.Eval(program, scope)
 	assert.Equal(t, 200, result.Value())
-	assert.Equal(t, main.VaIntVal, result.Kind())
+	assert.Equal(t, blulang.VaIntVal, result.Kind())
 }
 
 func TestComparisonExpression(t *testing.T) {
-	scope := main.NewGlobalScope()
-	parser := main.NewParser()
+	scope := blulang.NewGlobalScope()
+	parser := blulang.NewParser()
 	sources := []string{
 		"4*3 == 2*6",
 		"1+1 == abs(2-4)*1",
@@ -63,15 +66,15 @@ func TestComparisonExpression(t *testing.T) {
 	}
 	for _, code := range sources {
 		program := parser.CreateAST(code)
-		result := main.Eval(program, scope)
+		result := blulang.Eval(program, scope)
 		assert.Equal(t, true, result.Value())
-		assert.Equal(t, main.VaBoolVal, result.Kind())
+		assert.Equal(t, blulang.VaBoolVal, result.Kind())
 	}
 }
 
 func TestLogicalExpression(t *testing.T) {
-	scope := main.NewGlobalScope()
-	parser := main.NewParser()
+	scope := blulang.NewGlobalScope()
+	parser := blulang.NewParser()
 	sources := []string{
 		"(4 < 3) || (3 < 4)",
 		"(1 == 1) && (1 != 2)",
@@ -82,9 +85,9 @@ func TestLogicalExpression(t *testing.T) {
 	}
 	for _, code := range sources {
 		program := parser.CreateAST(code)
-		result := main.Eval(program, scope)
+		result := blulang.Eval(program, scope)
 		assert.Equalf(t, true, result.Value(), "%v", program)
-		assert.Equal(t, main.VaBoolVal, result.Kind())
+		assert.Equal(t, blulang.VaBoolVal, result.Kind())
 	}
 	falseSources := []string{
 		"(4 < 3) || (5 < 4)",
@@ -96,15 +99,15 @@ func TestLogicalExpression(t *testing.T) {
 	}
 	for _, code := range falseSources {
 		program := parser.CreateAST(code)
-		result := main.Eval(program, scope)
+		result := blulang.Eval(program, scope)
 		assert.Equalf(t, false, result.Value(), "%v", program)
-		assert.Equal(t, main.VaBoolVal, result.Kind())
+		assert.Equal(t, blulang.VaBoolVal, result.Kind())
 	}
 }
 
 func TestFunctionDeclareAndCall(t *testing.T) {
-	scope := main.NewScope(nil)
-	parser := main.NewParser()
+	scope := blulang.NewScope(nil)
+	parser := blulang.NewParser()
 	code := `
 	fn sum (a,b) {
 		a = a*a
@@ -115,14 +118,14 @@ func TestFunctionDeclareAndCall(t *testing.T) {
 	sum(a,b)
 	`
 	program := parser.CreateAST(code)
-	result := main.Eval(program, scope)
+	result := blulang.Eval(program, scope)
 	assert.Equal(t, 101, result.Value())
-	assert.Equal(t, main.VaIntVal, result.Kind())
+	assert.Equal(t, blulang.VaIntVal, result.Kind())
 }
 
 func TestAnonFunctionDeclareAndCall(t *testing.T) {
-	scope := main.NewScope(nil)
-	parser := main.NewParser()
+	scope := blulang.NewScope(nil)
+	parser := blulang.NewParser()
 	code := `
 	let sum = fn (a,b) {
 		a = a*a
@@ -133,14 +136,14 @@ func TestAnonFunctionDeclareAndCall(t *testing.T) {
 	sum(a,b)
 	`
 	program := parser.CreateAST(code)
-	result := main.Eval(program, scope)
+	result := blulang.Eval(program, scope)
 	assert.Equal(t, 101, result.Value())
-	assert.Equal(t, main.VaIntVal, result.Kind())
+	assert.Equal(t, blulang.VaIntVal, result.Kind())
 }
 
 func TestFunctionCallReturn(t *testing.T) {
-	scope := main.NewScope(nil)
-	parser := main.NewParser()
+	scope := blulang.NewScope(nil)
+	parser := blulang.NewParser()
 	code := `
 	fn increase(a) {
 		while a < 100 {
@@ -155,14 +158,39 @@ func TestFunctionCallReturn(t *testing.T) {
 	increase(a)
 	`
 	program := parser.CreateAST(code)
-	result := main.Eval(program, scope)
+	result := blulang.Eval(program, scope)
 	assert.Equal(t, 50, result.Value())
-	assert.Equal(t, main.VaIntVal, result.Kind())
+	assert.Equal(t, blulang.VaIntVal, result.Kind())
+}
+
+func TestFunctionScope(t *testing.T) {
+	scope := blulang.NewGlobalScope()
+	parser := blulang.NewParser()
+	// a function sees the variables where it is declared, not the ones of its caller
+	code := `
+	let unit = "m"
+	fn show(n) {
+		[n, unit, local]
+	}
+	fn caller() {
+		let local = 1
+		let unit = "cm"
+		show(2)
+	}
+	unit = "km"
+	caller()
+	`
+	program := parser.CreateAST(code)
+	result := blulang.Eval(program, scope)
+	assert.Equal(t, `[2, "km", null]`, blulang.Inspect(result))
+
+	_, err := blulang.Execute(parser.CreateAST(`fn area(w, h) { w * h } area(2, 3, 4) + area(2)`), blulang.NewGlobalScope())
+	assert.EqualError(t, err, "runtime error: fn area(w, h) takes 2 arguments but got 1")
 }
 
 func TestConditionalStatement(t *testing.T) {
-	scope := main.NewGlobalScope()
-	parser := main.NewParser()
+	scope := blulang.NewGlobalScope()
+	parser := blulang.NewParser()
 	code := `
 	let a = 1000
 	if 0 == 1 {
@@ -190,14 +218,14 @@ func TestConditionalStatement(t *testing.T) {
 	c + b + a
 	`
 	program := parser.CreateAST(code)
-	result := main.Eval(program, scope)
+	result := blulang.Eval(program, scope)
 	assert.Equal(t, 112, result.Value())
-	assert.Equal(t, main.VaIntVal, result.Kind())
+	assert.Equal(t, blulang.VaIntVal, result.Kind())
 }
 
 func TestWhileLoop(t *testing.T) {
-	scope := main.NewScope(nil)
-	parser := main.NewParser()
+	scope := blulang.NewScope(nil)
+	parser := blulang.NewParser()
 	code := `
 	let a = 1
 	let b = while a != 100 {
@@ -206,14 +234,14 @@ func TestWhileLoop(t *testing.T) {
 	a + b
 	`
 	program := parser.CreateAST(code)
-	result := main.Eval(program, scope)
+	result := blulang.Eval(program, scope)
 	assert.Equal(t, 200, result.Value())
-	assert.Equal(t, main.VaIntVal, result.Kind())
+	assert.Equal(t, blulang.VaIntVal, result.Kind())
 }
 
 func TestWhileLoopBreak(t *testing.T) {
-	scope := main.NewScope(nil)
-	parser := main.NewParser()
+	scope := blulang.NewScope(nil)
+	parser := blulang.NewParser()
 	code := `
 	let a = 1
 	while a != 100 {
@@ -223,62 +251,215 @@ func TestWhileLoopBreak(t *testing.T) {
 	a
 	`
 	program := parser.CreateAST(code)
-	result := main.Eval(program, scope)
+	result := blulang.Eval(program, scope)
 	assert.Equal(t, 50, result.Value())
-	assert.Equal(t, main.VaIntVal, result.Kind())
+	assert.Equal(t, blulang.VaIntVal, result.Kind())
 }
 
 func TestArray(t *testing.T) {
-	scope := main.NewGlobalScope()
-	parser := main.NewParser()
+	scope := blulang.NewGlobalScope()
+	parser := blulang.NewParser()
 	code, err := os.ReadFile("./sample/array.blu")
 	assert.NoError(t, err)
 	program := parser.CreateAST(string(code))
-	result := main.Eval(program, scope)
+	result := blulang.Eval(program, scope)
 	assert.Equal(t, 27, result.Value())
-	assert.Equal(t, main.VaIntVal, result.Kind())
+	assert.Equal(t, blulang.VaIntVal, result.Kind())
 }
 
 func TestVietnamese(t *testing.T) {
-	scope := main.NewGlobalScope()
-	parser := main.NewParser()
+	scope := blulang.NewGlobalScope()
+	parser := blulang.NewParser()
 	code, err := os.ReadFile("./sample/chao.blu")
 	assert.NoError(t, err)
 	program := parser.CreateAST(string(code))
-	result := main.Eval(program, scope)
-	assert.Equal(t, []main.RuntimeVal{main.NewIntVal(12), main.NewIntVal(10), main.NewIntVal(10), main.NewIntVal(32)}, result.Value())
-	assert.Equal(t, main.VaArrayVal, result.Kind())
+	result := blulang.Eval(program, scope)
+	assert.Equal(t, []blulang.RuntimeVal{blulang.NewIntVal(12), blulang.NewIntVal(10), blulang.NewIntVal(10), blulang.NewIntVal(32)}, result.Value())
+	assert.Equal(t, blulang.VaArrayVal, result.Kind())
 }
 
 func TestEnglish(t *testing.T) {
-	scope := main.NewGlobalScope()
-	parser := main.NewParser()
+	scope := blulang.NewGlobalScope()
+	parser := blulang.NewParser()
 	code, err := os.ReadFile("./sample/hello.blu")
 	assert.NoError(t, err)
 	program := parser.CreateAST(string(code))
-	result := main.Eval(program, scope)
-	assert.Equal(t, []main.RuntimeVal{main.NewIntVal(12), main.NewIntVal(10), main.NewIntVal(10), main.NewIntVal(32)}, result.Value())
-	assert.Equal(t, main.VaArrayVal, result.Kind())
+	result := blulang.Eval(program, scope)
+	assert.Equal(t, []blulang.RuntimeVal{blulang.NewIntVal(12), blulang.NewIntVal(10), blulang.NewIntVal(10), blulang.NewIntVal(32)}, result.Value())
+	assert.Equal(t, blulang.VaArrayVal, result.Kind())
 }
 
 func TestFibonacciRecursion(t *testing.T) {
-	scope := main.NewScope(nil)
-	parser := main.NewParser()
+	scope := blulang.NewScope(nil)
+	parser := blulang.NewParser()
 	code, err := os.ReadFile("./sample/fibonacci.blu")
 	assert.NoError(t, err)
 	program := parser.CreateAST(string(code))
-	result := main.Eval(program, scope)
+	result := blulang.Eval(program, scope)
 	assert.Equal(t, 21, result.Value())
-	assert.Equal(t, main.VaIntVal, result.Kind())
+	assert.Equal(t, blulang.VaIntVal, result.Kind())
 }
 
 func TestObject(t *testing.T) {
-	scope := main.NewScope(nil)
-	parser := main.NewParser()
+	scope := blulang.NewScope(nil)
+	parser := blulang.NewParser()
 	code, err := os.ReadFile("./sample/object.blu")
 	assert.NoError(t, err)
 	program := parser.CreateAST(string(code))
-	result := main.Eval(program, scope)
+	result := blulang.Eval(program, scope)
 	assert.Equal(t, 66, result.Value())
-	assert.Equal(t, main.VaIntVal, result.Kind())
+	assert.Equal(t, blulang.VaIntVal, result.Kind())
+}
+
+func TestShebangIsSkipped(t *testing.T) {
+	scope := blulang.NewScope(nil)
+	parser := blulang.NewParser()
+	code := "#!/usr/bin/env blulang\n1 + 2"
+	program := parser.CreateAST(code)
+	result := blulang.Eval(program, scope)
+	assert.Equal(t, 3, result.Value())
+}
+
+func TestSyntaxError(t *testing.T) {
+	parser := blulang.NewParser()
+	sources := map[string]string{
+		"let a = (1 + 2":   "syntax error at 1:14: unexpected end of input, expected ')'",
+		"if 1 == 1 {\n  2": "syntax error at 2:3: unexpected end of input, expected '}'",
+		"let = 2":          "syntax error at 1:5: unexpected '=', expected variable name",
+		"fn f(1) { 1 }":    "syntax error at 1:6: unexpected '1', expected parameter name",
+	}
+	for code, message := range sources {
+		_, err := parser.Parse(code)
+		assert.EqualError(t, err, message)
+	}
+}
+
+func TestRuntimeError(t *testing.T) {
+	parser := blulang.NewParser()
+	program := parser.CreateAST("let a = 1\nlet a = 2")
+	_, err := blulang.Execute(program, blulang.NewScope(nil))
+	assert.EqualError(t, err, "runtime error: variable already defined: a")
+}
+
+func TestFloat(t *testing.T) {
+	scope := blulang.NewGlobalScope()
+	parser := blulang.NewParser()
+	sources := map[string]any{
+		"1.5 + 1":           2.5,
+		"7 / 2.0":           3.5,
+		"0.1 * 3 > 0.2":     true,
+		"2.0 == 2":          true,
+		"abs(0 - 1.25)":     1.25,
+		"let f = 2.5 f - 1": 1.5,
+	}
+	for code, expected := range sources {
+		program := parser.CreateAST(code)
+		result := blulang.Eval(program, scope)
+		assert.Equalf(t, expected, result.Value(), code)
+	}
+}
+
+// vietnameseKeywordCorpus has a program for every Vietnamese keyword,
+// each is run as typed and with its accents decomposed
+var vietnameseKeywordCorpus = map[string]struct {
+	code     string
+	expected any
+}{
+	"cho":       {`cho thứ = 2 thứ`, 2},
+	"hàm":       {`hàm đôi(số) { số * 2 } đôi(3)`, 6},
+	"nếu":       {`nếu 1 == 1 { "đúng" }`, "đúng"},
+	"hay":       {`nếu 1 == 2 { 1 } hay { 2 }`, 2},
+	"khi":       {`cho i = 0 khi i < 5 { i = i + 1 } i`, 5},
+	"trả":       {`hàm f(a) { khi 1 == 1 { nếu a == 5 { a trả } a = a + 1 } } f(0)`, 5},
+	"nghỉ":      {`cho i = 0 khi 1 == 1 { i = i + 1 nếu i == 3 { nghỉ } } i`, 3},
+	"nhập khẩu": {`nhập khẩu "math" là toán toán.abs(0 - 4)`, 4},
+	"xuất khẩu": {`xuất khẩu cho kếtQuả = 7 kếtQuả`, 7},
+	"là":        {`nhập   khẩu "string" là chuỗi chuỗi.upper("đ")`, "Đ"},
+	"kiểm thử":  {`kiểm   thử "bỏ qua" { 1 / 0 } 2`, 2},
+}
+
+func TestNormalizedKeywords(t *testing.T) {
+	for keyword := range blulang.Vietnamese.Keywords {
+		assert.Containsf(t, vietnameseKeywordCorpus, keyword, "no corpus entry for %s", keyword)
+	}
+	for keyword, entry := range vietnameseKeywordCorpus {
+		decomposed := blulang.NormalizeNFD(entry.code)
+		if !isASCII(keyword) {
+			assert.NotEqualf(t, entry.code, decomposed, keyword)
+		}
+		for _, code := range []string{entry.code, decomposed} {
+			interpreter := blulang.New(blulang.Options{})
+			result, err := interpreter.Run(context.Background(), code)
+			assert.NoErrorf(t, err, "%s: %q", keyword, code)
+			value := result.Value()
+			// string literals keep the form they were typed in
+			if text, ok := value.(string); ok {
+				value = blulang.NormalizeNFC(text)
+			}
+			assert.Equalf(t, entry.expected, value, "%s: %q", keyword, code)
+		}
+	}
+}
+
+func TestNormalizedIdentifiers(t *testing.T) {
+	interpreter := blulang.New(blulang.Options{})
+	// declared precomposed, used decomposed
+	result, err := interpreter.Run(context.Background(), "cho thứ = 3 thứ + 1")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, result.Value())
+}
+
+func isASCII(text string) bool {
+	for _, ch := range text {
+		if ch > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
+
+func TestParseTolerant(t *testing.T) {
+	parser := blulang.NewParser()
+	program, errs := parser.ParseTolerant("let a = 1\nlet = 2\nlet b = a + 1\nfn f( {\n  a\n}\nb + 1 ) b")
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	assert.Equal(t, []string{
+		"syntax error at 2:5: unexpected '=', expected variable name",
+		"syntax error at 4:7: unexpected '{', expected parameter name",
+		"syntax error at 7:7: unexpected ')', expected an expression",
+	}, messages)
+	assert.Equal(t, 3, blulang.Eval(program, blulang.NewScope(nil)).Value())
+
+	program, errs = parser.ParseTolerant("; lang: xx\nlet a = 1\na")
+	assert.Len(t, errs, 1)
+	assert.Equal(t, 1, blulang.Eval(program, blulang.NewScope(nil)).Value())
+}
+
+func TestReparse(t *testing.T) {
+	source, err := os.ReadFile("sample/fibonacci.blu")
+	assert.NoError(t, err)
+	dump := func(program blulang.Program) string {
+		var out strings.Builder
+		blulang.DumpAST(&out, program)
+		return out.String()
+	}
+	parser := blulang.NewParser()
+	previous, _ := parser.ParseTolerant(string(source))
+	runes := []rune(string(source))
+	// every edit gives the program a parse from scratch gives
+	for offset := 0; offset < len(runes); offset += 7 {
+		edited := string(runes[:offset]) + "x" + string(runes[offset+1:])
+		reparsed, reparseErrs := parser.Reparse(previous, edited, offset)
+		fresh, freshErrs := parser.ParseTolerant(edited)
+		assert.Equalf(t, dump(fresh), dump(reparsed), "edit at %d", offset)
+		assert.Equalf(t, freshErrs, reparseErrs, "edit at %d", offset)
+	}
+	// an edit right after a statement continues it
+	previous, _ = parser.ParseTolerant("let a = 1 \nprint(a)")
+	reparsed, errs := parser.Reparse(previous, "let a = 1 + 2\nprint(a)", 10)
+	assert.Empty(t, errs)
+	fresh, _ := parser.ParseTolerant("let a = 1 + 2\nprint(a)")
+	assert.Equal(t, dump(fresh), dump(reparsed))
 }
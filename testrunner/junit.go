@@ -0,0 +1,103 @@
+package testrunner
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// The JUnit XML report read by CI servers
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr"`
+	Line      int           `xml:"line,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the results as a JUnit XML report, the tests of each file form a suite
+func WriteJUnit(w io.Writer, results []Result) error {
+	report := junitSuites{}
+	var total time.Duration
+	for _, result := range results {
+		if len(report.Suites) == 0 || report.Suites[len(report.Suites)-1].Name != result.File {
+			report.Suites = append(report.Suites, junitSuite{Name: result.File})
+		}
+		suite := &report.Suites[len(report.Suites)-1]
+		testCase := junitCase{
+			Name: result.Name, ClassName: result.File, File: result.File, Line: result.Pos.Line,
+			Time: seconds(result.Duration), SystemOut: result.Output,
+		}
+		switch result.Status {
+		case Failed:
+			testCase.Failure = problem(result)
+			suite.Failures++
+		case Errored:
+			testCase.Error = problem(result)
+			suite.Errors++
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, testCase)
+		total += result.Duration
+	}
+	for i, suite := range report.Suites {
+		var duration time.Duration
+		for _, result := range results {
+			if result.File == suite.Name {
+				duration += result.Duration
+			}
+		}
+		report.Suites[i].Time = seconds(duration)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+	}
+	report.Time = seconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func problem(result Result) *junitProblem {
+	return &junitProblem{Message: result.Err.Error(), Text: fmt.Sprintf("%s:%v: %v", result.ErrFile, result.ErrPos, result.Err)}
+}
+
+func seconds(duration time.Duration) string {
+	return fmt.Sprintf("%.3f", duration.Seconds())
+}
@@ -0,0 +1,196 @@
+// Package testrunner runs the test blocks of BluLang files. Each test runs the whole file in
+// a global scope of its own, so it only sees what the file declares before it and nothing the
+// other tests did, and a failing test is reported with the statement it failed at.
+package testrunner
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"blulang"
+)
+
+// Suffix ends the names of the files holding tests
+const Suffix = "_test.blu"
+
+// Discover lists the test files of the paths in order, a file is kept as it is and a directory
+// is searched for the files ending with Suffix, skipping the hidden directories
+func Discover(paths ...string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() && file != path && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), Suffix) {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// Options configures the interpreters running the tests
+type Options struct {
+	// Stdin is read by input, what the tests print is kept in the Output of their result
+	Stdin        io.Reader
+	Capabilities blulang.Capability
+	SearchPaths  []string
+	Limits       blulang.Limits
+	// Language, when set, gives the language of the error messages
+	Language *blulang.Locale
+	// Hook, when set, follows the runs of the tests too, with the bodies of ifs when it is
+	// a blulang.BranchHook but without the expressions
+	Hook blulang.Hook
+}
+
+// Status is the outcome of a test
+type Status string
+
+const (
+	// Passed tests ran to their end
+	Passed Status = "pass"
+	// Failed tests stopped on a failing assert or assertEqual
+	Failed Status = "fail"
+	// Errored tests stopped on any other error
+	Errored Status = "error"
+)
+
+// Result is the outcome of one test
+type Result struct {
+	// File is the path of the test file as it was given, Name and Pos the ones of the test block
+	File string
+	Name string
+	Pos  blulang.Position
+	// Status tells whether the test passed, Err is the error it stopped with otherwise
+	Status Status
+	Err    error
+	// ErrFile and ErrPos locate the statement the error happened at, ErrFile is File
+	// unless it happened in an imported module
+	ErrFile string
+	ErrPos  blulang.Position
+	// Output is what the test printed to stdout and stderr
+	Output   string
+	Duration time.Duration
+}
+
+// RunFile runs every test of a file in the order they are written, the error is the one
+// reading or parsing the file
+func RunFile(ctx context.Context, path string, options Options) ([]Result, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	parser := blulang.NewParser()
+	program, err := parser.Parse(string(source))
+	if err != nil {
+		return nil, blulang.LocalizeError(err, cmp.Or(options.Language, parser.Locale()))
+	}
+	absolute, _ := filepath.Abs(path)
+	var results []Result
+	for _, test := range program.Tests() {
+		results = append(results, runTest(ctx, path, absolute, test, options))
+	}
+	return results, nil
+}
+
+func runTest(ctx context.Context, path string, absolute string, test blulang.TestBlock, options Options) Result {
+	var output strings.Builder
+	located := &locator{next: options.Hook}
+	interpreter := blulang.New(blulang.Options{
+		Stdin:        options.Stdin,
+		Stdout:       &output,
+		Stderr:       &output,
+		Limits:       options.Limits,
+		Capabilities: options.Capabilities,
+		SearchPaths:  options.SearchPaths,
+		Language:     options.Language,
+		Hook:         located,
+		Test:         test.Name(),
+	})
+	start := time.Now()
+	_, err := interpreter.RunFile(ctx, path)
+	result := Result{File: path, Name: test.Name(), Pos: test.Pos(), Status: Passed, Err: err, Duration: time.Since(start)}
+	if err != nil {
+		result.Status = Errored
+		var runtimeError blulang.RuntimeError
+		if errors.As(err, &runtimeError) && isAssertion(runtimeError.Code) {
+			result.Status = Failed
+		}
+		result.ErrFile, result.ErrPos = located.file, located.pos
+		if located.file == absolute {
+			result.ErrFile = path
+		}
+	}
+	result.Output = output.String()
+	return result
+}
+
+// isAssertion tells the errors of assert and assertEqual
+func isAssertion(code blulang.MessageCode) bool {
+	return code == blulang.CodeAssertionFailed || code == blulang.CodeAssertionMessage || code == blulang.CodeNotEqual
+}
+
+// locator is a hook keeping the statement being evaluated, the one an error happens at.
+// Functions stopped by an error don't return, so the statement is the one of the innermost call.
+// It passes what it follows to the hook of the options.
+type locator struct {
+	file string
+	pos  blulang.Position
+	next blulang.Hook
+}
+
+func (l *locator) Statement(statement blulang.Statement, stack []*blulang.Frame) {
+	l.locate(stack[len(stack)-1])
+	if l.next != nil {
+		l.next.Statement(statement, stack)
+	}
+}
+
+func (l *locator) Call(stack []*blulang.Frame) {
+	if l.next != nil {
+		l.next.Call(stack)
+	}
+}
+
+// Return goes back to the statement of the caller
+func (l *locator) Return(stack []*blulang.Frame, value blulang.RuntimeVal) {
+	if len(stack) > 1 {
+		l.locate(stack[len(stack)-2])
+	}
+	if l.next != nil {
+		l.next.Return(stack, value)
+	}
+}
+
+func (l *locator) Branch(conditional blulang.ConditionalExpression, taken bool, stack []*blulang.Frame) {
+	if branches, ok := l.next.(blulang.BranchHook); ok {
+		branches.Branch(conditional, taken, stack)
+	}
+}
+
+func (l *locator) locate(frame *blulang.Frame) {
+	l.file, l.pos = frame.File, frame.Pos
+}
@@ -0,0 +1,115 @@
+package testrunner_test
+
+import (
+	"blulang"
+	"blulang/internal/testfiles"
+	"blulang/testrunner"
+	"context"
+	"encoding/xml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiscover(t *testing.T) {
+	dir := t.TempDir()
+	testfiles.WriteFiles(t, dir, map[string]string{
+		"a_test.blu":         "",
+		"a.blu":              "",
+		"lib/b_test.blu":     "",
+		".hidden/c_test.blu": "",
+	})
+	files, err := testrunner.Discover(dir, filepath.Join(dir, "a.blu"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "a_test.blu"), filepath.Join(dir, "lib/b_test.blu"), filepath.Join(dir, "a.blu")}, files)
+	_, err = testrunner.Discover(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestRunFile(t *testing.T) {
+	dir := t.TempDir()
+	testfiles.WriteFiles(t, dir, map[string]string{
+		"helpers.blu": "export fn check(n) {\n    assert(n > 0, \"not positive\")\n}\n",
+		"math_test.blu": `import "helpers.blu"
+let values = [1]
+test "isolated" {
+    values = values + [2]
+    assertEqual(values, [1, 2])
+}
+test "again" {
+    print("count", count(values))
+    assertEqual(values, [1, 2])
+}
+test "helper" {
+    helpers.check(0 - 1)
+}
+test "error" {
+    1 / 0
+}
+`,
+	})
+	path := filepath.Join(dir, "math_test.blu")
+	results, err := testrunner.RunFile(context.Background(), path, testrunner.Options{Capabilities: blulang.AllCapabilities})
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+
+	assert.Equal(t, testrunner.Result{File: path, Name: "isolated", Pos: blulang.Position{Line: 3, Column: 1}, Status: testrunner.Passed},
+		withoutDuration(results[0]))
+
+	again := results[1]
+	assert.Equal(t, testrunner.Failed, again.Status)
+	assert.Equal(t, "count 1\n", again.Output)
+	assert.Equal(t, path, again.ErrFile)
+	assert.Equal(t, blulang.Position{Line: 9, Column: 5}, again.ErrPos)
+	assert.EqualError(t, again.Err, "runtime error: values are not equal:\n  [1]: missing 2")
+
+	// failures in imported modules are located in the module
+	helper := results[2]
+	assert.Equal(t, testrunner.Failed, helper.Status)
+	assert.Equal(t, filepath.Join(dir, "helpers.blu"), helper.ErrFile)
+	assert.Equal(t, blulang.Position{Line: 2, Column: 5}, helper.ErrPos)
+
+	assert.Equal(t, testrunner.Errored, results[3].Status)
+	assert.EqualError(t, results[3].Err, "runtime error: division by zero")
+
+	var junit strings.Builder
+	require.NoError(t, testrunner.WriteJUnit(&junit, results))
+	var report struct {
+		Tests    int `xml:"tests,attr"`
+		Failures int `xml:"failures,attr"`
+		Errors   int `xml:"errors,attr"`
+		Suites   []struct {
+			Name  string `xml:"name,attr"`
+			Cases []struct {
+				Name    string `xml:"name,attr"`
+				Line    int    `xml:"line,attr"`
+				Failure *struct {
+					Message string `xml:"message,attr"`
+					Text    string `xml:",chardata"`
+				} `xml:"failure"`
+			} `xml:"testcase"`
+		} `xml:"testsuite"`
+	}
+	require.NoError(t, xml.Unmarshal([]byte(junit.String()), &report))
+	assert.Equal(t, []int{4, 2, 1}, []int{report.Tests, report.Failures, report.Errors})
+	assert.Equal(t, path, report.Suites[0].Name)
+	assert.Equal(t, 7, report.Suites[0].Cases[1].Line)
+	assert.Equal(t, path+":9:5: runtime error: values are not equal:\n  [1]: missing 2", report.Suites[0].Cases[1].Failure.Text)
+	assert.Nil(t, report.Suites[0].Cases[0].Failure)
+}
+
+func TestRunFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	testfiles.WriteFiles(t, dir, map[string]string{"bad_test.blu": "; lang: vi\nkiểm thử \"a\" {"})
+	_, err := testrunner.RunFile(context.Background(), filepath.Join(dir, "bad_test.blu"), testrunner.Options{})
+	assert.EqualError(t, err, "lỗi cú pháp tại 2:14: chương trình kết thúc đột ngột, cần '}'")
+	_, err = testrunner.RunFile(context.Background(), filepath.Join(dir, "missing_test.blu"), testrunner.Options{})
+	assert.Error(t, err)
+}
+
+func withoutDuration(result testrunner.Result) testrunner.Result {
+	result.Duration = 0
+	return result
+}
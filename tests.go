@@ -0,0 +1,134 @@
+package blulang
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// EvalTestBlock runs the body of a test in a scope of its own when the run selected the test,
+// see Options.Test, the other runs skip it
+func EvalTestBlock(test TestBlock, scope *Scope) RuntimeVal {
+	if scope.module == nil || scope.module.scope != scope {
+		panic(codedError(CodeTestNotTopLevel))
+	}
+	if scope.module.test == "" || scope.module.test != test.name {
+		return NullVal{}
+	}
+	return evalFunctionBody(test.body, NewScope(scope))
+}
+
+// AssertFunc fails with a runtime error unless its condition is true, the optional
+// second argument is shown in the error
+var AssertFunc = NewCheckedFuncVal("assert", 1, 2, func(scope *Scope, args NativeArgs) RuntimeVal {
+	if args.Get(0).Value() == true {
+		return TrueVal
+	}
+	if args.Len() > 1 {
+		panic(codedError(CodeAssertionMessage, args.String(1)))
+	}
+	panic(codedError(CodeAssertionFailed))
+})
+
+// AssertEqualFunc fails with a runtime error listing the differences unless its first
+// argument, the actual value, equals the second, the expected one
+var AssertEqualFunc = NewCheckedFuncVal("assertEqual", 2, 2, func(scope *Scope, args NativeArgs) RuntimeVal {
+	differences := Diff(args.Get(1), args.Get(0))
+	if len(differences) == 0 {
+		return TrueVal
+	}
+	panic(codedError(CodeNotEqual, "  "+strings.Join(differences, "\n  ")))
+})
+
+// Diff compares an actual value to the expected one and describes each difference on a line
+// prefixed with the index or property it is found at. Arrays and objects are compared element
+// by element, functions by their name and parameters, and an int never equals a float.
+// Equal values have no differences.
+func Diff(expected RuntimeVal, actual RuntimeVal) []string {
+	var differences []string
+	diff(&differences, "", expected, actual)
+	return differences
+}
+
+func diff(differences *[]string, path string, expected RuntimeVal, actual RuntimeVal) {
+	switch expectedVal := expected.(type) {
+	case ArrayVal:
+		actualVal, ok := actual.(ArrayVal)
+		if !ok {
+			break
+		}
+		for i := 0; i < max(len(expectedVal.values), len(actualVal.values)); i++ {
+			index := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(actualVal.values):
+				*differences = append(*differences, fmt.Sprintf("%s: missing %s", index, Inspect(expectedVal.values[i])))
+			case i >= len(expectedVal.values):
+				*differences = append(*differences, fmt.Sprintf("%s: unexpected %s", index, Inspect(actualVal.values[i])))
+			default:
+				diff(differences, index, expectedVal.values[i], actualVal.values[i])
+			}
+		}
+		return
+	case ObjectVal:
+		actualVal, ok := actual.(ObjectVal)
+		if !ok {
+			break
+		}
+		var names []string
+		for name := range expectedVal.properties.variables {
+			names = append(names, name)
+		}
+		for name := range actualVal.properties.variables {
+			if expectedVal.properties.variables[name] == nil {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property := path + "." + name
+			expectedProperty, actualProperty := expectedVal.properties.variables[name], actualVal.properties.variables[name]
+			switch {
+			case actualProperty == nil:
+				*differences = append(*differences, fmt.Sprintf("%s: missing %s", property, Inspect(expectedProperty)))
+			case expectedProperty == nil:
+				*differences = append(*differences, fmt.Sprintf("%s: unexpected %s", property, Inspect(actualProperty)))
+			default:
+				diff(differences, property, expectedProperty, actualProperty)
+			}
+		}
+		return
+	}
+
+	if equalValues(expected, actual) {
+		return
+	}
+	shownExpected, shownActual := Inspect(expected), Inspect(actual)
+	if shownExpected == shownActual {
+		// 2 and 2.0 are shown alike
+		shownExpected += fmt.Sprintf(" (%s)", expected.Kind())
+		shownActual += fmt.Sprintf(" (%s)", actual.Kind())
+	}
+	difference := fmt.Sprintf("expected %s, got %s", shownExpected, shownActual)
+	if path != "" {
+		difference = path + ": " + difference
+	}
+	*differences = append(*differences, difference)
+}
+
+// equalValues compares values that aren't arrays or objects
+func equalValues(expected RuntimeVal, actual RuntimeVal) bool {
+	if expected.Kind() != actual.Kind() {
+		return false
+	}
+	switch expectedVal := expected.(type) {
+	case FunctionVal:
+		return Inspect(expected) == Inspect(actual)
+	case NativeFuncVal:
+		return reflect.ValueOf(expectedVal.call).Pointer() == reflect.ValueOf(actual.(NativeFuncVal).call).Pointer()
+	case ArrayVal, ObjectVal:
+		// an array or object compared to a value of another type
+		return false
+	}
+	return expected.Value() == actual.Value()
+}
@@ -0,0 +1,99 @@
+package blulang_test
+
+import (
+	"blulang"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+const testedSource = `let total = 0
+fn add(n) { total = total + n }
+test "adds" {
+    add(2)
+    assertEqual(total, 2)
+}
+test "starts from zero" {
+    assert(total == 0, "total was kept")
+}
+test "fails" {
+    assertEqual({ items: [1, 2], name: "a" }, { items: [1, 3, 4], name: "a", id: 1 })
+}
+total
+`
+
+func TestTestBlocks(t *testing.T) {
+	parser := blulang.NewParser()
+	program, err := parser.Parse(testedSource)
+	assert.NoError(t, err)
+	var names []string
+	for _, test := range program.Tests() {
+		names = append(names, test.Name())
+	}
+	assert.Equal(t, []string{"adds", "starts from zero", "fails"}, names)
+	assert.Equal(t, 3, program.Tests()[0].Pos().Line)
+
+	// runs skip tests unless they select one
+	result, err := blulang.New(blulang.Options{}).Run(context.Background(), testedSource)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.Value())
+	for _, name := range []string{"adds", "starts from zero"} {
+		_, err := blulang.New(blulang.Options{Test: name}).Run(context.Background(), testedSource)
+		assert.NoError(t, err, name)
+	}
+
+	_, err = blulang.New(blulang.Options{Test: "fails"}).Run(context.Background(), testedSource)
+	var runtimeErr blulang.RuntimeError
+	assert.True(t, errors.As(err, &runtimeErr))
+	assert.Equal(t, blulang.CodeNotEqual, runtimeErr.Code)
+	assert.Equal(t, "values are not equal:\n  .id: missing 1\n  .items[1]: expected 3, got 2\n  .items[2]: missing 4", runtimeErr.Message)
+}
+
+func TestTestBlockErrors(t *testing.T) {
+	parser := blulang.NewParser()
+	_, err := parser.Parse(`test { 1 }`)
+	assert.ErrorContains(t, err, "expected test name")
+	_, err = parser.Parse(`test "" { 1 }`)
+	assert.ErrorContains(t, err, "expected test name")
+	_, err = blulang.New(blulang.Options{Test: "inner"}).Run(context.Background(), `fn f() { test "inner" { 1 } } f()`)
+	assert.EqualError(t, err, "runtime error: test is only allowed at the top level of a file")
+}
+
+func TestAssert(t *testing.T) {
+	interpreter := blulang.New(blulang.Options{})
+	sources := map[string]string{
+		`assert(1 == 2)`:                 "runtime error: assertion failed",
+		`assert(false, "not ready")`:     "runtime error: assertion failed: not ready",
+		`assert(1, 2)`:                   "runtime error: assert: argument 2 must be a string, got IntVal",
+		`assertEqual(2, 2.0)`:            "runtime error: values are not equal:\n  expected 2 (FloatVal), got 2 (IntVal)",
+		`assertEqual("a", [1])`:          "runtime error: values are not equal:\n  expected [1], got \"a\"",
+		`assertEqual([[1], 2], [[], 2])`: "runtime error: values are not equal:\n  [0][0]: unexpected 1",
+	}
+	for source, message := range sources {
+		_, err := interpreter.Run(context.Background(), source)
+		assert.EqualErrorf(t, err, message, source)
+	}
+	result, err := interpreter.Run(context.Background(), `assert(true) assertEqual({ a: [1, null] }, { a: [1, null] })`)
+	assert.NoError(t, err)
+	assert.Equal(t, true, result.Value())
+
+	// Vietnamese names the assertions too
+	_, err = interpreter.Run(context.Background(), "; lang: vi\nkhẳngĐịnh(sai, \"sai rồi\")")
+	assert.EqualError(t, err, "lỗi thực thi: khẳng định sai: sai rồi")
+}
+
+func TestDiff(t *testing.T) {
+	interpreter := blulang.New(blulang.Options{})
+	value := func(source string) blulang.RuntimeVal {
+		result, err := interpreter.Run(context.Background(), source)
+		assert.NoError(t, err)
+		return result
+	}
+	assert.Empty(t, blulang.Diff(value(`{ a: { b: [1] } }`), value(`{ a: { b: [1] } }`)))
+	assert.Equal(t, []string{".a.b[0]: expected 1, got \"1\"", ".c: unexpected true"},
+		blulang.Diff(value(`{ a: { b: [1] } }`), value(`{ a: { b: ["1"] }, c: true }`)))
+	assert.Equal(t, "expected fn f(a), got fn g(a)", strings.Join(blulang.Diff(value(`fn f(a) {} f`), value(`fn g(a) {} g`)), ""))
+	assert.Empty(t, blulang.Diff(value(`count`), value(`count`)))
+}
@@ -0,0 +1,205 @@
+// Package trace logs every expression a BluLang run evaluates with its source span and value,
+// and every variable it changes, so students can follow a program step by step. It is a
+// blulang.ExpressionHook, the log is text to read or JSON Lines for visualisation tools.
+package trace
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"blulang"
+)
+
+// Format is the layout of a trace
+type Format int
+
+const (
+	// Text writes one indented line per event, the expressions inside another one
+	// come before it and are indented once more
+	Text Format = iota
+	// JSON writes one JSON object per event
+	JSON
+)
+
+// Event is a line of a JSON trace
+type Event struct {
+	// Event is expression, variable, call, return or output
+	Event string `json:"event"`
+	// Depth is the number of expressions being evaluated around the event
+	Depth int `json:"depth"`
+	// File is the module the event happened in, empty for the traced program
+	File string `json:"file,omitempty"`
+	// Kind, Start, End and Text describe an evaluated expression
+	Kind  blulang.StmtType `json:"kind,omitempty"`
+	Start *Position        `json:"start,omitempty"`
+	End   *Position        `json:"end,omitempty"`
+	Text  string           `json:"text,omitempty"`
+	// Name is the changed variable, Change is declare or assign
+	Name   string `json:"name,omitempty"`
+	Change string `json:"change,omitempty"`
+	// Function is the called or returning function
+	Function string `json:"function,omitempty"`
+	// Value and Type are the value of an expression, a variable or a return
+	Value string `json:"value,omitempty"`
+	Type  string `json:"type,omitempty"`
+	// Output is what the program printed
+	Output string `json:"output,omitempty"`
+}
+
+// Position is a 1-based line and column counted in runes
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Tracer writes the trace of a run
+type Tracer struct {
+	out    io.Writer
+	format Format
+	// main is the traced program and file the module running, the lines of the
+	// files are read to show the text of expressions
+	main  string
+	file  string
+	lines map[string][]string
+	// files are the modules of the expressions being evaluated, an import goes back
+	// to the importing module once the imported one ran
+	files []string
+	depth int
+	err   error
+}
+
+// New creates a tracer writing to out for a program and its source, file is the absolute
+// path of the program, empty when the source doesn't come from a file
+func New(out io.Writer, format Format, file string, source string) *Tracer {
+	return &Tracer{out: out, format: format, main: file, file: file, lines: map[string][]string{file: strings.Split(source, "\n")}}
+}
+
+// Err is the first error writing the trace
+func (t *Tracer) Err() error {
+	return t.err
+}
+
+// Output is the stream the program should print to: the output of the tracer
+// for text, and output events for JSON so every line stays an event
+func (t *Tracer) Output() io.Writer {
+	if t.format == JSON {
+		return output{t}
+	}
+	return t.out
+}
+
+type output struct {
+	tracer *Tracer
+}
+
+func (o output) Write(p []byte) (int, error) {
+	o.tracer.write(Event{Event: "output", Depth: o.tracer.depth, Output: string(p)}, "")
+	return len(p), o.tracer.err
+}
+
+// Statement is part of blulang.Hook, it keeps the module of the statement
+func (t *Tracer) Statement(_ blulang.Statement, stack []*blulang.Frame) {
+	t.file = stack[len(stack)-1].File
+}
+
+func (t *Tracer) Call(stack []*blulang.Frame) {
+	frame := stack[len(stack)-1]
+	t.file = frame.File
+	t.write(Event{Event: "call", Depth: t.depth, File: t.shownFile(), Function: frame.Function}, "call "+frame.Function)
+}
+
+func (t *Tracer) Return(stack []*blulang.Frame, value blulang.RuntimeVal) {
+	function := stack[len(stack)-1].Function
+	shown := blulang.Inspect(value)
+	t.write(Event{Event: "return", Depth: t.depth, File: t.shownFile(), Function: function, Value: shown, Type: string(value.Kind())},
+		fmt.Sprintf("return %s => %s", function, shown))
+	if len(stack) > 1 {
+		t.file = stack[len(stack)-2].File
+	}
+}
+
+func (t *Tracer) Evaluate(blulang.Statement) {
+	t.files = append(t.files, t.file)
+	t.depth++
+}
+
+func (t *Tracer) Evaluated(expression blulang.Statement, value blulang.RuntimeVal) {
+	t.depth--
+	t.file = t.files[t.depth]
+	t.files = t.files[:t.depth]
+	start, end := expression.Pos(), expression.End()
+	// the parser adds expressions that aren't written, such as the true of a '!'
+	if start.Line == 0 {
+		return
+	}
+	text := t.text(start, end)
+	shown := blulang.Inspect(value)
+	span := fmt.Sprintf("%v-%v", start, end)
+	if file := t.shownFile(); file != "" {
+		span = file + ":" + span
+	}
+	t.write(Event{
+		Event: "expression", Depth: t.depth, File: t.shownFile(), Kind: expression.Kind(),
+		Start: &Position{start.Line, start.Column}, End: &Position{end.Line, end.Column},
+		Text: text, Value: shown, Type: string(value.Kind()),
+	}, fmt.Sprintf("%s  %s => %s", span, text, shown))
+}
+
+func (t *Tracer) Variable(name string, value blulang.RuntimeVal, declared bool) {
+	change := "assign"
+	if declared {
+		change = "declare"
+	}
+	shown := blulang.Inspect(value)
+	t.write(Event{Event: "variable", Depth: t.depth, File: t.shownFile(), Name: name, Change: change, Value: shown, Type: string(value.Kind())},
+		fmt.Sprintf("%s %s = %s", change, name, shown))
+}
+
+// write writes an event as JSON or its text indented by its depth
+func (t *Tracer) write(event Event, text string) {
+	if t.err != nil {
+		return
+	}
+	if t.format == JSON {
+		line, err := json.Marshal(event)
+		if err == nil {
+			_, err = fmt.Fprintf(t.out, "%s\n", line)
+		}
+		t.err = err
+		return
+	}
+	_, t.err = fmt.Fprintf(t.out, "%s%s\n", strings.Repeat("  ", event.Depth), text)
+}
+
+// shownFile is the base name of the running module, empty for the traced program
+func (t *Tracer) shownFile() string {
+	if t.file == t.main {
+		return ""
+	}
+	return filepath.Base(t.file)
+}
+
+// text is the source of an expression, only its first line for one spanning several lines
+func (t *Tracer) text(start blulang.Position, end blulang.Position) string {
+	lines, found := t.lines[t.file]
+	if !found {
+		content, err := os.ReadFile(t.file)
+		if err == nil {
+			lines = strings.Split(string(content), "\n")
+		}
+		t.lines[t.file] = lines
+	}
+	if start.Line > len(lines) {
+		return ""
+	}
+	line := []rune(lines[start.Line-1])
+	from := min(start.Column-1, len(line))
+	if end.Line != start.Line {
+		return strings.TrimRight(string(line[from:]), " \t\r") + " ..."
+	}
+	return string(line[from:max(from, min(end.Column-1, len(line)))])
+}
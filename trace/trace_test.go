@@ -0,0 +1,97 @@
+package trace_test
+
+import (
+	"blulang"
+	"blulang/trace"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func run(t *testing.T, tracer *trace.Tracer, source string) {
+	interpreter := blulang.New(blulang.Options{Hook: tracer, Stdout: tracer.Output(), Capabilities: blulang.AllCapabilities})
+	_, err := interpreter.Run(context.Background(), source)
+	assert.NoError(t, err)
+	assert.NoError(t, tracer.Err())
+}
+
+func TestText(t *testing.T) {
+	source := "let b = 8\nwhile b != 10 { b = b + 1 }\n"
+	var out strings.Builder
+	run(t, trace.New(&out, trace.Text, "", source), source)
+	assert.Equal(t, `  1:9-1:10  8 => 8
+  declare b = 8
+1:1-1:10  let b = 8 => 8
+    2:7-2:8  b => 8
+    2:12-2:14  10 => 10
+  2:7-2:14  b != 10 => true
+    2:17-2:18  b => 8
+      2:21-2:22  b => 8
+      2:25-2:26  1 => 1
+    2:21-2:26  b + 1 => 9
+    assign b = 9
+  2:17-2:26  b = b + 1 => 9
+    2:7-2:8  b => 9
+    2:12-2:14  10 => 10
+  2:7-2:14  b != 10 => true
+    2:17-2:18  b => 9
+      2:21-2:22  b => 9
+      2:25-2:26  1 => 1
+    2:21-2:26  b + 1 => 10
+    assign b = 10
+  2:17-2:26  b = b + 1 => 10
+    2:7-2:8  b => 10
+    2:12-2:14  10 => 10
+  2:7-2:14  b != 10 => false
+2:1-2:28  while b != 10 { b = b + 1 } => 10
+`, out.String())
+}
+
+func TestCalls(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "twice.blu"), []byte("export fn twice(n) {\n    n * 2\n}\n"), 0o644))
+	source := "import \"twice.blu\"\n\ntwice.twice(3)\n"
+	main := filepath.Join(dir, "main.blu")
+	assert.NoError(t, os.WriteFile(main, []byte(source), 0o644))
+	var out strings.Builder
+	tracer := trace.New(&out, trace.Text, main, source)
+	_, err := blulang.New(blulang.Options{Hook: tracer, Capabilities: blulang.AllCapabilities}).RunFile(context.Background(), main)
+	assert.NoError(t, err)
+	assert.Equal(t, `      declare twice = fn twice(n)
+    twice.blu:1:8-3:2  fn twice(n) { ... => fn twice(n)
+  twice.blu:1:1-3:2  export fn twice(n) { ... => fn twice(n)
+  declare twice = { twice: fn twice(n) }
+1:1-1:19  import "twice.blu" => { twice: fn twice(n) }
+  3:13-3:14  3 => 3
+  call twice
+  declare n = 3
+    twice.blu:2:5-2:6  n => 3
+    twice.blu:2:9-2:10  2 => 2
+  twice.blu:2:5-2:10  n * 2 => 6
+  return twice => 6
+3:1-3:15  twice.twice(3) => 6
+`, out.String())
+}
+
+func TestJSON(t *testing.T) {
+	source := "let a = [1]\na[0] = !false\nprint(\"x\")\n"
+	var out strings.Builder
+	run(t, trace.New(&out, trace.JSON, "", source), source)
+	var events []trace.Event
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var event trace.Event
+		assert.NoError(t, json.Unmarshal([]byte(line), &event))
+		events = append(events, event)
+	}
+	assert.Equal(t, trace.Event{
+		Event: "expression", Depth: 0, Kind: blulang.StmtVarDeclareExpr,
+		Start: &trace.Position{Line: 1, Column: 1}, End: &trace.Position{Line: 1, Column: 12},
+		Text: "let a = [1]", Value: "[1]", Type: "ArrayVal",
+	}, events[3])
+	assert.Contains(t, events, trace.Event{Event: "variable", Depth: 1, Name: "a", Change: "assign", Value: "[true]", Type: "ArrayVal"})
+	assert.Contains(t, events, trace.Event{Event: "output", Depth: 1, Output: "x\n"})
+}
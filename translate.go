@@ -0,0 +1,116 @@
+package blulang
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Translate rewrites the keywords and builtin names of a source into the language of the target pack.
+// Comments, formatting, strings, properties and the names declared by the program are kept as they are.
+// The source is read with the keywords of its lang header, which is updated, or of the default locales.
+func Translate(source string, target *Locale) (translated string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recoverError(r)
+		}
+	}()
+	parser := NewParser()
+	tokens := parser.CreateAST(source).tokens
+	sourceLocales := sourceLocales(source)
+	sourceKeywords := mergeKeywords(sourceLocales)
+
+	targetKeywords := make(map[TokenType]string)
+	for _, keyword := range sortedKeys(target.Keywords) {
+		if _, found := targetKeywords[target.Keywords[keyword]]; !found {
+			targetKeywords[target.Keywords[keyword]] = keyword
+		}
+	}
+	// the English name of every builtin by its name in the languages of the source
+	builtins := make(map[string]string)
+	for _, locale := range sourceLocales {
+		for _, builtin := range builtinNames() {
+			builtins[locale.builtinName(builtin)] = builtin
+		}
+	}
+	declared := declaredNames(tokens)
+
+	runeArr := []rune(source)
+	var builder strings.Builder
+	last := 0
+	replace := func(token Token, text string) {
+		builder.WriteString(string(runeArr[last:token.start]))
+		builder.WriteString(text)
+		last = token.end
+	}
+	for i, token := range tokens {
+		if _, isKeyword := sourceKeywords[token.value]; isKeyword && token.name != TkIdentifier {
+			keyword, found := targetKeywords[token.name]
+			if !found {
+				panic(NewSyntaxError(token.pos, CodeNoTargetKeyword, target.Name, token.value))
+			}
+			replace(token, keyword)
+			continue
+		}
+		if token.name != TkIdentifier {
+			continue
+		}
+		// properties and object keys are names chosen by the program
+		if (i > 0 && tokens[i-1].name == TkDot) || (i+1 < len(tokens) && tokens[i+1].name == TKColon) {
+			continue
+		}
+		if builtin, found := builtins[token.value]; found && !declared[token.value] {
+			replace(token, target.builtinName(builtin))
+			continue
+		}
+		if _, clash := target.Keywords[token.value]; clash {
+			panic(NewSyntaxError(token.pos, CodeKeywordClash, token.value, target.Name))
+		}
+	}
+	builder.WriteString(string(runeArr[last:]))
+	translated = builder.String()
+
+	if _, headerLine := headerLocale(source); headerLine > 0 {
+		lines := strings.SplitAfter(translated, "\n")
+		lines[headerLine-1] = headerName.ReplaceAllString(lines[headerLine-1], "${1}"+target.Name)
+		translated = strings.Join(lines, "")
+	}
+	return translated, nil
+}
+
+var headerName = regexp.MustCompile(`(lang:\s*)\S+`)
+
+// builtinNames are the English names of every builtin
+func builtinNames() []string {
+	builtins := newBuiltins(Environment{Capabilities: AllCapabilities})
+	return append(sortedKeys(builtins), "args")
+}
+
+// declaredNames are the variables, functions, parameters and import aliases declared by the program
+func declaredNames(tokens []Token) map[string]bool {
+	declared := make(map[string]bool)
+	inParameters := false
+	for i, token := range tokens {
+		switch {
+		case token.name == TkDeclareVar || token.name == TkDeclareFunc || token.name == TkAs:
+			if i+1 < len(tokens) && tokens[i+1].name == TkIdentifier {
+				declared[tokens[i+1].value] = true
+			}
+			inParameters = token.name == TkDeclareFunc
+		case inParameters && token.name == TkIdentifier:
+			declared[token.value] = true
+		case token.name == TkOpenCurly:
+			inParameters = false
+		}
+	}
+	return declared
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
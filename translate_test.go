@@ -0,0 +1,45 @@
+package blulang_test
+
+import (
+	"blulang"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestTranslate(t *testing.T) {
+	sources := map[string]struct {
+		target   *blulang.Locale
+		expected string
+	}{
+		"let a = if 1 == 1 { print(true) } else { 0 } ; if": {blulang.Vietnamese, "cho a = nếu 1 == 1 { in(đúng) } hay { 0 } ; if"},
+		"nhập   khẩu \"math\" là m":                         {blulang.English, "import \"math\" as m"},
+		"; lang: vi\ncho a = thamsố":                        {blulang.English, "; lang: en\nlet a = args"},
+		"let o = { in: 1 } o.in":                            {blulang.English, "let o = { in: 1 } o.in"},
+		"fn f(in) { in + abs(1) }":                          {blulang.English, "fn f(in) { in + abs(1) }"},
+		"xuất khẩu hàm f() { nghỉ }":                        {blulang.English, "export fn f() { break }"},
+	}
+	for source, c := range sources {
+		translated, err := blulang.Translate(source, c.target)
+		assert.NoErrorf(t, err, source)
+		assert.Equalf(t, c.expected, translated, source)
+	}
+}
+
+func TestTranslateRoundTrip(t *testing.T) {
+	source, err := os.ReadFile("sample/hello.blu")
+	assert.NoError(t, err)
+	vietnamese, err := blulang.Translate(string(source), blulang.Vietnamese)
+	assert.NoError(t, err)
+	assert.NotEqual(t, string(source), vietnamese)
+	english, err := blulang.Translate(vietnamese, blulang.English)
+	assert.NoError(t, err)
+	assert.Equal(t, string(source), english)
+}
+
+func TestTranslateErrors(t *testing.T) {
+	_, err := blulang.Translate("; lang: en\nlet cho = 1", blulang.Vietnamese)
+	assert.EqualError(t, err, "syntax error at 2:5: 'cho' is a keyword in vi, rename it before translating")
+	_, err = blulang.Translate("let a = (1", blulang.Vietnamese)
+	assert.EqualError(t, err, "syntax error at 1:10: unexpected end of input, expected ')'")
+}
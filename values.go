@@ -1,9 +1,10 @@
-package main
+package blulang
 
 type ValueType string
 
 const (
 	VaIntVal        ValueType = "IntVal"
+	VaFloatVal      ValueType = "FloatVal"
 	VaBoolVal       ValueType = "BoolVal"
 	VaNullVal       ValueType = "NullVal"
 	VaStringVal     ValueType = "StringVal"
@@ -34,6 +35,32 @@ func NewIntVal(value int) IntVal {
 	return IntVal{value: value}
 }
 
+type FloatVal struct {
+	value float64
+}
+
+func (v FloatVal) Kind() ValueType {
+	return VaFloatVal
+}
+func (v FloatVal) Value() any {
+	return v.value
+}
+
+func NewFloatVal(value float64) FloatVal {
+	return FloatVal{value: value}
+}
+
+// toFloat gives the value of an IntVal or FloatVal as a float
+func toFloat(val RuntimeVal) (float64, bool) {
+	switch v := val.(type) {
+	case IntVal:
+		return float64(v.value), true
+	case FloatVal:
+		return v.value, true
+	}
+	return 0, false
+}
+
 type BoolVal struct {
 	value bool
 }
@@ -94,6 +121,8 @@ type FunctionVal struct {
 	name      string
 	arguments []Identifier
 	body      []Statement
+	// closure is the scope the function was declared in
+	closure *Scope
 }
 
 func (v FunctionVal) Kind() ValueType {
@@ -103,11 +132,12 @@ func (v FunctionVal) Value() any {
 	return v
 }
 
-func NewFuncVal(name string, args []Identifier, body []Statement) FunctionVal {
+func NewFuncVal(name string, args []Identifier, body []Statement, closure *Scope) FunctionVal {
 	return FunctionVal{
 		name:      name,
 		arguments: args,
 		body:      body,
+		closure:   closure,
 	}
 }
 